@@ -1,22 +1,38 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/KromaEnergia/api-consultor/internal/assinatura"
 	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/busca"
 	"github.com/KromaEnergia/api-consultor/internal/calculocomissao"
+	"github.com/KromaEnergia/api-consultor/internal/cliente"
 	"github.com/KromaEnergia/api-consultor/internal/comentario"
 	"github.com/KromaEnergia/api-consultor/internal/comercial"
 	"github.com/KromaEnergia/api-consultor/internal/consultor"
 	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/indicacao"
 	"github.com/KromaEnergia/api-consultor/internal/models"
 	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
 	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
+	"github.com/KromaEnergia/api-consultor/internal/pdf"
 	"github.com/KromaEnergia/api-consultor/internal/produtos"
+	"github.com/KromaEnergia/api-consultor/internal/relatorio"
+	"github.com/KromaEnergia/api-consultor/internal/simulacao"
+	"github.com/KromaEnergia/api-consultor/internal/sla"
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+	"github.com/KromaEnergia/api-consultor/internal/tarefa"
+	"github.com/KromaEnergia/api-consultor/internal/unidade"
+	"github.com/KromaEnergia/api-consultor/internal/upload"
 	"github.com/KromaEnergia/api-consultor/internal/utils/db"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -74,21 +90,79 @@ func main() {
 	if err := database.AutoMigrate(
 		&comercial.Comercial{},
 		&consultor.Consultor{},
+		&models.Cliente{},
+		&models.ClienteContato{},
+		&models.ClienteDocumento{},
 		&models.Negociacao{},
+		&models.NegociacaoParticipante{},
 		&models.Comentario{},
 		&contrato.Contrato{},
+		&contrato.Aditivo{},
 		&produtos.Produto{},
 		&calculocomissao.CalculoComissao{},
 		&parcelacomissao.ParcelaComissao{},
 		&auth.RefreshToken{},
+		&models.NegociacaoStatusHistorico{},
+		&models.ConflitoCNPJ{},
+		&models.TipoDocumento{},
+		&models.NegociacaoDocumento{},
+		&tarefa.Tarefa{},
+		&evento.Evento{},
+		&unidade.UnidadeConsumidora{},
+		&unidade.ConsumoMensal{},
+		&simulacao.Tarifa{},
+		&simulacao.Estudo{},
+		&pdf.Job{},
+		&indicacao.Lead{},
+		&sla.Prazo{},
+		&assinatura.Envelope{},
+		&pdf.ModeloContrato{},
+		&pdf.DocumentoContrato{},
 	); err != nil {
 		log.Fatal("Erro no AutoMigrate: ", err)
 	}
+	if err := negociacao.Migrate(database); err != nil {
+		log.Fatal("Erro ao migrar status das negociações: ", err)
+	}
+	if err := calculocomissao.Migrate(database); err != nil {
+		log.Fatal("Erro ao migrar parcelas de comissão: ", err)
+	}
+	if err := documento.Migrate(database); err != nil {
+		log.Fatal("Erro ao migrar documentos das negociações: ", err)
+	}
+	if err := cliente.Migrate(database); err != nil {
+		log.Fatal("Erro ao criar clientes a partir das negociações: ", err)
+	}
+	if err := busca.Migrate(database); err != nil {
+		log.Fatal("Erro ao criar índices de busca: ", err)
+	}
+	if err := evento.Migrate(database); err != nil {
+		log.Fatal("Erro ao popular a linha do tempo: ", err)
+	}
+	if err := indicacao.Migrate(database); err != nil {
+		log.Fatal("Erro ao gerar códigos de indicação: ", err)
+	}
+	if err := sla.Migrate(database); err != nil {
+		log.Fatal("Erro ao popular prazos de SLA: ", err)
+	}
+	if err := contrato.Migrate(database); err != nil {
+		log.Fatal("Erro ao migrar vínculo dos contratos: ", err)
+	}
+	if err := assinatura.Migrate(database); err != nil {
+		log.Fatal("Erro ao criar índice de envelopes em andamento: ", err)
+	}
+
+	// -------- Storage de arquivos (STORAGE_DRIVER=local|s3) --------
+	store, err := storage.NewFromEnv(context.Background())
+	if err != nil {
+		log.Fatal("Erro ao configurar storage: ", err)
+	}
 
 	// -------- Instancia handlers/repos --------
 	consultorHandler := consultor.NewHandler(database)
 	comercialHandler := comercial.NewHandler(database)
-	negHandler := negociacao.NewHandler(database)
+	notif := notificacao.NewFromEnv()
+	negHandler := negociacao.NewHandler(database, notif)
 	contratoHandler := contrato.NewHandler(database)
 
 	prodRepo := produtos.NewRepository(database)
@@ -101,6 +175,38 @@ func main() {
 	parcelaHandler := parcelacomissao.NewHandler(parcelaRepo)
 
 	comentHandler := comentario.NewHandler(database)
+	uploadHandler := upload.NewHandler(database, store)
+	docHandler := documento.NewHandler(database, notif)
+	relHandler := relatorio.NewHandler(database)
+	buscaHandler := busca.NewHandler(database)
+	tarefaHandler := tarefa.NewHandler(database)
+	eventoHandler := evento.NewHandler(database)
+	clienteHandler := cliente.NewHandler(database, notif)
+	unidadeHandler := unidade.NewHandler(database)
+	simulacaoHandler := simulacao.NewHandler(database)
+	pdfProcessador := pdf.NewProcessadorFromEnv(database, store, notif)
+	pdfHandler := pdf.NewHandler(database, pdfProcessador)
+	indicacaoHandler := indicacao.NewHandler(database, notif)
+	slaHandler := sla.NewHandler(database)
+
+	// -------- Assinatura eletrônica (ASSINATURA_PROVEDOR=fake|api) --------
+	provedorAssinatura, err := assinatura.NewFromEnv()
+	if err != nil {
+		log.Fatal("Erro ao configurar assinatura eletrônica: ", err)
+	}
+	assinaturaHandler := assinatura.NewHandler(database, provedorAssinatura, store, notif)
+
+	// -------- Lembretes de tarefas (background) --------
+	tarefa.NewAgendadorFromEnv(database, notif).Iniciar(context.Background())
+
+	// -------- Geração de PDFs (background) --------
+	pdfProcessador.Iniciar(context.Background())
+
+	// -------- Prazos (SLA) por etapa: avisos e cancelamento de abandonadas (background) --------
+	sla.NewMonitorFromEnv(database, notif).Iniciar(context.Background())
+
+	// -------- Ciclo de vida dos contratos: vigência e avisos de vencimento (background) --------
+	contrato.NewMonitorFromEnv(database, notif).Iniciar(context.Background())
 
 	// -------- Router --------
 	r := mux.NewRouter()
@@ -111,6 +217,11 @@ func main() {
 		_, _ = w.Write([]byte("ok"))
 	}).Methods(http.MethodGet)
 
+	// Arquivos do storage local (em produção as URLs apontam para o bucket)
+	if local, ok := store.(*storage.Local); ok {
+		r.PathPrefix("/arquivos/").Handler(http.StripPrefix("/arquivos/", local.Handler())).Methods("GET")
+	}
+
 	// ---------- Rotas públicas ----------
 	r.HandleFunc("/.well-known/jwks.json", auth.JWKSHandler).Methods("GET")
 	r.HandleFunc("/auth/refresh", auth.RefreshHTTPHandler(database)).Methods("POST")
@@ -119,6 +230,12 @@ func main() {
 	r.HandleFunc("/consultores", consultorHandler.CriarConsultor).Methods("POST")
 	r.HandleFunc("/comerciais/login", comercialHandler.Login).Methods("POST")
 	r.HandleFunc("/comerciais", comercialHandler.Create).Methods("POST")
+	// Link de indicação do consultor: GET devolve o token do formulário; POST cria o lead
+	r.HandleFunc("/publico/indicacoes/{codigo}", indicacaoHandler.Formulario).Methods("GET")
+	r.HandleFunc("/publico/indicacoes/{codigo}/leads", indicacaoHandler.EnviarLead).Methods("POST")
+
+	// Callback do provedor de assinatura (autenticado pelo HMAC no header X-Assinatura-Hmac)
+	r.HandleFunc("/publico/assinaturas/callback", assinaturaHandler.Callback).Methods("POST")
 
 	// ---------- Rotas protegidas ----------
 	authRoutes := r.PathPrefix("").Subrouter()
@@ -134,6 +251,7 @@ func main() {
 	// Comercial (autenticado)
 	authRoutes.HandleFunc("/comerciais/me", comercialHandler.Me).Methods("GET")
 	authRoutes.HandleFunc("/comerciais/{id:[0-9]+}", comercialHandler.GetByID).Methods("GET")
+	authRoutes.HandleFunc("/comerciais/{id:[0-9]+}/foto", uploadHandler.FotoComercial).Methods("POST") // multipart: arquivo
 
 	// Consultores (autenticado)
 	consultorRoutes := authRoutes.PathPrefix("/consultores").Subrouter()
@@ -147,6 +265,7 @@ func main() {
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/solicitar-cnpj", consultorHandler.SolicitarAlteracaoCNPJ).Methods("PUT")
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/gerenciar-cnpj", consultorHandler.GerenciarAlteracaoCNPJ).Methods("POST")
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/termo-parceria", consultorHandler.AtualizarTermoDeParceria).Methods("PUT")
+	consultorRoutes.HandleFunc("/{id:[0-9]+}/foto", uploadHandler.FotoConsultor).Methods("POST") // multipart: arquivo
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/solicitar-email", consultorHandler.SolicitarAlteracaoEmail).Methods("PUT")
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/gerenciar-email", consultorHandler.GerenciarAlteracaoEmail).Methods("POST")
 	consultorRoutes.HandleFunc("/", consultorHandler.ListarConsultoresSimples).Methods("GET")
@@ -156,22 +275,84 @@ func main() {
 	consultorRoutes.HandleFunc("/{id:[0-9]+}/dados-bancarios", consultorHandler.DeleteDadosBancariosHandler).Methods("DELETE")
 
 	// -------- Negociações --------
-	authRoutes.HandleFunc("/negociacoes", negHandler.Criar).Methods("POST")
+	authRoutes.HandleFunc("/negociacoes", negHandler.Listar).Methods("GET")         // resumo paginado; ?status=&uf=&kromaTake=&de=&ate=&consultorId=&clienteId=&include=&pagina=&porPagina=
+	authRoutes.HandleFunc("/negociacoes", negHandler.Criar).Methods("POST")         // "clienteId" opcional; sem ele o cliente é achado ou criado pelo CNPJ
+	authRoutes.HandleFunc("/negociacoes/busca", buscaHandler.Buscar).Methods("GET") // ?q=...&pagina=1&porPagina=20 (consultor: as próprias; comercial: o time)
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}", negHandler.BuscarPorID).Methods("GET")
 	authRoutes.HandleFunc("/consultores/{id:[0-9]+}/negociacoes", negHandler.ListarPorConsultor).Methods("GET")
-	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}", negHandler.Atualizar).Methods("PUT")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}", negHandler.Atualizar).Methods("PUT")          // exige If-Match (ETag do GET)
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}", negHandler.AtualizarParcial).Methods("PATCH") // exige If-Match; só os campos enviados
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}", negHandler.Deletar).Methods("DELETE")
 
+	// Link de indicação do consultor (o próprio ou admin)
+	authRoutes.HandleFunc("/consultores/{id:[0-9]+}/indicacao", indicacaoHandler.Meu).Methods("GET")              // código, link e leads recebidos
+	authRoutes.HandleFunc("/consultores/{id:[0-9]+}/indicacao/renovar", indicacaoHandler.Renovar).Methods("POST") // invalida o link antigo
+
 	// Arquivos livres (array genérico)
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/arquivos", negHandler.AdicionarArquivos).Methods("POST") // body: { "urls": ["...","..."] }
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/arquivos/{idx:[0-9]+}", negHandler.RemoverArquivo).Methods("DELETE")
 
 	// Status da negociação
-	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/status", negHandler.AtualizarStatus).Methods("PATCH") // body: { "status": "..." }
-
-	// ===== Anexos SIMPLES (url + status opcional) =====
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/status", negHandler.AtualizarStatus).Methods("PATCH") // body: { "status": "...", "motivo": "..." }
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/status/historico", negHandler.HistoricoStatus).Methods("GET")
+
+	// Transferência para outro consultor (comercial/admin). Parcelas pagas ficam com o anterior
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/transferir", negHandler.Transferir).Methods("POST") // body: { "consultorId": 7, "motivo": "...", "comissao": "mover" | "dividir", "percentualNovo": 50 }
+	// Venda conjunta: divisão da comissão entre consultores (percentuais somam 100; PUT só comercial/admin)
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/participantes", negHandler.ListarParticipantes).Methods("GET")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/participantes", negHandler.DefinirParticipantes).Methods("PUT") // body: { "participantes": [ { "consultorId": 3, "percentual": 60 }, ... ] }
+
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/timeline", eventoHandler.Timeline).Methods("GET") // ?pagina=&porPagina=&tipo=status_alterado,comentario_criado
+
+	// -------- Clientes --------
+	authRoutes.HandleFunc("/clientes", clienteHandler.Listar).Methods("GET") // ?q=nome ou CNPJ&pagina=&porPagina= (consultor: clientes com negociação sua)
+	authRoutes.HandleFunc("/clientes", clienteHandler.Criar).Methods("POST") // comercial/admin
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}", clienteHandler.BuscarPorID).Methods("GET")
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}", clienteHandler.Atualizar).Methods("PUT")    // comercial/admin; copia os dados básicos para as negociações do cliente
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/360", clienteHandler.Visao360).Methods("GET") // negociações, contratos e comissões do cliente
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/contatos", clienteHandler.CriarContato).Methods("POST")
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/contatos/{contatoId:[0-9]+}", clienteHandler.AtualizarContato).Methods("PUT")
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/contatos/{contatoId:[0-9]+}", clienteHandler.DeletarContato).Methods("DELETE")
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/documentos", clienteHandler.ListarDocumentos).Methods("GET")
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}", clienteHandler.DefinirDocumento).Methods("PUT")          // comercial/admin; body: { "url": "...", "status": "..." }
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}", clienteHandler.RemoverDocumento).Methods("DELETE")       // comercial/admin
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}/status", clienteHandler.RevisarDocumento).Methods("PATCH") // comercial: { "status": "Validado"|"Rejeitado", "motivo": "..." }
+
+	// -------- Unidades consumidoras e histórico de consumo --------
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/unidades", unidadeHandler.ListarPorCliente).Methods("GET") // com resumo dos últimos 12 meses
+	authRoutes.HandleFunc("/clientes/{id:[0-9]+}/unidades", unidadeHandler.Criar).Methods("POST")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/unidades", unidadeHandler.ListarPorNegociacao).Methods("GET")
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}", unidadeHandler.BuscarPorID).Methods("GET")
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}", unidadeHandler.Atualizar).Methods("PUT")
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}", unidadeHandler.Deletar).Methods("DELETE")
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}/consumos", unidadeHandler.Historico).Methods("GET")             // ?meses=12
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}/consumos", unidadeHandler.SalvarConsumos).Methods("PUT")        // body: [ { "referencia": "2024-05", ... } ]
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}/consumos/importar", unidadeHandler.ImportarCSV).Methods("POST") // multipart "arquivo" ou text/csv
+	authRoutes.HandleFunc("/unidades/{id:[0-9]+}/consumos/{referencia:[0-9]{4}-[0-9]{2}}", unidadeHandler.RemoverConsumo).Methods("DELETE")
+
+	// -------- Tarifas e estudos de economia (simulação) --------
+	authRoutes.HandleFunc("/tarifas", simulacaoHandler.ListarTarifas).Methods("GET")                            // ?distribuidora=&subgrupo=&modalidade=&vigentes=true
+	authRoutes.HandleFunc("/tarifas", simulacaoHandler.CriarTarifa).Methods("POST")                             // comercial: nova versão (encerra a anterior)
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/estudos/simular", simulacaoHandler.Simular).Methods("POST") // body: { "precoEnergiaMWh": 210, "descontoTUSD": 50, "tributos": 25, ... }
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/estudos", simulacaoHandler.Criar).Methods("POST")           // simula e salva a próxima versão
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/estudos", simulacaoHandler.Listar).Methods("GET")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/estudos/{versao:[0-9]+}", simulacaoHandler.BuscarPorVersao).Methods("GET")
+
+	// -------- PDFs (geração assíncrona; acompanhar em /pdfs/{id}) --------
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/pdfs", pdfHandler.ListarPorNegociacao).Methods("GET")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/pdfs/proposta", pdfHandler.GerarProposta).Methods("POST")                               // vira o documento "proposta"
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/estudos/{versao:[0-9]+}/pdf", pdfHandler.GerarEstudo).Methods("POST")                   // vira o estudo de viabilidade
+	authRoutes.HandleFunc("/consultores/{id:[0-9]+}/extratos/{competencia:[0-9]{4}-[0-9]{2}}/pdf", pdfHandler.GerarExtrato).Methods("POST") // anexo das parcelas do mês
+	authRoutes.HandleFunc("/pdfs/{id:[0-9]+}", pdfHandler.BuscarPorID).Methods("GET")
+
+	// ===== Conflitos de CNPJ (fila de revisão - admin) =====
+	authRoutes.HandleFunc("/conflitos-cnpj", negHandler.ListarConflitosCNPJ).Methods("GET")                        // ?status=Pendente|Mantido|Cancelado|todos
+	authRoutes.HandleFunc("/conflitos-cnpj/{id:[0-9]+}/resolver", negHandler.ResolverConflitoCNPJ).Methods("POST") // body: { "decisao": "manter"|"cancelar", "observacao": "..." }
+
+	// ===== Anexos SIMPLES (rotas legadas; gravam em negociacao_documentos) =====
 	// Body esperado: { "url": "https://...", "status": true }  // "status" é opcional; se omitido, só atualiza a URL
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/logo", negHandler.PatchLogo).Methods("PATCH")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/logo", uploadHandler.LogoNegociacao).Methods("POST") // multipart: arquivo
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/anexo-estudo", negHandler.PatchAnexoEstudo).Methods("PATCH")
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/contrato-kc", negHandler.PatchContratoKC).Methods("PATCH")
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/anexo-contrato-social", negHandler.PatchAnexoContratoSocial).Methods("PATCH")
@@ -179,6 +360,40 @@ func main() {
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/anexo-representante-legal", negHandler.PatchAnexoRepresentanteLegal).Methods("PATCH")
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/anexo-estudo-viabilidade", negHandler.PatchAnexoEstudoDeViabilidade).Methods("PATCH")
 
+	// ===== Documentos (checklist configurável) =====
+	authRoutes.HandleFunc("/tipos-documento", docHandler.ListarTipos).Methods("GET")                                  // admin: ?todos=1 inclui inativos
+	authRoutes.HandleFunc("/tipos-documento", docHandler.CriarTipo).Methods("POST")                                   // admin
+	authRoutes.HandleFunc("/tipos-documento/{id:[0-9]+}", docHandler.AtualizarTipo).Methods("PUT")                    // admin
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/documentos", docHandler.Checklist).Methods("GET")                 // checklist com status de cada tipo
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}", docHandler.Definir).Methods("PUT") // body: { "url": "...", "status": "..." }
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}", docHandler.Remover).Methods("DELETE")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}/status", docHandler.Revisar).Methods("PATCH")                // comercial: { "status": "Validado"|"Rejeitado", "motivo": "..." }
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/documentos/{tipo:[a-z0-9_]+}/arquivo", uploadHandler.DocumentoNegociacao).Methods("POST") // multipart: arquivo
+
+	// ===== Tarefas (follow-ups) =====
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/tarefas", tarefaHandler.ListarPorNegociacao).Methods("GET")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/tarefas", tarefaHandler.Criar).Methods("POST") // body: { "titulo", "descricao", "vencimento", "responsavelTipo", "responsavelId" }
+	authRoutes.HandleFunc("/tarefas/minhas", tarefaHandler.Minhas).Methods("GET")                  // ?filtro=hoje|atrasadas|abertas
+	authRoutes.HandleFunc("/tarefas/{id:[0-9]+}", tarefaHandler.Atualizar).Methods("PUT")
+	authRoutes.HandleFunc("/tarefas/{id:[0-9]+}", tarefaHandler.Deletar).Methods("DELETE")
+	authRoutes.HandleFunc("/tarefas/{id:[0-9]+}/concluir", tarefaHandler.Concluir).Methods("PATCH") // body opcional: { "concluida": false } reabre
+
+	// ===== Relatórios (comercial/admin) =====
+	// Filtros: ?de=AAAA-MM-DD&ate=AAAA-MM-DD&comercialId=&consultorId=&uf=&tipoProduto=&origem=cadastro|indicacao|renovacao
+	authRoutes.HandleFunc("/relatorios/pipeline", relHandler.Pipeline).Methods("GET")
+	authRoutes.HandleFunc("/relatorios/pipeline/status", relHandler.PorStatus).Methods("GET")
+	authRoutes.HandleFunc("/relatorios/pipeline/conversao", relHandler.Conversao).Methods("GET")
+	authRoutes.HandleFunc("/relatorios/pipeline/tempo", relHandler.Tempo).Methods("GET")
+	authRoutes.HandleFunc("/relatorios/indicacoes", relHandler.Indicacoes).Methods("GET") // leads e conversão por consultor indicador
+
+	// de/ate filtram o fim do suprimento (padrão: próximos 180 dias)
+	authRoutes.HandleFunc("/relatorios/contratos/vencendo", relHandler.ContratosVencendo).Methods("GET")
+
+	// ===== Prazos (SLA) por etapa =====
+	authRoutes.HandleFunc("/sla/prazos", slaHandler.ListarPrazos).Methods("GET")
+	authRoutes.HandleFunc("/sla/prazos/{status}", slaHandler.AtualizarPrazo).Methods("PUT") // body: { "diasAlerta": 10, "diasCancelamento": 90, "ativo": true } (admin)
+	authRoutes.HandleFunc("/sla/em-risco", slaHandler.ListarEmRisco).Methods("GET")         // ?comercialId= (admin); comercial vê o próprio time
+
 	// ===== Anexo Fatura (múltiplos itens + status textual) =====
 	// Adicionar item: body: { "url": "https://..." }
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/anexo-fatura/itens", negHandler.PostFaturaItem).Methods("POST")
@@ -197,10 +412,49 @@ func main() {
 	// -------- Contratos --------
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/contrato", contratoHandler.CriarParaNegociacao).Methods("POST")
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/contrato", contratoHandler.BuscarPorNegociacao).Methods("GET")
+	// Próprio consultor, comercial do time ou admin
 	authRoutes.HandleFunc("/consultores/{id:[0-9]+}/contratos", contratoHandler.ListarPorConsultor).Methods("GET")
-	authRoutes.HandleFunc("/contratos/{id:[0-9]+}", contratoHandler.Atualizar).Methods("PUT")
+
+	// Todos os contratos da negociação (gestão e energia); filtros: ?tipo=&status=
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/contratos", negHandler.ListarContratos).Methods("GET")
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}", contratoHandler.Atualizar).Methods("PUT")          // exige If-Match
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}", contratoHandler.AtualizarParcial).Methods("PATCH") // exige If-Match; só os campos enviados
 	authRoutes.HandleFunc("/contratos/{id:[0-9]+}", contratoHandler.Deletar).Methods("DELETE")
 
+	// Aditivos: body: { "vigenciaDesde": "...", "alteracoes": { ... }, "documentoUrl": "...", "signatario": "..." } (comercial)
+	// Depois do primeiro aditivo, PUT/PATCH não alteram mais valor, datas, fee e pagamento
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/aditivos", contratoHandler.RegistrarAditivo).Methods("POST")
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/aditivos", contratoHandler.ListarAditivos).Methods("GET")
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/versoes", contratoHandler.ListarVersoes).Methods("GET")
+
+	// Cálculo de comissão derivado das condições do contrato; ?preview=true só simula
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/calculo-comissao", calcHandler.GerarDoContrato).Methods("POST")
+
+	// Renovação: abre negociação de renovação e contrato sucessor "Em Renovação"
+	// body opcional: { "inicioSuprimento": "...", "fimSuprimento": "...", "valor": 1000 }
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/renovar", negHandler.RenovarContrato).Methods("POST")
+
+	// Assinatura eletrônica do Contrato KC ou de um contrato (cliente, consultor e Kroma)
+	// body: { "documento": "contrato_kc" | "contrato", "contratoId": 3, "documentoUrl": "...", "signatarios": [...] }
+	// Concluída a assinatura, o PDF assinado é guardado e a negociação avança para "Contrato Assinado"
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/assinaturas", assinaturaHandler.Enviar).Methods("POST")
+	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/assinaturas", assinaturaHandler.ListarDaNegociacao).Methods("GET")
+	authRoutes.HandleFunc("/assinaturas/{id:[0-9]+}", assinaturaHandler.Buscar).Methods("GET")
+	authRoutes.HandleFunc("/assinaturas/{id:[0-9]+}/cancelar", assinaturaHandler.Cancelar).Methods("POST")
+
+	// Modelos de contrato versionados, com variáveis {{ grupo.campo }} (veja /modelos-contrato/variaveis)
+	// POST (comercial) body: { "codigo": "gestao-padrao", "nome": "...", "tipoContrato": "Gestão", "conteudo": "...", "notas": "..." }
+	authRoutes.HandleFunc("/modelos-contrato", pdfHandler.CriarModeloContrato).Methods("POST")
+	authRoutes.HandleFunc("/modelos-contrato", pdfHandler.ListarModelosContrato).Methods("GET")
+	authRoutes.HandleFunc("/modelos-contrato/variaveis", pdfHandler.ListarVariaveisContrato).Methods("GET")
+	authRoutes.HandleFunc("/modelos-contrato/{codigo}/versoes", pdfHandler.ListarVersoesModeloContrato).Methods("GET")
+	authRoutes.HandleFunc("/modelos-contrato/{codigo}/versoes/{versao:[0-9]+}", pdfHandler.BuscarVersaoModeloContrato).Methods("GET")
+
+	// PDF do contrato a partir de um modelo (com hash SHA-256); ?preview=true só devolve o texto preenchido
+	// body: { "modelo": "gestao-padrao", "versao": 2 } (versão 0 ou ausente = mais recente)
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/documento", pdfHandler.GerarDocumentoContrato).Methods("POST")
+	authRoutes.HandleFunc("/contratos/{id:[0-9]+}/documentos", pdfHandler.ListarDocumentosContrato).Methods("GET")
+
 	// -------- Comentários --------
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/comentarios", comentHandler.ListarPorNegociacao).Methods("GET")
 	authRoutes.HandleFunc("/negociacoes/{id:[0-9]+}/comentarios", comentHandler.CriarComentario).Methods("POST")
@@ -239,8 +493,8 @@ func main() {
 	c := cors.New(cors.Options{
 		AllowedOrigins:   allowed,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Authorization"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match"},
+		ExposedHeaders:   []string{"Authorization", "ETag"},
 		AllowCredentials: true,
 	})
 	handler := c.Handler(r)
@@ -257,12 +511,33 @@ func main() {
 func dropAllTables(db *gorm.DB) error {
 	// Ordem importa: primeiro dependentes, depois pais.
 	return db.Migrator().DropTable(
+		&pdf.DocumentoContrato{},
+		&pdf.ModeloContrato{},
+		&assinatura.Envelope{},
+		&sla.Prazo{},
+		&indicacao.Lead{},
+		&pdf.Job{},
+		&simulacao.Estudo{},
+		&simulacao.Tarifa{},
+		&unidade.ConsumoMensal{},
+		&unidade.UnidadeConsumidora{},
+		&evento.Evento{},
+		&tarefa.Tarefa{},
+		&models.NegociacaoDocumento{},
+		&models.TipoDocumento{},
+		&models.NegociacaoParticipante{},
+		&models.ConflitoCNPJ{},
+		&models.NegociacaoStatusHistorico{},
 		&parcelacomissao.ParcelaComissao{},
 		&calculocomissao.CalculoComissao{},
 		&produtos.Produto{},
+		&contrato.Aditivo{},
 		&contrato.Contrato{},
 		&models.Comentario{},
 		&models.Negociacao{},
+		&models.ClienteDocumento{},
+		&models.ClienteContato{},
+		&models.Cliente{},
 		&consultor.Consultor{},
 		&comercial.Comercial{},
 		&auth.RefreshToken{},
@@ -0,0 +1,129 @@
+// internal/assinatura/api.go
+package assinatura
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var clienteAPI = &http.Client{Timeout: 30 * time.Second}
+
+// tamanhoMaxPDF limita os PDFs lidos do storage e baixados do provedor.
+const tamanhoMaxPDF = 20 << 20
+
+// lerPDF lê até tamanhoMaxPDF; um byte a mais indica arquivo maior, que é
+// recusado em vez de truncado.
+func lerPDF(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, tamanhoMaxPDF+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > tamanhoMaxPDF {
+		return nil, ErrPDFGrande
+	}
+	return b, nil
+}
+
+// API fala com um serviço de assinatura por HTTP/JSON (Bearer token):
+//
+//	POST   {URL}/envelopes                 -> {"id": "..."}
+//	DELETE {URL}/envelopes/{id}
+//	GET    {URL}/envelopes/{id}/documento  -> PDF assinado
+//
+// Os callbacks seguem o formato de Callback, com HMAC no HeaderHMAC.
+type API struct {
+	URL     string
+	Token   string
+	Segredo string
+}
+
+func (a *API) Nome() string { return "api" }
+
+type apiEnvelope struct {
+	Referencia  string       `json:"referencia"`
+	Nome        string       `json:"nome"`
+	Documento   string       `json:"documento"` // PDF em base64
+	Signatarios []Signatario `json:"signatarios"`
+	CallbackURL string       `json:"callbackUrl,omitempty"`
+}
+
+func (a *API) Enviar(ctx context.Context, e Envio) (string, error) {
+	body, err := json.Marshal(apiEnvelope{
+		Referencia:  e.Referencia,
+		Nome:        e.NomeDocumento,
+		Documento:   base64.StdEncoding.EncodeToString(e.Documento),
+		Signatarios: e.Signatarios,
+		CallbackURL: e.CallbackURL,
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, err := a.fazer(ctx, http.MethodPost, "/envelopes", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.ID == "" {
+		return "", fmt.Errorf("resposta inválida do provedor de assinatura: %v", err)
+	}
+	return out.ID, nil
+}
+
+func (a *API) Cancelar(ctx context.Context, envelopeID string) error {
+	resp, err := a.fazer(ctx, http.MethodDelete, "/envelopes/"+url.PathEscape(envelopeID), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (a *API) BaixarAssinado(ctx context.Context, envelopeID string) ([]byte, error) {
+	resp, err := a.fazer(ctx, http.MethodGet, "/envelopes/"+url.PathEscape(envelopeID)+"/documento", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return lerPDF(resp.Body)
+}
+
+func (a *API) LerCallback(r *http.Request, corpo []byte) (Callback, error) {
+	return lerCallbackHMAC(a.Segredo, r, corpo)
+}
+
+// fazer executa a chamada e trata respostas fora de 2xx como erro.
+func (a *API) fazer(ctx context.Context, metodo, caminho string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, metodo, a.URL+caminho, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+	resp, err := clienteAPI.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrEnvelopeDesconhecido
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("provedor de assinatura respondeu %d em %s %s", resp.StatusCode, metodo, caminho)
+	}
+	return resp, nil
+}
@@ -0,0 +1,64 @@
+// internal/assinatura/fake.go
+package assinatura
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Fake guarda os envelopes em memória e não envia convites. O "PDF
+// assinado" é o próprio documento enviado. Os callbacks são simulados com
+// um POST assinado por AssinarCorpo.
+type Fake struct {
+	Segredo string
+
+	mu        sync.Mutex
+	prefixo   string // aleatório por processo: os IDs não se repetem após reiniciar
+	seq       int
+	envelopes map[string]Envio
+}
+
+func NewFake(segredo string) *Fake {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return &Fake{Segredo: segredo, prefixo: hex.EncodeToString(b), envelopes: map[string]Envio{}}
+}
+
+func (f *Fake) Nome() string { return "fake" }
+
+func (f *Fake) Enviar(_ context.Context, e Envio) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	id := fmt.Sprintf("fake-%s-%d", f.prefixo, f.seq)
+	f.envelopes[id] = e
+	return id, nil
+}
+
+func (f *Fake) Cancelar(_ context.Context, envelopeID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.envelopes[envelopeID]; !ok {
+		return ErrEnvelopeDesconhecido
+	}
+	delete(f.envelopes, envelopeID)
+	return nil
+}
+
+func (f *Fake) BaixarAssinado(_ context.Context, envelopeID string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.envelopes[envelopeID]
+	if !ok {
+		return nil, ErrEnvelopeDesconhecido
+	}
+	return e.Documento, nil
+}
+
+func (f *Fake) LerCallback(r *http.Request, corpo []byte) (Callback, error) {
+	return lerCallbackHMAC(f.Segredo, r, corpo)
+}
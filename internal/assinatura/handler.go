@@ -0,0 +1,671 @@
+// internal/assinatura/handler.go
+package assinatura
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tamanhoMaxCallback limita o corpo aceito no webhook do provedor.
+const tamanhoMaxCallback = 1 << 20
+
+var errEnvelopeEncerrado = errors.New("o envelope não está mais aguardando assinaturas")
+
+// Handler expõe o envio de documentos para assinatura eletrônica e recebe
+// os callbacks do provedor.
+type Handler struct {
+	DB          *gorm.DB
+	Provedor    Provedor
+	Storage     storage.Storage
+	Notificador notificacao.Notificador
+	CallbackURL string     // URL pública de POST /publico/assinaturas/callback
+	Kroma       Signatario // quem assina pela Kroma
+}
+
+// NewHandler lê ASSINATURA_CALLBACK_URL, ASSINATURA_KROMA_NOME e
+// ASSINATURA_KROMA_EMAIL.
+func NewHandler(db *gorm.DB, prov Provedor, store storage.Storage, notif notificacao.Notificador) *Handler {
+	kroma := Signatario{Papel: PapelKroma, Nome: os.Getenv("ASSINATURA_KROMA_NOME"), Email: os.Getenv("ASSINATURA_KROMA_EMAIL")}
+	if kroma.Nome == "" {
+		kroma.Nome = "Kroma Energia"
+	}
+	return &Handler{
+		DB:          db,
+		Provedor:    prov,
+		Storage:     store,
+		Notificador: notif,
+		CallbackURL: os.Getenv("ASSINATURA_CALLBACK_URL"),
+		Kroma:       kroma,
+	}
+}
+
+type enviarRequest struct {
+	Documento    string       `json:"documento"`    // "contrato_kc" | "contrato"
+	ContratoID   *uint        `json:"contratoId"`   // obrigatório para "contrato"
+	DocumentoURL string       `json:"documentoUrl"` // padrão: URL atual do Contrato KC ou do contrato
+	Signatarios  []Signatario `json:"signatarios"`  // substituem os padrões pelo papel
+}
+
+// Enviar trata POST /negociacoes/{id}/assinaturas (dono da negociação ou comercial do time)
+// Body: { "documento": "contrato_kc", "contratoId": 3, "documentoUrl": "...", "signatarios": [...] }
+// O PDF precisa estar no storage da API. Por padrão assinam o contato do
+// cliente, o consultor dono da negociação e a Kroma.
+func (h *Handler) Enviar(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	var req enviarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Documento = strings.TrimSpace(req.Documento)
+	req.DocumentoURL = strings.TrimSpace(req.DocumentoURL)
+
+	switch req.Documento {
+	case DocumentoContrato:
+		if req.ContratoID == nil {
+			http.Error(w, "o campo 'contratoId' é obrigatório para o documento 'contrato'", http.StatusBadRequest)
+			return
+		}
+	case DocumentoContratoKC:
+	default:
+		http.Error(w, "o campo 'documento' deve ser 'contrato_kc' ou 'contrato'", http.StatusBadRequest)
+		return
+	}
+
+	if req.ContratoID != nil {
+		var c contrato.Contrato
+		err := h.DB.Where("negociacao_id = ?", neg.ID).First(&c, *req.ContratoID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Contrato não encontrado nesta negociação", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Erro ao buscar contrato", http.StatusInternalServerError)
+			return
+		}
+		if c.Status == contrato.StatusCancelado {
+			http.Error(w, "contrato cancelado não pode ir para assinatura", http.StatusUnprocessableEntity)
+			return
+		}
+		if req.DocumentoURL == "" && req.Documento == DocumentoContrato {
+			req.DocumentoURL = c.URL
+		}
+	}
+	if req.DocumentoURL == "" && req.Documento == DocumentoContratoKC {
+		kc, err := documento.Buscar(h.DB, neg.ID, models.DocContratoKC)
+		if err != nil {
+			http.Error(w, "Erro ao buscar Contrato KC", http.StatusInternalServerError)
+			return
+		}
+		if kc != nil {
+			req.DocumentoURL = kc.URL
+		}
+	}
+	if req.DocumentoURL == "" {
+		http.Error(w, "informe o campo 'documentoUrl'", http.StatusUnprocessableEntity)
+		return
+	}
+	key, ok := storage.KeyFromURL(h.Storage, req.DocumentoURL)
+	if !ok {
+		http.Error(w, "o documento precisa ter sido enviado pelo upload da API", http.StatusUnprocessableEntity)
+		return
+	}
+
+	signatarios, err := h.montarSignatarios(neg, req.Signatarios)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	existente, err := envelopeEmAndamento(h.DB, neg.ID, req.Documento, req.ContratoID)
+	if err == nil {
+		responderEmAndamento(w, existente)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Erro ao verificar envelopes", http.StatusInternalServerError)
+		return
+	}
+
+	pdf, err := h.lerArquivo(r, key)
+	if errors.Is(err, storage.ErrNaoEncontrado) {
+		http.Error(w, "Documento não encontrado no storage", http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, ErrPDFGrande) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao ler documento", http.StatusInternalServerError)
+		return
+	}
+	soma := sha256.Sum256(pdf)
+
+	externo, err := h.Provedor.Enviar(r.Context(), Envio{
+		Referencia:    fmt.Sprintf("negociacao-%d-%s", neg.ID, req.Documento),
+		NomeDocumento: nomeDocumento(req.Documento, neg),
+		Documento:     pdf,
+		Signatarios:   signatarios,
+		CallbackURL:   h.CallbackURL,
+	})
+	if err != nil {
+		log.Printf("[assinatura] erro ao enviar envelope da negociação %d: %v", neg.ID, err)
+		http.Error(w, "Erro ao enviar para o provedor de assinatura", http.StatusBadGateway)
+		return
+	}
+
+	env := Envelope{
+		NegociacaoID:  neg.ID,
+		ContratoID:    req.ContratoID,
+		Documento:     req.Documento,
+		Provedor:      h.Provedor.Nome(),
+		ExternoID:     externo,
+		Status:        StatusEnviado,
+		DocumentoURL:  req.DocumentoURL,
+		DocumentoHash: hex.EncodeToString(soma[:]),
+		Signatarios:   signatarios,
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&env).Error; err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   neg.ID,
+			Tipo:           evento.AssinaturaEnviada,
+			Descricao:      fmt.Sprintf("%s enviado para assinatura eletrônica", nomeDocumento(env.Documento, neg)),
+			ReferenciaTipo: "assinatura",
+			ReferenciaID:   env.ID,
+			Dados:          map[string]any{"documento": env.Documento, "contratoId": env.ContratoID, "hash": env.DocumentoHash},
+		}.Por(auth.AtorDaRequisicao(r)))
+	})
+	if err != nil {
+		// não deixa o envelope órfão no provedor
+		if errCancel := h.Provedor.Cancelar(r.Context(), externo); errCancel != nil {
+			log.Printf("[assinatura] erro ao cancelar envelope órfão %s: %v", externo, errCancel)
+		}
+		// outro envio do mesmo documento ganhou a corrida (idx_envelope_em_andamento)
+		if outro, errBusca := envelopeEmAndamento(h.DB, neg.ID, req.Documento, req.ContratoID); errBusca == nil {
+			responderEmAndamento(w, outro)
+			return
+		}
+		http.Error(w, "Erro ao registrar envelope", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(env)
+}
+
+// ListarDaNegociacao trata GET /negociacoes/{id}/assinaturas
+func (h *Handler) ListarDaNegociacao(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	lista := []Envelope{}
+	if err := h.DB.Where("negociacao_id = ?", neg.ID).Order("id DESC").Find(&lista).Error; err != nil {
+		http.Error(w, "Erro ao listar envelopes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lista)
+}
+
+// Buscar trata GET /assinaturas/{id}
+func (h *Handler) Buscar(w http.ResponseWriter, r *http.Request) {
+	env, ok := h.carregarEnvelope(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(env)
+}
+
+// Cancelar trata POST /assinaturas/{id}/cancelar
+// Cancela no provedor o envelope que ainda aguarda assinaturas.
+func (h *Handler) Cancelar(w http.ResponseWriter, r *http.Request) {
+	env, ok := h.carregarEnvelope(w, r)
+	if !ok {
+		return
+	}
+	if env.Status != StatusEnviado {
+		http.Error(w, errEnvelopeEncerrado.Error(), http.StatusConflict)
+		return
+	}
+	if err := h.Provedor.Cancelar(r.Context(), env.ExternoID); err != nil && !errors.Is(err, ErrEnvelopeDesconhecido) {
+		log.Printf("[assinatura] erro ao cancelar envelope %d no provedor: %v", env.ID, err)
+		http.Error(w, "Erro ao cancelar no provedor de assinatura", http.StatusBadGateway)
+		return
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Envelope{}).Where("id = ? AND status = ?", env.ID, StatusEnviado).
+			Update("status", StatusCancelado)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errEnvelopeEncerrado
+		}
+		env.Status = StatusCancelado
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   env.NegociacaoID,
+			Tipo:           evento.AssinaturaCancelada,
+			Descricao:      "Envelope de assinatura cancelado",
+			ReferenciaTipo: "assinatura",
+			ReferenciaID:   env.ID,
+		}.Por(auth.AtorDaRequisicao(r)))
+	})
+	if errors.Is(err, errEnvelopeEncerrado) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao cancelar envelope", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(env)
+}
+
+// Callback trata POST /publico/assinaturas/callback (sem JWT; autenticado
+// pelo HMAC do provedor). É idempotente: reenvios do mesmo evento não
+// repetem os efeitos.
+func (h *Handler) Callback(w http.ResponseWriter, r *http.Request) {
+	corpo, err := io.ReadAll(io.LimitReader(r.Body, tamanhoMaxCallback))
+	if err != nil {
+		http.Error(w, "Erro ao ler callback", http.StatusBadRequest)
+		return
+	}
+	cb, err := h.Provedor.LerCallback(r, corpo)
+	if errors.Is(err, ErrCallbackInvalido) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var env Envelope
+	err = h.DB.Where("provedor = ? AND externo_id = ?", h.Provedor.Nome(), cb.EnvelopeID).First(&env).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Envelope não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar envelope", http.StatusInternalServerError)
+		return
+	}
+	if env.Status != StatusEnviado {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch cb.Evento {
+	case EventoAssinado:
+		err = h.registrarAssinatura(env.ID, cb)
+	case EventoConcluido:
+		err = h.concluir(r, env, cb)
+	case EventoRecusado, EventoCancelado:
+		err = h.encerrar(env.ID, cb)
+	}
+	if err != nil {
+		log.Printf("[assinatura] erro ao processar callback %s do envelope %d: %v", cb.Evento, env.ID, err)
+		http.Error(w, "Erro ao processar callback", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// registrarAssinatura marca o signatário que acabou de assinar.
+func (h *Handler) registrarAssinatura(id uint, cb Callback) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		env, err := travarEnviado(tx, id)
+		if err != nil || env == nil {
+			return err
+		}
+		for i := range env.Signatarios {
+			s := &env.Signatarios[i]
+			if s.AssinadoEm == nil && strings.EqualFold(s.Email, cb.Email) {
+				em := cb.Em
+				s.AssinadoEm = &em
+			}
+		}
+		return tx.Model(env).Update("signatarios", env.Signatarios).Error
+	})
+}
+
+// encerrar registra a recusa ou o cancelamento feito do lado do provedor.
+func (h *Handler) encerrar(id uint, cb Callback) error {
+	var env *Envelope
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if env, err = travarEnviado(tx, id); err != nil || env == nil {
+			return err
+		}
+		status, tipo, desc := StatusCancelado, evento.AssinaturaCancelada, "Envelope de assinatura cancelado no provedor"
+		if cb.Evento == EventoRecusado {
+			status, tipo, desc = StatusRecusado, evento.AssinaturaRecusada, "Assinatura recusada"
+			if cb.Email != "" {
+				desc += " por " + cb.Email
+			}
+		}
+		env.Status, env.MotivoRecusa = status, cb.Motivo
+		if err := tx.Model(env).Updates(map[string]any{"status": status, "motivo_recusa": cb.Motivo}).Error; err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   env.NegociacaoID,
+			Tipo:           tipo,
+			Descricao:      desc,
+			ReferenciaTipo: "assinatura",
+			ReferenciaID:   env.ID,
+			Dados:          map[string]any{"email": cb.Email, "motivo": cb.Motivo},
+		})
+	})
+	if err == nil && env != nil && env.Status == StatusRecusado {
+		h.notificar(env, "assinatura_recusada", "Assinatura recusada",
+			fmt.Sprintf("A assinatura do envelope #%d foi recusada: %s", env.ID, cb.Motivo))
+	}
+	return err
+}
+
+// concluir guarda o PDF assinado, marca a assinatura do contrato (e o
+// Contrato KC como validado) e tenta avançar a negociação para "Contrato
+// Assinado". Se a negociação não estiver em condições de avançar, só a
+// transição fica para o comercial.
+func (h *Handler) concluir(r *http.Request, env Envelope, cb Callback) error {
+	pdf, err := h.Provedor.BaixarAssinado(r.Context(), env.ExternoID)
+	if err != nil {
+		return fmt.Errorf("baixar documento assinado: %w", err)
+	}
+	sufixo, err := storage.SufixoAleatorio()
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("negociacoes/%d/assinaturas/%d-%s-%s.pdf", env.NegociacaoID, env.ID, env.Documento, sufixo)
+	url, err := h.Storage.Put(r.Context(), key, bytes.NewReader(pdf), int64(len(pdf)), "application/pdf")
+	if err != nil {
+		return fmt.Errorf("guardar documento assinado: %w", err)
+	}
+
+	sistema := auth.AtorSistemaPadrao()
+	var concluido *Envelope
+	// callback repetido (envelope já concluído) ou erro na transação: o PDF
+	// enviado acima não é referenciado por ninguém, então é apagado.
+	defer func() {
+		if concluido != nil {
+			return
+		}
+		if err := h.Storage.Delete(context.WithoutCancel(r.Context()), key); err != nil {
+			log.Printf("[assinatura] erro ao apagar %s não usado: %v", key, err)
+		}
+	}()
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		e, err := travarEnviado(tx, env.ID)
+		if err != nil || e == nil {
+			return err
+		}
+		em := cb.Em
+		for i := range e.Signatarios {
+			if e.Signatarios[i].AssinadoEm == nil {
+				e.Signatarios[i].AssinadoEm = &em
+			}
+		}
+		e.Status, e.AssinadoURL, e.ConcluidoEm = StatusConcluido, url, &em
+		if err := tx.Model(e).Updates(map[string]any{
+			"status":       e.Status,
+			"assinado_url": url,
+			"concluido_em": em,
+			"signatarios":  e.Signatarios,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Só o contrato do envelope recebe a data de assinatura; um KC sem
+		// contrato não assina contratos que ninguém pôs no envelope.
+		if e.ContratoID != nil {
+			mudancas := map[string]any{"data_assinatura": em, "versao": gorm.Expr("versao + 1")}
+			if e.Documento == DocumentoContrato {
+				mudancas["url"] = url
+			}
+			if err := tx.Model(&contrato.Contrato{}).
+				Where("id = ? AND negociacao_id = ? AND status <> ?", *e.ContratoID, e.NegociacaoID, contrato.StatusCancelado).
+				Updates(mudancas).Error; err != nil {
+				return err
+			}
+		}
+		if e.Documento == DocumentoContratoKC {
+			if _, err := documento.Definir(tx, e.NegociacaoID, models.DocContratoKC, url, "", models.StatusValidado, sistema); err != nil {
+				return err
+			}
+		}
+
+		if err := evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   e.NegociacaoID,
+			Tipo:           evento.AssinaturaConcluida,
+			Descricao:      "Documento assinado por todas as partes",
+			ReferenciaTipo: "assinatura",
+			ReferenciaID:   e.ID,
+			Dados:          map[string]any{"documento": e.Documento, "contratoId": e.ContratoID, "url": url},
+		}); err != nil {
+			return err
+		}
+		concluido = e
+		return nil
+	})
+	if err != nil {
+		concluido = nil
+		return err
+	}
+	if concluido == nil {
+		return nil
+	}
+
+	_, err = negociacao.TransicionarStatus(h.DB, concluido.NegociacaoID, models.StatusContratoAssinado, sistema, "Assinatura eletrônica concluída")
+	var guarda *negociacao.ErrGuarda
+	switch {
+	case err == nil:
+	case errors.Is(err, negociacao.ErrTransicaoNaoPermitida), errors.As(err, &guarda):
+		log.Printf("[assinatura] negociação %d não avançou para '%s': %v", concluido.NegociacaoID, models.StatusContratoAssinado, err)
+	default:
+		log.Printf("[assinatura] erro ao avançar negociação %d: %v", concluido.NegociacaoID, err)
+	}
+	h.notificar(concluido, "assinatura_concluida", "Documento assinado",
+		fmt.Sprintf("O envelope #%d foi assinado por todas as partes", concluido.ID))
+	return nil
+}
+
+// travarEnviado trava o envelope na transação; devolve nil se ele já saiu de
+// "Enviado" (callback repetido ou concorrente).
+func travarEnviado(tx *gorm.DB, id uint) (*Envelope, error) {
+	var env Envelope
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&env, id).Error; err != nil {
+		return nil, err
+	}
+	if env.Status != StatusEnviado {
+		return nil, nil
+	}
+	return &env, nil
+}
+
+// montarSignatarios parte dos padrões (cliente, consultor e Kroma) e aplica
+// os informados no pedido, pelo papel.
+func (h *Handler) montarSignatarios(neg models.Negociacao, informados []Signatario) ([]Signatario, error) {
+	var consultor struct{ Nome, Email string }
+	if err := h.DB.Table("consultors").Select("nome, email").Where("id = ?", neg.ConsultorID).Take(&consultor).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	cliente := Signatario{Papel: PapelCliente, Nome: neg.Contato, Email: neg.Email}
+	if cliente.Nome == "" {
+		cliente.Nome = neg.Nome
+	}
+	lista := []Signatario{
+		cliente,
+		{Papel: PapelConsultor, Nome: consultor.Nome, Email: consultor.Email},
+		h.Kroma,
+	}
+	for _, s := range informados {
+		s.Papel = strings.ToLower(strings.TrimSpace(s.Papel))
+		s.Nome, s.Email, s.AssinadoEm = strings.TrimSpace(s.Nome), strings.TrimSpace(s.Email), nil
+		i := 0
+		for i < len(lista) && lista[i].Papel != s.Papel {
+			i++
+		}
+		if i == len(lista) {
+			return nil, fmt.Errorf("papel de signatário desconhecido: %q", s.Papel)
+		}
+		if s.Nome != "" {
+			lista[i].Nome = s.Nome
+		}
+		if s.Email != "" {
+			lista[i].Email = s.Email
+		}
+	}
+	for _, s := range lista {
+		if s.Email == "" || !strings.Contains(s.Email, "@") {
+			return nil, fmt.Errorf("informe um e-mail válido para o signatário '%s'", s.Papel)
+		}
+	}
+	return lista, nil
+}
+
+func (h *Handler) lerArquivo(r *http.Request, key string) ([]byte, error) {
+	rc, err := h.Storage.Get(r.Context(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return lerPDF(rc)
+}
+
+// envelopeEmAndamento busca o envelope ainda "Enviado" do mesmo documento.
+func envelopeEmAndamento(db *gorm.DB, negID uint, documento string, contratoID *uint) (Envelope, error) {
+	q := db.Model(&Envelope{}).
+		Where("negociacao_id = ? AND documento = ? AND status = ?", negID, documento, StatusEnviado)
+	if contratoID != nil {
+		q = q.Where("contrato_id = ?", *contratoID)
+	} else {
+		q = q.Where("contrato_id IS NULL")
+	}
+	var env Envelope
+	err := q.Select("id").Take(&env).Error
+	return env, err
+}
+
+func responderEmAndamento(w http.ResponseWriter, env Envelope) {
+	http.Error(w, fmt.Sprintf("já existe o envelope #%d aguardando assinaturas; cancele-o antes de reenviar", env.ID), http.StatusConflict)
+}
+
+func (h *Handler) notificar(env *Envelope, tipo, titulo, msg string) {
+	if h.Notificador == nil {
+		return
+	}
+	var dono struct {
+		ConsultorID uint
+		ComercialID *uint
+	}
+	if err := h.DB.Raw(`
+		SELECT n.consultor_id, c.comercial_id
+		FROM negociacaos n LEFT JOIN consultors c ON c.id = n.consultor_id
+		WHERE n.id = ?`, env.NegociacaoID).Scan(&dono).Error; err != nil {
+		log.Printf("[assinatura] erro ao buscar destinatários do envelope %d: %v", env.ID, err)
+		return
+	}
+	destinos := []notificacao.Notificacao{{DestinatarioTipo: notificacao.DestinoConsultor, DestinatarioID: dono.ConsultorID}}
+	if dono.ComercialID != nil && *dono.ComercialID != 0 {
+		destinos = append(destinos, notificacao.Notificacao{DestinatarioTipo: notificacao.DestinoComercial, DestinatarioID: *dono.ComercialID})
+	}
+	for _, d := range destinos {
+		d.Tipo, d.Titulo, d.Mensagem = tipo, titulo, msg
+		d.NegociacaoID = env.NegociacaoID
+		d.Dados = map[string]any{"envelopeId": env.ID, "documento": env.Documento}
+		if err := h.Notificador.Notificar(d); err != nil {
+			log.Printf("[assinatura] erro ao notificar envelope %d: %v", env.ID, err)
+		}
+	}
+}
+
+// carregarNegociacao busca a negociação dentro do escopo do usuário.
+func (h *Handler) carregarNegociacao(w http.ResponseWriter, r *http.Request, idStr string) (models.Negociacao, bool) {
+	var neg models.Negociacao
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return neg, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return neg, false
+	}
+	err = escopo.Aplicar(h.DB).First(&neg, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return neg, false
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
+		return neg, false
+	}
+	return neg, true
+}
+
+// carregarEnvelope busca o envelope de {id} se a negociação dele estiver no
+// escopo do usuário.
+func (h *Handler) carregarEnvelope(w http.ResponseWriter, r *http.Request) (Envelope, bool) {
+	var env Envelope
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return env, false
+	}
+	err = h.DB.First(&env, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Envelope não encontrado", http.StatusNotFound)
+		return env, false
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar envelope", http.StatusInternalServerError)
+		return env, false
+	}
+	if _, ok := h.carregarNegociacao(w, r, strconv.Itoa(int(env.NegociacaoID))); !ok {
+		return env, false
+	}
+	return env, true
+}
+
+func nomeDocumento(doc string, neg models.Negociacao) string {
+	if doc == DocumentoContratoKC {
+		return "Contrato KC - " + neg.Nome
+	}
+	return "Contrato - " + neg.Nome
+}
@@ -0,0 +1,69 @@
+// internal/assinatura/model.go
+package assinatura
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Status do envelope
+const (
+	StatusEnviado   = "Enviado"
+	StatusConcluido = "Concluído"
+	StatusRecusado  = "Recusado"
+	StatusCancelado = "Cancelado"
+)
+
+// Documentos que podem ir para assinatura
+const (
+	DocumentoContratoKC = "contrato_kc" // Contrato KC da negociação
+	DocumentoContrato   = "contrato"    // PDF de um contrato de produto
+)
+
+// Papéis dos signatários
+const (
+	PapelCliente   = "cliente"
+	PapelConsultor = "consultor"
+	PapelKroma     = "kroma"
+)
+
+// Signatario é uma das partes que assinam o envelope.
+type Signatario struct {
+	Papel      string     `json:"papel"`
+	Nome       string     `json:"nome"`
+	Email      string     `json:"email"`
+	AssinadoEm *time.Time `json:"assinadoEm,omitempty"`
+}
+
+// Envelope acompanha um documento enviado ao provedor de assinatura.
+type Envelope struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	NegociacaoID uint   `gorm:"not null;index" json:"negociacaoId"`
+	ContratoID   *uint  `gorm:"index" json:"contratoId,omitempty"`
+	Documento    string `gorm:"size:30;not null" json:"documento"`
+	Provedor     string `gorm:"size:30;not null;uniqueIndex:idx_envelope_externo,priority:1" json:"provedor"`
+	ExternoID    string `gorm:"size:120;not null;uniqueIndex:idx_envelope_externo,priority:2" json:"externoId"`
+	Status       string `gorm:"size:20;not null;index" json:"status"`
+
+	DocumentoURL  string `gorm:"type:text;not null" json:"documentoUrl"`
+	DocumentoHash string `gorm:"size:64;not null" json:"documentoHash"` // SHA-256 do PDF enviado
+	AssinadoURL   string `gorm:"type:text" json:"assinadoUrl,omitempty"`
+
+	Signatarios  []Signatario `gorm:"type:jsonb;serializer:json" json:"signatarios"`
+	MotivoRecusa string       `gorm:"type:text" json:"motivoRecusa,omitempty"`
+	ConcluidoEm  *time.Time   `json:"concluidoEm,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Envelope) TableName() string { return "assinatura_envelopes" }
+
+// Migrate cria o índice que impede dois envelopes "Enviado" do mesmo
+// documento (contrato_id nulo conta como um valor só).
+func Migrate(db *gorm.DB) error {
+	return db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_envelope_em_andamento
+		ON assinatura_envelopes (negociacao_id, documento, COALESCE(contrato_id, 0))
+		WHERE status = 'Enviado'`).Error
+}
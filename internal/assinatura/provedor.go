@@ -0,0 +1,123 @@
+// internal/assinatura/provedor.go
+package assinatura
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCallbackInvalido     = errors.New("callback com assinatura inválida")
+	ErrEnvelopeDesconhecido = errors.New("envelope desconhecido no provedor")
+	ErrPDFGrande            = errors.New("PDF acima de 20 MB")
+)
+
+// Eventos do callback, já normalizados pelo provedor.
+const (
+	EventoAssinado  = "assinado"  // um signatário assinou
+	EventoConcluido = "concluido" // todos assinaram; o documento final está disponível
+	EventoRecusado  = "recusado"
+	EventoCancelado = "cancelado"
+)
+
+// HeaderHMAC carrega o HMAC-SHA256 (hex) do corpo do callback.
+const HeaderHMAC = "X-Assinatura-Hmac"
+
+// Envio é o pedido de assinatura de um documento.
+type Envio struct {
+	Referencia    string // identifica o envelope do nosso lado (ex.: "envelope-12")
+	NomeDocumento string
+	Documento     []byte // PDF
+	Signatarios   []Signatario
+	CallbackURL   string
+}
+
+// Callback é a notificação do provedor sobre um envelope.
+type Callback struct {
+	EnvelopeID string    `json:"envelopeId"`
+	Evento     string    `json:"evento"`
+	Email      string    `json:"email,omitempty"`  // signatário, em "assinado" e "recusado"
+	Motivo     string    `json:"motivo,omitempty"` // em "recusado"
+	Em         time.Time `json:"em"`
+}
+
+// Provedor abstrai o serviço de assinatura eletrônica.
+type Provedor interface {
+	Nome() string
+	// Enviar cria o envelope e dispara os convites; devolve o ID do envelope no provedor.
+	Enviar(ctx context.Context, e Envio) (string, error)
+	Cancelar(ctx context.Context, envelopeID string) error
+	// BaixarAssinado devolve o PDF final, com as assinaturas.
+	BaixarAssinado(ctx context.Context, envelopeID string) ([]byte, error)
+	// LerCallback verifica a autenticidade do callback e o interpreta.
+	LerCallback(r *http.Request, corpo []byte) (Callback, error)
+}
+
+// NewFromEnv escolhe o provedor por ASSINATURA_PROVEDOR ("fake" | "api").
+// Não há padrão: o provedor fake em memória (modo dev/testes) não envia
+// nada, então só é usado com ASSINATURA_PROVEDOR=fake explícito. Os
+// callbacks são verificados com ASSINATURA_WEBHOOK_SEGREDO.
+func NewFromEnv() (Provedor, error) {
+	segredo := os.Getenv("ASSINATURA_WEBHOOK_SEGREDO")
+	if segredo == "" {
+		log.Println("[assinatura] ASSINATURA_WEBHOOK_SEGREDO não configurado; callbacks serão recusados")
+	}
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("ASSINATURA_PROVEDOR"))) {
+	case "":
+		return nil, errors.New("ASSINATURA_PROVEDOR não configurado; use 'api' ou, em dev/testes, 'fake'")
+	case "fake":
+		log.Println("[assinatura] usando o provedor fake: nenhum convite de assinatura será enviado")
+		return NewFake(segredo), nil
+	case "api":
+		url := strings.TrimSuffix(os.Getenv("ASSINATURA_API_URL"), "/")
+		if url == "" {
+			return nil, errors.New("ASSINATURA_API_URL é obrigatório para o provedor 'api'")
+		}
+		return &API{URL: url, Token: os.Getenv("ASSINATURA_API_TOKEN"), Segredo: segredo}, nil
+	default:
+		return nil, fmt.Errorf("ASSINATURA_PROVEDOR desconhecido: %q", os.Getenv("ASSINATURA_PROVEDOR"))
+	}
+}
+
+// AssinarCorpo calcula o valor do HeaderHMAC para um corpo de callback.
+func AssinarCorpo(segredo string, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(corpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// lerCallbackHMAC valida o HeaderHMAC e decodifica o corpo no formato de
+// Callback. Sem segredo configurado, nenhum callback é aceito.
+func lerCallbackHMAC(segredo string, r *http.Request, corpo []byte) (Callback, error) {
+	recebido := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(HeaderHMAC)), "sha256=")
+	if segredo == "" || recebido == "" ||
+		!hmac.Equal([]byte(strings.ToLower(recebido)), []byte(AssinarCorpo(segredo, corpo))) {
+		return Callback{}, ErrCallbackInvalido
+	}
+	var cb Callback
+	if err := json.Unmarshal(corpo, &cb); err != nil {
+		return Callback{}, fmt.Errorf("callback inválido: %w", err)
+	}
+	if cb.EnvelopeID == "" {
+		return Callback{}, errors.New("callback sem envelopeId")
+	}
+	switch cb.Evento {
+	case EventoAssinado, EventoConcluido, EventoRecusado, EventoCancelado:
+	default:
+		return Callback{}, fmt.Errorf("evento de callback desconhecido: %q", cb.Evento)
+	}
+	if cb.Em.IsZero() {
+		cb.Em = time.Now()
+	}
+	return cb, nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import "net/http"
+
+// Tipos de autor de uma ação registrada (histórico, eventos, etc.)
+const (
+	AtorConsultor = "consultor"
+	AtorComercial = "comercial"
+	AtorSistema   = "sistema"
+)
+
+// Ator identifica quem executou uma ação. O Tipo vem da claim "tipo" do
+// token (a tabela do ID); Admin vem de isAdmin.
+type Ator struct {
+	Tipo  string `json:"tipo"`
+	ID    uint   `json:"id,omitempty"` // 0 para sistema
+	Admin bool   `json:"-"`
+}
+
+// AtorDaRequisicao monta o Ator a partir das claims do contexto.
+func AtorDaRequisicao(r *http.Request) Ator {
+	userID, _ := r.Context().Value(CtxUserID).(uint)
+	isAdmin, _ := r.Context().Value(CtxIsAdmin).(bool)
+	if tipo, _ := r.Context().Value(CtxTipo).(string); tipo == TipoComercial {
+		return Ator{Tipo: AtorComercial, ID: userID, Admin: isAdmin}
+	}
+	return Ator{Tipo: AtorConsultor, ID: userID, Admin: isAdmin}
+}
+
+// AtorSistemaPadrao é usado por jobs e integrações.
+func AtorSistemaPadrao() Ator {
+	return Ator{Tipo: AtorSistema}
+}
+
+// EhComercial indica se o ator tem poderes do lado comercial/admin
+// (comercial, com ou sem admin, ou consultor admin).
+func (a Ator) EhComercial() bool {
+	return a.Tipo == AtorComercial || a.Admin
+}
@@ -10,6 +10,7 @@ type ctxKey string
 const (
 	CtxUserID  ctxKey = "usuarioID"
 	CtxIsAdmin ctxKey = "isAdmin"
+	CtxTipo    ctxKey = "tipoUsuario" // TipoConsultor | TipoComercial
 )
 
 func MiddlewareAutenticacao(next http.Handler) http.Handler {
@@ -24,11 +25,14 @@ func MiddlewareAutenticacao(next http.Handler) http.Handler {
 		}
 		raw := strings.TrimPrefix(h, "Bearer ")
 		claims, err := ParseAndValidate(raw)
-		if err != nil {
+		// tokens sem tipo de usuário (anteriores à claim) não dizem de qual
+		// tabela é o ID; o cliente precisa fazer login de novo
+		if err != nil || !TipoValido(claims.Tipo) {
 			http.Error(w, "Token inválido", http.StatusUnauthorized); return
 		}
 		ctx := context.WithValue(r.Context(), CtxUserID, claims.UserID)
 		ctx = context.WithValue(ctx, CtxIsAdmin, claims.IsAdmin)
+		ctx = context.WithValue(ctx, CtxTipo, claims.Tipo)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -66,8 +66,8 @@ func clearRTCookie(w http.ResponseWriter) {
 
 // Use isso no LOGIN após validar usuário/senha
 // isAdmin = true para Comercial (admin master); false para Consultor.
-func IssueTokensOnLogin(db *gorm.DB, w http.ResponseWriter, userID uint, isAdmin bool) (string, error) {
-	access, err := GenerateAccessToken(userID, isAdmin)
+func IssueTokensOnLogin(db *gorm.DB, w http.ResponseWriter, userID uint, isAdmin bool, tipo string) (string, error) {
+	access, err := GenerateAccessToken(userID, isAdmin, tipo)
 	if err != nil {
 		return "", err
 	}
@@ -79,9 +79,10 @@ func IssueTokensOnLogin(db *gorm.DB, w http.ResponseWriter, userID uint, isAdmin
 
 	rt := RefreshToken{
 		UserID:    userID,
-		FamilyID:  fmt.Sprintf("fam-%d", userID),
+		FamilyID:  fmt.Sprintf("fam-%s-%d", tipo, userID),
 		Hash:      hashRaw(raw),
 		IsAdmin:   isAdmin, // guarda o papel p/ RBAC no refresh
+		Tipo:      tipo,
 		ExpiresAt: time.Now().Add(RefreshTTL),
 	}
 	if err := db.Create(&rt).Error; err != nil {
@@ -117,8 +118,15 @@ func RefreshHTTPHandler(db *gorm.DB) http.HandlerFunc {
 		now := time.Now()
 		_ = db.Model(&cur).Update("revoked_at", &now).Error
 
+		// refresh emitido antes do tipo de usuário: exige novo login
+		if !TipoValido(cur.Tipo) {
+			clearRTCookie(w)
+			http.Error(w, "expired refresh", http.StatusUnauthorized)
+			return
+		}
+
 		// Gera novo access preservando RBAC do usuário salvo no refresh
-		access, err := GenerateAccessToken(cur.UserID, cur.IsAdmin)
+		access, err := GenerateAccessToken(cur.UserID, cur.IsAdmin, cur.Tipo)
 		if err != nil {
 			clearRTCookie(w)
 			http.Error(w, "error", http.StatusInternalServerError)
@@ -137,6 +145,7 @@ func RefreshHTTPHandler(db *gorm.DB) http.HandlerFunc {
 			FamilyID:  cur.FamilyID,
 			Hash:      hashRaw(newRaw),
 			IsAdmin:   cur.IsAdmin, // mantém papel
+			Tipo:      cur.Tipo,
 			ExpiresAt: time.Now().Add(RefreshTTL),
 		}
 		if err := db.Create(&newRT).Error; err != nil {
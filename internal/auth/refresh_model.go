@@ -9,6 +9,7 @@ type RefreshToken struct {
   FamilyID  string     `gorm:"index"`
   Hash      string     `gorm:"uniqueIndex"`
   IsAdmin   bool       // <<< novo
+  Tipo      string     `gorm:"size:20"` // "consultor" | "comercial"
   ExpiresAt time.Time  `gorm:"index"`
   RevokedAt *time.Time
   CreatedAt time.Time
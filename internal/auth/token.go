@@ -8,19 +8,35 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Claims do seu token (inclui RBAC simples: IsAdmin)
+// Claims do seu token (inclui RBAC simples: IsAdmin). Tipo diz em qual
+// tabela está o UserID: consultores e comerciais têm IDs independentes.
 type Claims struct {
-	UserID  uint `json:"userId"`
-	IsAdmin bool `json:"isAdmin"`
+	UserID  uint   `json:"userId"`
+	IsAdmin bool   `json:"isAdmin"`
+	Tipo    string `json:"tipo"` // TipoConsultor | TipoComercial
 	jwt.RegisteredClaims
 }
 
+// Tipos de usuário do token
+const (
+	TipoConsultor = "consultor"
+	TipoComercial = "comercial"
+)
+
+// TipoValido indica se o tipo de usuário é conhecido.
+func TipoValido(tipo string) bool {
+	return tipo == TipoConsultor || tipo == TipoComercial
+}
+
 // Tempo de vida do access token
 const AccessTTL = 10080 * time.Minute
 
 // Gera um JWT RS256 com KID, iss, aud, iat, nbf e jti
 
-func GenerateAccessToken(userID uint, isAdmin bool) (string, error) {
+func GenerateAccessToken(userID uint, isAdmin bool, tipo string) (string, error) {
+	if !TipoValido(tipo) {
+		return "", fmt.Errorf("tipo de usuário inválido: %q", tipo)
+	}
 	if err := mustInitKeys(); err != nil {
 		return "", fmt.Errorf("keys init: %w", err)
 	}
@@ -35,6 +51,7 @@ func GenerateAccessToken(userID uint, isAdmin bool) (string, error) {
 	claims := &Claims{
 		UserID:  userID,
 		IsAdmin: isAdmin,
+		Tipo:    tipo,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    getIssuer(),
 			Audience:  []string{getAudience()},
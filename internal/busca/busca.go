@@ -0,0 +1,173 @@
+// internal/busca/busca.go
+package busca
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ErrConsultaVazia indica que o termo não tem nada pesquisável.
+var ErrConsultaVazia = errors.New("informe o termo de busca em 'q'")
+
+const (
+	porPaginaPadrao = 20
+	porPaginaMax    = 100
+)
+
+// Documento de busca da negociação e do comentário. O índice GIN criado em
+// Migrate usa exatamente a mesma expressão (sem o alias), senão o Postgres
+// não o aproveita.
+func docNegociacao(p string) string {
+	return fmt.Sprintf(`to_tsvector('portuguese', f_unaccent(
+		coalesce(%[1]snome, '') || ' ' || coalesce(%[1]scontato, '') || ' ' ||
+		coalesce(%[1]semail, '') || ' ' || coalesce(%[1]scnpj_normalizado, '') || ' ' ||
+		coalesce(%[1]stelefone, '')))`, p)
+}
+
+func docComentario(p string) string {
+	return fmt.Sprintf(`to_tsvector('portuguese', f_unaccent(coalesce(%stexto, '')))`, p)
+}
+
+// Migrate habilita unaccent, cria o wrapper imutável f_unaccent (unaccent
+// puro não pode ser usado em índice) e os índices GIN da busca.
+func Migrate(db *gorm.DB) error {
+	stmts := []string{
+		`CREATE EXTENSION IF NOT EXISTS unaccent`,
+		`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+			$$ SELECT public.unaccent('public.unaccent', $1) $$
+			LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT`,
+		`CREATE INDEX IF NOT EXISTS idx_negociacaos_busca ON negociacaos USING GIN (` + docNegociacao("") + `)`,
+		`CREATE INDEX IF NOT EXISTS idx_comentarios_busca ON comentarios USING GIN (` + docComentario("") + `)`,
+	}
+	for _, s := range stmts {
+		if err := db.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resultado é uma negociação encontrada.
+type Resultado struct {
+	ID          uint    `json:"negociacaoId"`
+	Nome        string  `json:"nome"`
+	CNPJ        string  `json:"cnpj"`
+	Contato     string  `json:"contato"`
+	Email       string  `json:"email"`
+	Telefone    string  `json:"telefone"`
+	Status      string  `json:"status"`
+	ConsultorID uint    `json:"consultorId"`
+	Rank        float64 `json:"rank"`
+	Trecho      string  `json:"trecho,omitempty"` // trecho do comentário que casou, se houver
+}
+
+// Pagina é a resposta paginada da busca.
+type Pagina struct {
+	Total      int64       `json:"total"`
+	Pagina     int         `json:"pagina"`
+	PorPagina  int         `json:"porPagina"`
+	Resultados []Resultado `json:"resultados"`
+}
+
+var termoRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// montarTSQuery transforma o texto livre em "termo1:* & termo2:*" (prefixo),
+// descartando pontuação para não quebrar o to_tsquery.
+func montarTSQuery(q string) string {
+	termos := termoRe.FindAllString(q, -1)
+	for i, t := range termos {
+		termos[i] = t + ":*"
+	}
+	return strings.Join(termos, " & ")
+}
+
+// Buscar procura nas negociações (nome, CNPJ, contato, e-mail, telefone) e
+// nos comentários, respeitando o escopo. Ordena por relevância.
+func Buscar(db *gorm.DB, termo string, escopo negociacao.Escopo, pagina, porPagina int) (*Pagina, error) {
+	tsq := montarTSQuery(termo)
+	if tsq == "" {
+		return nil, ErrConsultaVazia
+	}
+	if porPagina <= 0 {
+		porPagina = porPaginaPadrao
+	}
+	if porPagina > porPaginaMax {
+		porPagina = porPaginaMax
+	}
+	if pagina <= 0 {
+		pagina = 1
+	}
+
+	// CNPJ/telefone digitados com máscara: compara só os dígitos
+	digitos := utils.NormalizarCNPJ(termo)
+	if len(digitos) < 4 {
+		digitos = ""
+	}
+
+	escNeg, argsNeg := escopo.SQL("n")
+	escCom, argsCom := escopo.SQL("n")
+
+	sql := `WITH q AS (SELECT to_tsquery('portuguese', f_unaccent(?)) AS tsq),
+	neg_hits AS (
+		SELECT n.id,
+			GREATEST(
+				CASE WHEN ` + docNegociacao("n.") + ` @@ q.tsq THEN ts_rank(` + docNegociacao("n.") + `, q.tsq) ELSE 0 END,
+				CASE WHEN ? <> '' AND (n.cnpj_normalizado LIKE ? || '%'
+					OR regexp_replace(coalesce(n.telefone, ''), '\D', '', 'g') LIKE '%' || ? || '%') THEN 1 ELSE 0 END
+			) AS rank,
+			NULL::text AS trecho
+		FROM negociacaos n, q
+		WHERE n.deleted_at IS NULL AND (` + escNeg + `)
+			AND (` + docNegociacao("n.") + ` @@ q.tsq
+				OR (? <> '' AND (n.cnpj_normalizado LIKE ? || '%'
+					OR regexp_replace(coalesce(n.telefone, ''), '\D', '', 'g') LIKE '%' || ? || '%')))
+	),
+	com_hits AS (
+		SELECT c.negociacao_id AS id,
+			ts_rank(` + docComentario("c.") + `, q.tsq) * 0.5 AS rank,
+			ts_headline('portuguese', c.texto, q.tsq, 'MaxWords=20, MinWords=5') AS trecho
+		FROM comentarios c
+		JOIN negociacaos n ON n.id = c.negociacao_id, q
+		WHERE c.deleted_at IS NULL AND n.deleted_at IS NULL AND (` + escCom + `)
+			AND ` + docComentario("c.") + ` @@ q.tsq
+	),
+	agregado AS (
+		SELECT id, MAX(rank) AS rank,
+			(ARRAY_AGG(trecho ORDER BY rank DESC) FILTER (WHERE trecho IS NOT NULL))[1] AS trecho
+		FROM (SELECT * FROM neg_hits UNION ALL SELECT * FROM com_hits) t
+		GROUP BY id
+	)
+	SELECT n.id, n.nome, n.cnpj, n.contato, n.email, n.telefone, n.status, n.consultor_id,
+		a.rank, coalesce(a.trecho, '') AS trecho, COUNT(*) OVER () AS total
+	FROM agregado a
+	JOIN negociacaos n ON n.id = a.id
+	ORDER BY a.rank DESC, n.updated_at DESC
+	LIMIT ? OFFSET ?`
+
+	args := []any{tsq, digitos, digitos, digitos}
+	args = append(args, argsNeg...)
+	args = append(args, digitos, digitos, digitos)
+	args = append(args, argsCom...)
+	args = append(args, porPagina, (pagina-1)*porPagina)
+
+	var linhas []struct {
+		Resultado
+		Total int64
+	}
+	if err := db.Raw(sql, args...).Scan(&linhas).Error; err != nil {
+		return nil, err
+	}
+
+	out := &Pagina{Pagina: pagina, PorPagina: porPagina, Resultados: make([]Resultado, len(linhas))}
+	for i, l := range linhas {
+		out.Resultados[i] = l.Resultado
+		out.Total = l.Total
+	}
+	return out, nil
+}
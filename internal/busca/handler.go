@@ -0,0 +1,47 @@
+// internal/busca/handler.go
+package busca
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"gorm.io/gorm"
+)
+
+// Handler expõe a busca textual de negociações.
+type Handler struct {
+	DB *gorm.DB
+}
+
+// NewHandler cria o handler de busca
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db}
+}
+
+// Buscar trata GET /negociacoes/busca?q=...&pagina=1&porPagina=20
+func (h *Handler) Buscar(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	pagina, _ := strconv.Atoi(q.Get("pagina"))
+	porPagina, _ := strconv.Atoi(q.Get("porPagina"))
+
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := Buscar(h.DB, q.Get("q"), escopo, pagina, porPagina)
+	if errors.Is(err, ErrConsultaVazia) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar negociações", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
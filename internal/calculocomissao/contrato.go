@@ -0,0 +1,284 @@
+// internal/calculocomissao/contrato.go
+package calculocomissao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errContratoSemCondicoes = errors.New("contrato sem condições suficientes para gerar a comissão")
+	errForaDoTime           = errors.New("negociação fora do time do comercial")
+)
+
+// errCalculoExistente diz que o contrato já gerou um cálculo ainda ativo.
+type errCalculoExistente struct{ id uint }
+
+func (e errCalculoExistente) Error() string {
+	return fmt.Sprintf("o contrato já tem o cálculo de comissão #%d", e.id)
+}
+
+// conferirTime deixa o admin passar e exige que o comercial seja o do
+// consultor dono da negociação. SQL direto: calculocomissao não pode
+// depender de negociacao.
+func conferirTime(db *gorm.DB, ator auth.Ator, negociacaoID uint) error {
+	if ator.Admin {
+		return nil
+	}
+	var qtd int64
+	err := db.Table("negociacaos AS n").
+		Joins("JOIN consultors co ON co.id = n.consultor_id").
+		Where("n.id = ? AND co.comercial_id = ?", negociacaoID, ator.ID).
+		Count(&qtd).Error
+	if err != nil {
+		return err
+	}
+	if qtd == 0 {
+		return errForaDoTime
+	}
+	return nil
+}
+
+// mesesSuprimento conta os vencimentos mensais entre o início e o fim do
+// suprimento, contando o mês do início (mínimo 1). Cada vencimento segue
+// somarMeses, então 31/01 a 30/04 tem quatro.
+func mesesSuprimento(inicio, fim time.Time) int {
+	meses := (fim.Year()-inicio.Year())*12 + int(fim.Month()-inicio.Month())
+	if somarMeses(inicio, meses).Day() <= fim.Day() { // cai no mês do fim
+		meses++
+	}
+	if meses < 1 {
+		meses = 1
+	}
+	return meses
+}
+
+// somarMeses avança n meses mantendo o dia, limitado ao último dia do mês
+// de destino: 31/01 + 1 mês vence em 28/02 (ou 29), não em 03/03 como no
+// AddDate.
+func somarMeses(t time.Time, n int) time.Time {
+	primeiro := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	ultimo := primeiro.AddDate(0, 1, -1).Day()
+	return primeiro.AddDate(0, 0, min(t.Day(), ultimo)-1)
+}
+
+// CalculoDoContrato deriva o cálculo e o cronograma de parcelas das
+// condições do contrato:
+//   - o total é o valor do contrato, ou o fee sobre ele quando Fee está ativo;
+//   - UniPay antecipa UniPayPercent do total na assinatura (ou no início do suprimento);
+//   - MonPay paga MonthPay em cada mês do suprimento;
+//   - sem MonPay, ValorIntegral paga o restante de uma vez no início e, sem
+//     ele, o restante é dividido igualmente pelos meses do suprimento.
+//
+// As parcelas saem sem ID de cálculo e sem divisão entre participantes.
+func CalculoDoContrato(c contrato.Contrato, agora time.Time) (CalculoComissao, []*parcelacomissao.ParcelaComissao, error) {
+	if c.InicioSuprimento.IsZero() || !c.FimSuprimento.After(c.InicioSuprimento) {
+		return CalculoComissao{}, nil, fmt.Errorf("%w: informe início e fim do suprimento", errContratoSemCondicoes)
+	}
+	total := c.Valor
+	if c.Fee {
+		total = c.Valor * c.FeePercent
+	}
+	total = math.Round(total*100) / 100
+	if total <= 0 && !(c.MonPay && c.MonthPay > 0) {
+		return CalculoComissao{}, nil, fmt.Errorf("%w: valor da comissão zerado", errContratoSemCondicoes)
+	}
+
+	contratoID := c.ID
+	parcela := func(valor float64, vencimento time.Time) *parcelacomissao.ParcelaComissao {
+		return &parcelacomissao.ParcelaComissao{
+			ContratoID:     &contratoID,
+			Valor:          valor,
+			DataVencimento: vencimento,
+			Status:         "Pendente",
+		}
+	}
+
+	var parcelas []*parcelacomissao.ParcelaComissao
+	restante := total
+	if c.UniPay && c.UniPayPercent > 0 {
+		inicial := math.Round(total*c.UniPayPercent*100) / 100
+		vencimento := c.InicioSuprimento
+		if !c.DataAssinatura.IsZero() && c.DataAssinatura.After(time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			vencimento = c.DataAssinatura
+		}
+		parcelas = append(parcelas, parcela(inicial, vencimento))
+		restante -= inicial
+	}
+	iniciais := len(parcelas)
+
+	meses := mesesSuprimento(c.InicioSuprimento, c.FimSuprimento)
+	switch {
+	case c.MonPay:
+		for i := 0; i < meses; i++ {
+			parcelas = append(parcelas, parcela(c.MonthPay, somarMeses(c.InicioSuprimento, i)))
+		}
+	case c.ValorIntegral:
+		if restante > 0 {
+			parcelas = append(parcelas, parcela(math.Round(restante*100)/100, c.InicioSuprimento))
+		}
+	default:
+		if restante > 0 {
+			mensal := math.Floor(restante/float64(meses)*100) / 100
+			for i := 0; i < meses; i++ {
+				v := mensal
+				if i == meses-1 {
+					v = math.Round((restante-mensal*float64(meses-1))*100) / 100 // sobra do arredondamento
+				}
+				parcelas = append(parcelas, parcela(v, somarMeses(c.InicioSuprimento, i)))
+			}
+		}
+	}
+
+	modo := "parcelasIguais"
+	if iniciais > 0 {
+		modo = "pagamentoInicialEParcelas"
+	}
+	var soma float64
+	for _, p := range parcelas {
+		soma += p.Valor
+	}
+	calc := CalculoComissao{
+		NegociacaoID:          c.NegociacaoID,
+		ContratoID:            &contratoID,
+		Status:                "Pendente",
+		ModalidadeRecebimento: strings.TrimSpace("Contrato " + c.Tipo),
+		Fee:                   c.FeePercent,
+		PossuiComissaoGestao:  strings.EqualFold(c.Tipo, "Gestão"),
+		TotalReceber:          math.Round(soma*100) / 100,
+		InicioContrato:        c.InicioSuprimento,
+		TerminioContrato:      c.FimSuprimento,
+		ModoPagamento:         modo,
+		QtdParcelas:           len(parcelas) - iniciais,
+		DataGeracao:           agora,
+	}
+	if c.MonPay {
+		calc.ValorGestaoMensal = c.MonthPay
+	}
+	return calc, parcelas, nil
+}
+
+// GerarDoContrato trata POST /contratos/{id}/calculo-comissao?preview=true
+// (comercial do time do consultor ou admin)
+// Deriva o cálculo e as parcelas das condições do contrato. Com preview, só
+// devolve o resultado (já dividido entre os participantes) sem gravar.
+func (h *Handler) GerarDoContrato(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+	if !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID do contrato inválido", http.StatusBadRequest)
+		return
+	}
+	preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+
+	var calc CalculoComissao
+	err = h.Repo.DB.Transaction(func(tx *gorm.DB) error {
+		var c contrato.Contrato
+		q := tx
+		if !preview {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := q.First(&c, id).Error; err != nil {
+			return err
+		}
+		if err := conferirTime(tx, ator, c.NegociacaoID); err != nil {
+			return err
+		}
+		var existente CalculoComissao
+		err := tx.Select("id").Where("contrato_id = ?", c.ID).Take(&existente).Error
+		if err == nil {
+			return errCalculoExistente{id: existente.ID}
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var parcelas []*parcelacomissao.ParcelaComissao
+		calc, parcelas, err = CalculoDoContrato(c, time.Now())
+		if err != nil {
+			return err
+		}
+		participantes, err := participantesDe(tx, c.NegociacaoID)
+		if err != nil {
+			return err
+		}
+		parcelas = dividirParcelas(parcelas, participantes)
+
+		if preview {
+			calc.Parcelas = make([]parcelacomissao.ParcelaComissao, len(parcelas))
+			for i, p := range parcelas {
+				calc.Parcelas[i] = *p
+			}
+			return nil
+		}
+
+		if err := tx.Create(&calc).Error; err != nil {
+			return err
+		}
+		for _, p := range parcelas {
+			p.CalculoComissaoID = calc.ID
+		}
+		if err := parcelacomissao.NewRepository(tx).CreateInBatch(parcelas); err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   calc.NegociacaoID,
+			Tipo:           evento.CalculoComissaoCriado,
+			Descricao:      fmt.Sprintf("Cálculo de comissão gerado do contrato #%d", c.ID),
+			ReferenciaTipo: "calculo_comissao",
+			ReferenciaID:   calc.ID,
+			Dados: map[string]any{
+				"totalReceber": calc.TotalReceber, "modoPagamento": calc.ModoPagamento,
+				"participantes": len(participantes), "contratoId": c.ID,
+			},
+		}.Por(ator))
+	})
+	var existente errCalculoExistente
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, errForaDoTime):
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	case errors.As(err, &existente):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errContratoSemCondicoes):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao gerar cálculo de comissão", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if preview {
+		_ = json.NewEncoder(w).Encode(calc)
+		return
+	}
+	if err := h.Repo.DB.Preload("Parcelas").First(&calc, calc.ID).Error; err != nil {
+		http.Error(w, "Erro ao carregar cálculo", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(calc)
+}
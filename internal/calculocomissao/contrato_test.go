@@ -0,0 +1,85 @@
+package calculocomissao
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+)
+
+func data(a int, m time.Month, d int) time.Time {
+	return time.Date(a, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSomarMeses(t *testing.T) {
+	casos := []struct {
+		nome   string
+		inicio time.Time
+		meses  int
+		quer   time.Time
+	}{
+		{"mesmo dia", data(2025, time.March, 15), 1, data(2025, time.April, 15)},
+		{"zero meses", data(2025, time.January, 31), 0, data(2025, time.January, 31)},
+		{"31 para fevereiro", data(2025, time.January, 31), 1, data(2025, time.February, 28)},
+		{"31 para fevereiro bissexto", data(2024, time.January, 31), 1, data(2024, time.February, 29)},
+		{"31 para abril", data(2025, time.January, 31), 3, data(2025, time.April, 30)},
+		{"31 volta a 31", data(2025, time.January, 31), 2, data(2025, time.March, 31)},
+		{"30 para fevereiro", data(2025, time.January, 30), 1, data(2025, time.February, 28)},
+		{"29 para fevereiro", data(2025, time.January, 29), 1, data(2025, time.February, 28)},
+		{"29 para fevereiro bissexto", data(2024, time.January, 29), 1, data(2024, time.February, 29)},
+		{"virada de ano", data(2025, time.November, 30), 3, data(2026, time.February, 28)},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if got := somarMeses(c.inicio, c.meses); !got.Equal(c.quer) {
+				t.Errorf("somarMeses(%s, %d) = %s, quer %s", c.inicio.Format("2006-01-02"), c.meses,
+					got.Format("2006-01-02"), c.quer.Format("2006-01-02"))
+			}
+		})
+	}
+}
+
+func TestCalculoDoContratoVencimentosNoFimDoMes(t *testing.T) {
+	casos := []struct {
+		nome   string
+		inicio time.Time
+		quer   []time.Time
+	}{
+		{"dia 29", data(2025, time.January, 29),
+			[]time.Time{data(2025, time.January, 29), data(2025, time.February, 28), data(2025, time.March, 29), data(2025, time.April, 29)}},
+		{"dia 30", data(2025, time.January, 30),
+			[]time.Time{data(2025, time.January, 30), data(2025, time.February, 28), data(2025, time.March, 30), data(2025, time.April, 30)}},
+		{"dia 31", data(2025, time.January, 31),
+			[]time.Time{data(2025, time.January, 31), data(2025, time.February, 28), data(2025, time.March, 31), data(2025, time.April, 30)}},
+	}
+	for _, c := range casos {
+		for _, monPay := range []bool{false, true} {
+			nome := c.nome + "/parcelas iguais"
+			if monPay {
+				nome = c.nome + "/mensal"
+			}
+			t.Run(nome, func(t *testing.T) {
+				ct := contrato.Contrato{
+					Valor:            1000,
+					InicioSuprimento: c.inicio,
+					FimSuprimento:    c.quer[len(c.quer)-1],
+					MonPay:           monPay,
+					MonthPay:         250,
+				}
+				_, parcelas, err := CalculoDoContrato(ct, data(2025, time.January, 1))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(parcelas) != len(c.quer) {
+					t.Fatalf("%d parcelas, quer %d", len(parcelas), len(c.quer))
+				}
+				for i, p := range parcelas {
+					if !p.DataVencimento.Equal(c.quer[i]) {
+						t.Errorf("parcela %d vence em %s, quer %s", i+1,
+							p.DataVencimento.Format("2006-01-02"), c.quer[i].Format("2006-01-02"))
+					}
+				}
+			})
+		}
+	}
+}
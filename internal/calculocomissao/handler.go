@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
 	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
 	"github.com/gorilla/mux"
 )
@@ -140,6 +142,15 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// venda conjunta: cada parcela é dividida entre os participantes
+	participantes, err := participantesDe(tx, calc.NegociacaoID)
+	if err != nil {
+		_ = tx.Rollback()
+		http.Error(w, "Erro ao buscar participantes da negociação", http.StatusInternalServerError)
+		return
+	}
+	parcelas = dividirParcelas(parcelas, participantes)
+
 	if len(parcelas) > 0 {
 		if err := parcRepo.CreateInBatch(parcelas); err != nil {
 			_ = tx.Rollback()
@@ -167,7 +178,21 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 10) commit e resposta
+	// 10) evento da linha do tempo
+	if err := evento.Registrar(tx, evento.Evento{
+		NegociacaoID:   calc.NegociacaoID,
+		Tipo:           evento.CalculoComissaoCriado,
+		Descricao:      "Cálculo de comissão criado",
+		ReferenciaTipo: "calculo_comissao",
+		ReferenciaID:   calc.ID,
+		Dados:          map[string]any{"totalReceber": total, "modoPagamento": dto.ModoPagamento, "participantes": len(participantes)},
+	}.Por(auth.AtorDaRequisicao(r))); err != nil {
+		_ = tx.Rollback()
+		http.Error(w, "Erro ao registrar evento", http.StatusInternalServerError)
+		return
+	}
+
+	// 11) commit e resposta
 	if err := tx.Commit().Error; err != nil {
 		_ = tx.Rollback()
 		http.Error(w, "Erro ao confirmar transação", http.StatusInternalServerError)
@@ -11,6 +11,7 @@ import (
 type CalculoComissao struct {
 	ID                    uint      `gorm:"primaryKey" json:"id"`
 	NegociacaoID          uint      `gorm:"not null;index" json:"negociacaoId"`
+	ContratoID            *uint     `gorm:"index" json:"contratoId,omitempty"` // gerado das condições deste contrato
 	Status                string    `gorm:"size:100;not null;default:'Pendente';index" json:"status"`
 	ModalidadeRecebimento string    `gorm:"size:255;not null" json:"modalidadeRecebimento"`
 	Fee                   float64   `gorm:"not null;default:0" json:"fee"`
@@ -34,7 +35,17 @@ type CalculoComissao struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 }
 
-// Migrate cria a tabela no banco de dados e aplica relacionamentos
+// Migrate cria a tabela no banco de dados, aplica relacionamentos e preenche
+// o percentual das parcelas de venda conjunta anteriores a ele com a divisão
+// atual, que era o critério do resumo de comissões.
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&CalculoComissao{})
+	if err := db.AutoMigrate(&CalculoComissao{}); err != nil {
+		return err
+	}
+	return db.Exec(`
+		UPDATE parcela_comissaos pc SET percentual = np.percentual
+		FROM calculo_comissaos cc, negociacaos n, negociacao_participantes np
+		WHERE cc.id = pc.calculo_comissao_id AND n.id = cc.negociacao_id
+			AND np.negociacao_id = n.id AND np.consultor_id = COALESCE(pc.consultor_id, n.consultor_id)
+			AND pc.percentual IS NULL`).Error
 }
@@ -0,0 +1,61 @@
+// internal/calculocomissao/participantes.go
+package calculocomissao
+
+import (
+	"math"
+
+	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
+	"gorm.io/gorm"
+)
+
+// participacao é a fatia de um consultor na comissão da negociação. Lê a
+// tabela "negociacao_participantes" direto porque models depende deste pacote.
+type participacao struct {
+	ConsultorID uint
+	Percentual  float64
+	Dono        bool // dono atual da negociação: a parcela dele segue a negociação
+}
+
+// participantesDe devolve a divisão da negociação; vazio quando não há venda
+// conjunta (o dono recebe tudo).
+func participantesDe(db *gorm.DB, negociacaoID uint) ([]participacao, error) {
+	var out []participacao
+	err := db.Table("negociacao_participantes AS p").
+		Select("p.consultor_id, p.percentual, p.consultor_id = n.consultor_id AS dono").
+		Joins("JOIN negociacaos n ON n.id = p.negociacao_id").
+		Where("p.negociacao_id = ?", negociacaoID).
+		Order("dono DESC, p.consultor_id ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// dividirParcelas troca cada parcela por uma por participante, com o valor e
+// o volume proporcionais ao percentual, que fica gravado na parcela. O último participante fica com a
+// sobra do arredondamento, então a soma de cada vencimento não muda.
+func dividirParcelas(parcelas []*parcelacomissao.ParcelaComissao, participantes []participacao) []*parcelacomissao.ParcelaComissao {
+	if len(participantes) == 0 {
+		return parcelas
+	}
+	out := make([]*parcelacomissao.ParcelaComissao, 0, len(parcelas)*len(participantes))
+	for _, base := range parcelas {
+		restante := base.Valor
+		for i, p := range participantes {
+			parte := *base
+			parte.Valor = math.Round(base.Valor*p.Percentual) / 100
+			parte.VolumeMensal = base.VolumeMensal * p.Percentual / 100
+			if i == len(participantes)-1 {
+				parte.Valor = math.Round(restante*100) / 100
+			}
+			restante -= parte.Valor
+			pct := p.Percentual
+			parte.Percentual = &pct
+			parte.ConsultorID = nil
+			if !p.Dono {
+				id := p.ConsultorID
+				parte.ConsultorID = &id
+			}
+			out = append(out, &parte)
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package calculocomissao
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
+)
+
+func TestDividirParcelas(t *testing.T) {
+	venc := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	casos := []struct {
+		nome          string
+		valor         float64
+		participantes []participacao
+		quer          []float64
+	}{
+		{"sem venda conjunta", 100, nil, []float64{100}},
+		{"meio a meio", 100, []participacao{{1, 50, true}, {2, 50, false}}, []float64{50, 50}},
+		{"sobra vai para o último", 100, []participacao{{1, 33.33, true}, {2, 33.33, false}, {3, 33.34, false}},
+			[]float64{33.33, 33.33, 33.34}},
+		{"terços de centavo ímpar", 0.05, []participacao{{1, 33.33, true}, {2, 33.33, false}, {3, 33.34, false}},
+			[]float64{0.02, 0.02, 0.01}},
+		{"valor quebrado", 1234.57, []participacao{{1, 70, true}, {2, 30, false}}, []float64{864.2, 370.37}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			base := &parcelacomissao.ParcelaComissao{Valor: c.valor, VolumeMensal: 10, DataVencimento: venc, Status: "Pendente"}
+			out := dividirParcelas([]*parcelacomissao.ParcelaComissao{base}, c.participantes)
+			if len(out) != len(c.quer) {
+				t.Fatalf("%d parcelas, quer %d", len(out), len(c.quer))
+			}
+			var soma float64
+			for i, p := range out {
+				if p.Valor != c.quer[i] {
+					t.Errorf("parcela %d = %v, quer %v", i, p.Valor, c.quer[i])
+				}
+				if !p.DataVencimento.Equal(venc) {
+					t.Errorf("parcela %d vence em %s", i, p.DataVencimento)
+				}
+				soma += p.Valor
+			}
+			if math.Abs(soma-c.valor) > 1e-9 {
+				t.Errorf("as partes somam %v, quer %v", soma, c.valor)
+			}
+			for i, p := range c.participantes {
+				if out[i].Percentual == nil || *out[i].Percentual != p.Percentual {
+					t.Errorf("parcela %d sem o percentual %v", i, p.Percentual)
+				}
+				if p.Dono != (out[i].ConsultorID == nil) {
+					t.Errorf("parcela %d: consultor %v, dono %v", i, out[i].ConsultorID, p.Dono)
+				}
+			}
+		})
+	}
+}
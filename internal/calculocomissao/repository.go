@@ -77,16 +77,22 @@ func (r *Repository) FindByNegociacaoAndStatus(negID uint, status string) ([]Cal
 
 // <<<---- NOVO MÉTODO ---->>>
 // ListByConsultorID busca todos os cálculos de comissão de um consultor,
-// pré-carregando as parcelas de cada um.
+// pré-carregando as parcelas de cada um. Depois de uma transferência, cada
+// consultor vê só as parcelas que recebe (parcela.consultor_id, ou o dono
+// atual da negociação quando nulo).
 func (r *Repository) ListByConsultorID(consultorID uint) ([]CalculoComissao, error) {
+	const recebe = `COALESCE(parcela_comissaos.consultor_id,
+		(SELECT n.consultor_id FROM calculo_comissaos c JOIN negociacaos n ON n.id = c.negociacao_id
+		WHERE c.id = parcela_comissaos.calculo_comissao_id)) = ?`
 	var calculos []CalculoComissao
 	err := r.DB.
-		// Pré-carrega as parcelas para cada cálculo encontrado
-		Preload("Parcelas").
-		// Junta com a tabela de negociações para poder filtrar pelo consultor
-		Joins("JOIN negociacoes ON negociacoes.id = calculo_comissaos.negociacao_id").
-		// Filtra pelo ID do consultor
-		Where("negociacoes.consultor_id = ?", consultorID).
+		// Pré-carrega só as parcelas do consultor
+		Preload("Parcelas", recebe, consultorID).
+		// Cálculos das negociações dele ou em que ainda tem parcelas
+		Joins("JOIN negociacaos ON negociacaos.id = calculo_comissaos.negociacao_id").
+		Where(`negociacaos.consultor_id = ? OR EXISTS (SELECT 1 FROM parcela_comissaos
+			WHERE parcela_comissaos.calculo_comissao_id = calculo_comissaos.id AND parcela_comissaos.consultor_id = ?)`,
+			consultorID, consultorID).
 		// Executa a busca
 		Find(&calculos).Error
 	return calculos, err
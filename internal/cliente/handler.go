@@ -0,0 +1,430 @@
+// internal/cliente/handler.go
+package cliente
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const (
+	porPaginaPadrao = 20
+	porPaginaMax    = 100
+)
+
+// Handler encapsula DB, repository e o notificador das revisões de documento
+type Handler struct {
+	DB          *gorm.DB
+	Repository  Repository
+	Notificador notificacao.Notificador
+}
+
+// NewHandler cria um novo handler de clientes
+func NewHandler(db *gorm.DB, notif notificacao.Notificador) *Handler {
+	return &Handler{
+		DB:          db,
+		Repository:  NewRepository(),
+		Notificador: notif,
+	}
+}
+
+type clienteRequest struct {
+	Nome            string `json:"nome"`
+	CNPJ            string `json:"cnpj"`
+	Email           string `json:"email"`
+	Contato         string `json:"contato"`
+	NumeroDoContato string `json:"numeroDoContato"`
+	Telefone        string `json:"telefone"`
+	UF              string `json:"uf"`
+}
+
+type contatoRequest struct {
+	Nome      string `json:"nome"`
+	Cargo     string `json:"cargo"`
+	Email     string `json:"email"`
+	Telefone  string `json:"telefone"`
+	Principal bool   `json:"principal"`
+}
+
+type definirDocumentoRequest struct {
+	URL    string `json:"url"`
+	Status string `json:"status"` // opcional; só o comercial pode enviar já "Validado"
+}
+
+type revisarDocumentoRequest struct {
+	Status string `json:"status"` // "Validado" | "Rejeitado"
+	Motivo string `json:"motivo"` // obrigatório ao rejeitar
+}
+
+/* ================== Clientes ================== */
+
+// Listar trata GET /clientes?q=&pagina=1&porPagina=20
+// Consultor vê os clientes com negociação sua; comercial, os do time.
+func (h *Handler) Listar(w http.ResponseWriter, r *http.Request) {
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	q := r.URL.Query()
+	pagina, _ := strconv.Atoi(q.Get("pagina"))
+	if pagina <= 0 {
+		pagina = 1
+	}
+	porPagina, _ := strconv.Atoi(q.Get("porPagina"))
+	if porPagina <= 0 {
+		porPagina = porPaginaPadrao
+	}
+	porPagina = min(porPagina, porPaginaMax)
+
+	list, total, err := h.Repository.Listar(h.DB, escopo, q.Get("q"), porPagina, (pagina-1)*porPagina)
+	if err != nil {
+		http.Error(w, "Erro ao listar clientes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"total":     total,
+		"pagina":    pagina,
+		"porPagina": porPagina,
+		"clientes":  list,
+	})
+}
+
+// Criar trata POST /clientes (somente comercial/admin; o consultor cria o
+// cliente junto com a negociação)
+func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	var req clienteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	var c models.Cliente
+	if !h.aplicar(w, &c, req) {
+		return
+	}
+	if err := h.Repository.Salvar(h.DB, &c); err != nil {
+		http.Error(w, "Erro ao salvar cliente", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// BuscarPorID trata GET /clientes/{id} (com contatos e documentos)
+func (h *Handler) BuscarPorID(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// Atualizar trata PUT /clientes/{id} (somente comercial/admin). Os dados
+// básicos são copiados para todas as negociações do cliente, inclusive as de
+// outros consultores; por isso o consultor não altera o cadastro.
+func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+
+	var req clienteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if !h.aplicar(w, c, req) {
+		return
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := h.Repository.Salvar(tx, c); err != nil {
+			return err
+		}
+		return h.Repository.PropagarParaNegociacoes(tx, c)
+	})
+	if err != nil {
+		http.Error(w, "Erro ao atualizar cliente", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
+
+// aplicar valida o payload e copia para o cliente; em caso de erro já responde.
+func (h *Handler) aplicar(w http.ResponseWriter, c *models.Cliente, req clienteRequest) bool {
+	if strings.TrimSpace(req.Nome) == "" {
+		http.Error(w, "O campo 'nome' é obrigatório", http.StatusBadRequest)
+		return false
+	}
+	if cnpj := utils.NormalizarCNPJ(req.CNPJ); cnpj != "" {
+		outro, err := h.Repository.BuscarPorCNPJ(h.DB, cnpj)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Erro ao verificar CNPJ", http.StatusInternalServerError)
+			return false
+		}
+		if err == nil && outro.ID != c.ID {
+			http.Error(w, "Já existe um cliente com esse CNPJ", http.StatusConflict)
+			return false
+		}
+	}
+
+	c.Nome = strings.TrimSpace(req.Nome)
+	c.CNPJ = strings.TrimSpace(req.CNPJ)
+	c.Email = strings.TrimSpace(req.Email)
+	c.Contato = strings.TrimSpace(req.Contato)
+	c.NumeroDoContato = strings.TrimSpace(req.NumeroDoContato)
+	c.Telefone = strings.TrimSpace(req.Telefone)
+	c.UF = strings.ToUpper(strings.TrimSpace(req.UF))
+	c.CNPJNormalizado = utils.NormalizarCNPJ(c.CNPJ)
+	return true
+}
+
+/* ================== Contatos ================== */
+
+// CriarContato trata POST /clientes/{id}/contatos
+func (h *Handler) CriarContato(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	ct := models.ClienteContato{ClienteID: c.ID}
+	if !decodificarContato(w, r, &ct) {
+		return
+	}
+	if err := h.Repository.SalvarContato(h.DB, &ct); err != nil {
+		http.Error(w, "Erro ao salvar contato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(ct)
+}
+
+// AtualizarContato trata PUT /clientes/{id}/contatos/{contatoId}
+func (h *Handler) AtualizarContato(w http.ResponseWriter, r *http.Request) {
+	ct, ok := h.carregarContato(w, r)
+	if !ok {
+		return
+	}
+	if !decodificarContato(w, r, ct) {
+		return
+	}
+	if err := h.Repository.SalvarContato(h.DB, ct); err != nil {
+		http.Error(w, "Erro ao salvar contato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ct)
+}
+
+// DeletarContato trata DELETE /clientes/{id}/contatos/{contatoId}
+func (h *Handler) DeletarContato(w http.ResponseWriter, r *http.Request) {
+	ct, ok := h.carregarContato(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Repository.DeletarContato(h.DB, ct); err != nil {
+		http.Error(w, "Erro ao remover contato", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodificarContato(w http.ResponseWriter, r *http.Request, ct *models.ClienteContato) bool {
+	var req contatoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return false
+	}
+	if strings.TrimSpace(req.Nome) == "" {
+		http.Error(w, "O campo 'nome' é obrigatório", http.StatusBadRequest)
+		return false
+	}
+	ct.Nome = strings.TrimSpace(req.Nome)
+	ct.Cargo = strings.TrimSpace(req.Cargo)
+	ct.Email = strings.TrimSpace(req.Email)
+	ct.Telefone = strings.TrimSpace(req.Telefone)
+	ct.Principal = req.Principal
+	return true
+}
+
+/* ================== Documentos do cliente ================== */
+
+// ListarDocumentos trata GET /clientes/{id}/documentos
+func (h *Handler) ListarDocumentos(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c.Documentos)
+}
+
+// DefinirDocumento trata PUT /clientes/{id}/documentos/{tipo} (somente comercial/admin;
+// o consultor envia pelo documento da negociação).
+// Body: { "url": "https://...", "status": "Enviado" }
+func (h *Handler) DefinirDocumento(w http.ResponseWriter, r *http.Request) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	var req definirDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := documento.DefinirDoCliente(h.DB, c.ID, mux.Vars(r)["tipo"], req.URL, "", req.Status, auth.AtorDaRequisicao(r))
+	if err != nil {
+		documento.ResponderErro(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// RevisarDocumento trata PATCH /clientes/{id}/documentos/{tipo}/status (somente comercial/admin).
+// A rejeição vira comentário e notificação em cada negociação ativa do cliente.
+func (h *Handler) RevisarDocumento(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	var req revisarDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	codigo := mux.Vars(r)["tipo"]
+	var doc *models.ClienteDocumento
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		doc, err = documento.RevisarDoCliente(tx, c.ID, codigo, req.Status, req.Motivo, auth.AtorDaRequisicao(r))
+		if err != nil || doc.Status != models.StatusRejeitado {
+			return err
+		}
+		var negs []models.Negociacao
+		if err := tx.Select("id", "consultor_id").
+			Where("cliente_id = ? AND status <> ?", c.ID, models.StatusCancelada).
+			Find(&negs).Error; err != nil {
+			return err
+		}
+		nome := codigo
+		var tipo models.TipoDocumento
+		if err := tx.Where("codigo = ?", codigo).First(&tipo).Error; err == nil {
+			nome = tipo.Nome
+		}
+		for i := range negs {
+			if err := documento.RegistrarRejeicao(tx, h.Notificador, &negs[i], nome, doc.MotivoRejeicao); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		documento.ResponderErro(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// RemoverDocumento trata DELETE /clientes/{id}/documentos/{tipo} (somente comercial/admin)
+func (h *Handler) RemoverDocumento(w http.ResponseWriter, r *http.Request) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	if err := documento.RemoverDoCliente(h.DB, c.ID, mux.Vars(r)["tipo"]); err != nil {
+		http.Error(w, "Erro ao remover documento", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/* ================== Helpers ================== */
+
+// carregarCliente lê {id} e confere se o cliente está no escopo do usuário;
+// em caso de erro já responde.
+func (h *Handler) carregarCliente(w http.ResponseWriter, r *http.Request) (*models.Cliente, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+	c, err := h.Repository.BuscarPorID(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, false
+	}
+	visivel, err := h.Repository.Visivel(h.DB, escopo, c.ID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !visivel {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, false
+	}
+	return c, true
+}
+
+// carregarContato lê {id}/{contatoId}; em caso de erro já responde.
+func (h *Handler) carregarContato(w http.ResponseWriter, r *http.Request) (*models.ClienteContato, bool) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return nil, false
+	}
+	contatoID, err := strconv.Atoi(mux.Vars(r)["contatoId"])
+	if err != nil {
+		http.Error(w, "ID do contato inválido", http.StatusBadRequest)
+		return nil, false
+	}
+	ct, err := h.Repository.BuscarContato(h.DB, c.ID, uint(contatoID))
+	if err != nil {
+		http.Error(w, "Contato não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return ct, true
+}
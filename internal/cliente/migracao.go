@@ -0,0 +1,74 @@
+// internal/cliente/migracao.go
+package cliente
+
+import (
+	"fmt"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"gorm.io/gorm"
+)
+
+// Migrate cria os clientes a partir das negociações que ainda não têm um:
+// negociações com o mesmo CNPJ normalizado viram um único cliente (com os
+// dados mais recentes) e as sem CNPJ ganham um cliente cada. Os documentos de
+// escopo "cliente" dessas negociações são copiados para o cadastro,
+// preferindo o validado e, depois, o mais recente. Só mexe em negociações
+// sem cliente, então é seguro rodar a cada subida. Roda depois de
+// negociacao.Migrate (CNPJ normalizado) e documento.Migrate (escopo).
+func Migrate(db *gorm.DB) error {
+	for {
+		var negs []models.Negociacao
+		if err := db.Unscoped().
+			Where("cliente_id IS NULL").
+			Order("updated_at DESC, id DESC").
+			Limit(500).
+			Find(&negs).Error; err != nil {
+			return err
+		}
+		if len(negs) == 0 {
+			return nil
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			ids := make([]uint, len(negs))
+			for i := range negs {
+				// da mais recente para a mais antiga: a primeira de cada CNPJ cria
+				// o cliente (VincularCliente não altera cadastro existente)
+				if err := negociacao.VincularCliente(tx, &negs[i]); err != nil {
+					return fmt.Errorf("negociação %d: %w", negs[i].ID, err)
+				}
+				if err := tx.Unscoped().Model(&negs[i]).UpdateColumn("cliente_id", negs[i].ClienteID).Error; err != nil {
+					return err
+				}
+				ids[i] = negs[i].ID
+			}
+			return copiarDocumentos(tx, ids)
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copiarDocumentos leva para o cliente os documentos de escopo "cliente" das
+// negociações informadas, sem substituir o que o cliente já tem.
+func copiarDocumentos(db *gorm.DB, negociacaoIDs []uint) error {
+	if len(negociacaoIDs) == 0 {
+		return nil
+	}
+	return db.Exec(`
+		INSERT INTO cliente_documentos
+			(cliente_id, tipo_codigo, url, thumbnail_url, status, enviado_por_tipo, enviado_por_id,
+			 motivo_rejeicao, revisado_por_id, revisado_em, created_at, updated_at)
+		SELECT DISTINCT ON (n.cliente_id, d.tipo_codigo)
+			n.cliente_id, d.tipo_codigo, d.url, d.thumbnail_url, d.status, d.enviado_por_tipo, d.enviado_por_id,
+			d.motivo_rejeicao, d.revisado_por_id, d.revisado_em, d.created_at, d.updated_at
+		FROM negociacao_documentos d
+		JOIN negociacaos n ON n.id = d.negociacao_id
+		JOIN tipo_documentos t ON t.codigo = d.tipo_codigo
+		WHERE d.negociacao_id IN ? AND t.escopo = ? AND n.cliente_id IS NOT NULL AND d.url <> ''
+		ORDER BY n.cliente_id, d.tipo_codigo, (d.status = ?) DESC, d.updated_at DESC
+		ON CONFLICT (cliente_id, tipo_codigo) DO NOTHING`,
+		negociacaoIDs, models.EscopoCliente, models.StatusValidado).Error
+}
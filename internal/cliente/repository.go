@@ -0,0 +1,137 @@
+// internal/cliente/repository.go
+package cliente
+
+import (
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Repository define operações de persistência de clientes e contatos
+type Repository interface {
+	Listar(db *gorm.DB, escopo negociacao.Escopo, termo string, limite, offset int) ([]models.Cliente, int64, error)
+	BuscarPorID(db *gorm.DB, id uint) (*models.Cliente, error)
+	BuscarPorCNPJ(db *gorm.DB, cnpjNormalizado string) (*models.Cliente, error)
+	Visivel(db *gorm.DB, escopo negociacao.Escopo, id uint) (bool, error)
+	Salvar(db *gorm.DB, c *models.Cliente) error
+	PropagarParaNegociacoes(db *gorm.DB, c *models.Cliente) error
+
+	BuscarContato(db *gorm.DB, clienteID, contatoID uint) (*models.ClienteContato, error)
+	SalvarContato(db *gorm.DB, ct *models.ClienteContato) error
+	DeletarContato(db *gorm.DB, ct *models.ClienteContato) error
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+// condicaoEscopo: o cliente é visível quando alguma negociação dele está no escopo.
+func condicaoEscopo(e negociacao.Escopo) (string, []any) {
+	if e.Todos {
+		return "TRUE", nil
+	}
+	cond, args := e.SQL("n")
+	return "EXISTS (SELECT 1 FROM negociacaos n WHERE n.cliente_id = clientes.id AND n.deleted_at IS NULL AND " + cond + ")", args
+}
+
+func (r *repositoryImpl) Listar(db *gorm.DB, escopo negociacao.Escopo, termo string, limite, offset int) ([]models.Cliente, int64, error) {
+	cond, args := condicaoEscopo(escopo)
+	q := db.Model(&models.Cliente{}).Where(cond, args...)
+	if termo = strings.TrimSpace(termo); termo != "" {
+		if cnpj := utils.NormalizarCNPJ(termo); cnpj != "" {
+			q = q.Where("nome ILIKE ? OR cnpj_normalizado LIKE ?", "%"+termo+"%", cnpj+"%")
+		} else {
+			q = q.Where("nome ILIKE ?", "%"+termo+"%")
+		}
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := []models.Cliente{}
+	err := q.Order("nome ASC, id ASC").Limit(limite).Offset(offset).Find(&list).Error
+	return list, total, err
+}
+
+func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*models.Cliente, error) {
+	var c models.Cliente
+	err := db.
+		Preload("Contatos", func(db *gorm.DB) *gorm.DB { return db.Order("principal DESC, nome ASC") }).
+		Preload("Documentos").
+		First(&c, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *repositoryImpl) BuscarPorCNPJ(db *gorm.DB, cnpjNormalizado string) (*models.Cliente, error) {
+	var c models.Cliente
+	if err := db.Where("cnpj_normalizado = ?", cnpjNormalizado).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *repositoryImpl) Visivel(db *gorm.DB, escopo negociacao.Escopo, id uint) (bool, error) {
+	cond, args := condicaoEscopo(escopo)
+	var qtd int64
+	err := db.Model(&models.Cliente{}).Where("id = ?", id).Where(cond, args...).Count(&qtd).Error
+	return qtd > 0, err
+}
+
+func (r *repositoryImpl) Salvar(db *gorm.DB, c *models.Cliente) error {
+	return db.Omit("Contatos", "Documentos").Save(c).Error
+}
+
+// PropagarParaNegociacoes copia os dados básicos do cliente para a cópia
+// mantida em cada negociação dele (e sobe a versão de cada uma).
+func (r *repositoryImpl) PropagarParaNegociacoes(db *gorm.DB, c *models.Cliente) error {
+	return db.Model(&models.Negociacao{}).
+		Where("cliente_id = ?", c.ID).
+		Updates(map[string]any{
+			"nome":              c.Nome,
+			"cnpj":              c.CNPJ,
+			"cnpj_normalizado":  c.CNPJNormalizado,
+			"email":             c.Email,
+			"contato":           c.Contato,
+			"numero_do_contato": c.NumeroDoContato,
+			"telefone":          c.Telefone,
+			"uf":                c.UF,
+			"versao":            gorm.Expr("versao + 1"),
+		}).Error
+}
+
+func (r *repositoryImpl) BuscarContato(db *gorm.DB, clienteID, contatoID uint) (*models.ClienteContato, error) {
+	var ct models.ClienteContato
+	if err := db.Where("cliente_id = ?", clienteID).First(&ct, contatoID).Error; err != nil {
+		return nil, err
+	}
+	return &ct, nil
+}
+
+// SalvarContato grava o contato; se ele for o principal, os demais deixam de ser.
+func (r *repositoryImpl) SalvarContato(db *gorm.DB, ct *models.ClienteContato) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(ct).Error; err != nil {
+			return err
+		}
+		if !ct.Principal {
+			return nil
+		}
+		return tx.Model(&models.ClienteContato{}).
+			Where("cliente_id = ? AND id <> ?", ct.ClienteID, ct.ID).
+			Update("principal", false).Error
+	})
+}
+
+func (r *repositoryImpl) DeletarContato(db *gorm.DB, ct *models.ClienteContato) error {
+	return db.Delete(ct).Error
+}
@@ -0,0 +1,118 @@
+// internal/cliente/visao360.go
+package cliente
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KromaEnergia/api-consultor/internal/calculocomissao"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"gorm.io/gorm"
+)
+
+// Totais360 resume o relacionamento com o cliente.
+type Totais360 struct {
+	Negociacoes        int     `json:"negociacoes"`
+	NegociacoesAbertas int     `json:"negociacoesAbertas"` // nem fechadas nem canceladas
+	Contratos          int     `json:"contratos"`
+	ValorContratos     float64 `json:"valorContratos"`
+	ComissaoTotal      float64 `json:"comissaoTotal"`    // soma dos cálculos
+	ComissaoPaga       float64 `json:"comissaoPaga"`     // parcelas pagas
+	ComissaoPendente   float64 `json:"comissaoPendente"` // parcelas nem pagas nem canceladas
+}
+
+// Visao360 junta o cadastro do cliente com tudo o que foi negociado com ele
+// dentro do escopo do usuário.
+type Visao360 struct {
+	Cliente          models.Cliente                    `json:"cliente"`
+	Negociacoes      []negociacao.ResumoNegociacao     `json:"negociacoes"`
+	Contratos        []contrato.Contrato               `json:"contratos"`
+	CalculosComissao []calculocomissao.CalculoComissao `json:"calculosComissao"`
+	Totais           Totais360                         `json:"totais"`
+}
+
+// Visao360 trata GET /clientes/{id}/360
+func (h *Handler) Visao360(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarCliente(w, r)
+	if !ok {
+		return
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	v, err := MontarVisao360(h.DB, c, escopo)
+	if err != nil {
+		http.Error(w, "Erro ao montar visão do cliente", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// MontarVisao360 carrega negociações, contratos e comissões do cliente.
+func MontarVisao360(db *gorm.DB, c *models.Cliente, escopo negociacao.Escopo) (*Visao360, error) {
+	v := &Visao360{
+		Cliente:          *c,
+		Negociacoes:      []negociacao.ResumoNegociacao{},
+		Contratos:        []contrato.Contrato{},
+		CalculosComissao: []calculocomissao.CalculoComissao{},
+	}
+
+	f := negociacao.FiltrosListagem{ClienteID: c.ID, Pagina: 1, PorPagina: porPaginaMax}
+	for {
+		p, err := negociacao.ListarResumos(db, escopo, f)
+		if err != nil {
+			return nil, err
+		}
+		v.Negociacoes = append(v.Negociacoes, p.Negociacoes...)
+		if len(p.Negociacoes) == 0 || int64(len(v.Negociacoes)) >= p.Total {
+			break
+		}
+		f.Pagina++
+	}
+	if len(v.Negociacoes) == 0 {
+		return v, nil
+	}
+
+	ids := make([]uint, len(v.Negociacoes))
+	for i, n := range v.Negociacoes {
+		ids[i] = n.ID
+		if n.Status != models.StatusFechada && n.Status != models.StatusCancelada {
+			v.Totais.NegociacoesAbertas++
+		}
+	}
+	v.Totais.Negociacoes = len(ids)
+
+	if err := db.Where("negociacao_id IN ?", ids).Order("created_at DESC").Find(&v.Contratos).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Preload("Parcelas", func(db *gorm.DB) *gorm.DB { return db.Order("data_vencimento ASC") }).
+		Where("negociacao_id IN ?", ids).
+		Order("created_at DESC").
+		Find(&v.CalculosComissao).Error; err != nil {
+		return nil, err
+	}
+
+	v.Totais.Contratos = len(v.Contratos)
+	for _, ct := range v.Contratos {
+		v.Totais.ValorContratos += ct.Valor
+	}
+	for _, cc := range v.CalculosComissao {
+		v.Totais.ComissaoTotal += cc.TotalReceber
+		for _, p := range cc.Parcelas {
+			switch p.Status {
+			case "Pago":
+				v.Totais.ComissaoPaga += p.Valor
+			case "Cancelada":
+			default:
+				v.Totais.ComissaoPendente += p.Valor
+			}
+		}
+	}
+	return v, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
 	"github.com/KromaEnergia/api-consultor/internal/models"
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
@@ -97,8 +98,24 @@ func (h *Handler) CriarComentario(w http.ResponseWriter, r *http.Request) {
 		com.ComercialID = nil
 	}
 
-	// 6) Persiste
-	if err := h.DB.Create(&com).Error; err != nil {
+	// 6) Persiste (comentário + evento da linha do tempo)
+	ator := auth.AtorDaRequisicao(r)
+	if req.IsSystemComment {
+		ator = auth.AtorSistemaPadrao()
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&com).Error; err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   com.NegociacaoID,
+			Tipo:           evento.ComentarioCriado,
+			Descricao:      com.Texto,
+			ReferenciaTipo: "comentario",
+			ReferenciaID:   com.ID,
+		}.Por(ator))
+	})
+	if err != nil {
 		http.Error(w, "Erro ao salvar comentário", http.StatusInternalServerError)
 		return
 	}
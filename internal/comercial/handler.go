@@ -45,7 +45,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Emite access token e seta refresh (httpOnly) no cookie
-	access, err := auth.IssueTokensOnLogin(h.DB, w, user.ID /* isAdmin */, user.IsAdmin)
+	access, err := auth.IssueTokensOnLogin(h.DB, w, user.ID /* isAdmin */, user.IsAdmin, auth.TipoComercial)
 	if err != nil {
 		fmt.Print("Erro ao gerar tokens: ", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -16,6 +16,7 @@ type Comercial struct {
 	Password    string                `gorm:"size:255;not null" json:"-"`
 	Telefone    string                `gorm:"size:20" json:"telefone"`
 	Foto        string                `gorm:"size:255" json:"foto"`
+	FotoThumb   string                `gorm:"size:255" json:"fotoThumb"`
 	IsAdmin     bool                  `gorm:"default:false" json:"isAdmin"`
 	Consultores []consultor.Consultor `gorm:"foreignKey:ComercialID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"consultores"`
 	CreatedAt   time.Time             `json:"created_at"`
@@ -0,0 +1,89 @@
+// internal/consultor/comissoes.go
+package consultor
+
+import (
+	"github.com/KromaEnergia/api-consultor/internal/parcelacomissao"
+	"gorm.io/gorm"
+)
+
+// Participacao é uma negociação de outro consultor em que este recebe parte
+// da comissão: venda conjunta ou parcelas que ficaram com ele numa transferência.
+type Participacao struct {
+	NegociacaoID uint                              `json:"negociacaoId"`
+	Nome         string                            `json:"nome"`
+	Status       string                            `json:"status"`
+	ConsultorID  uint                              `json:"consultorId"` // dono da negociação
+	Percentual   float64                           `json:"percentual"`  // 0 quando não é venda conjunta
+	Parcelas     []parcelacomissao.ParcelaComissao `json:"parcelas"`
+}
+
+// condRecebe filtra parcelas (alias p, negociação n) que o consultor recebe:
+// a parcela aponta para ele ou segue a negociação e ele é o dono.
+const condRecebe = "COALESCE(p.consultor_id, n.consultor_id) = ?"
+
+// totaisComissao soma as parcelas recebidas e a receber do consultor, em
+// todas as negociações (próprias, conjuntas e transferidas).
+func totaisComissao(db *gorm.DB, consultorID uint) (aReceber, recebido float64, err error) {
+	var linhas []struct {
+		Status string
+		Total  float64
+	}
+	err = db.Table("parcela_comissaos AS p").
+		Select("p.status, COALESCE(SUM(p.valor), 0) AS total").
+		Joins("JOIN calculo_comissaos c ON c.id = p.calculo_comissao_id AND c.deleted_at IS NULL").
+		Joins("JOIN negociacaos n ON n.id = c.negociacao_id AND n.deleted_at IS NULL").
+		Where(condRecebe, consultorID).
+		Where("p.status IN ?", []string{"Pago", "Pendente", "Atrasado"}).
+		Group("p.status").
+		Scan(&linhas).Error
+	for _, l := range linhas {
+		if l.Status == "Pago" {
+			recebido += l.Total
+		} else {
+			aReceber += l.Total
+		}
+	}
+	return aReceber, recebido, err
+}
+
+// participacoes lista as negociações de outros consultores em que este tem
+// participação na divisão ou parcelas próprias, com as parcelas dele.
+func participacoes(db *gorm.DB, consultorID uint) ([]Participacao, error) {
+	out := []Participacao{}
+	err := db.Table("negociacaos AS n").
+		Select("n.id AS negociacao_id, n.nome, n.status, n.consultor_id, COALESCE(np.percentual, 0) AS percentual").
+		Joins("LEFT JOIN negociacao_participantes np ON np.negociacao_id = n.id AND np.consultor_id = ?", consultorID).
+		Where("n.deleted_at IS NULL AND n.consultor_id <> ?", consultorID).
+		Where(`np.id IS NOT NULL OR EXISTS (SELECT 1 FROM parcela_comissaos p
+			JOIN calculo_comissaos c ON c.id = p.calculo_comissao_id AND c.deleted_at IS NULL
+			WHERE c.negociacao_id = n.id AND p.consultor_id = ?)`, consultorID).
+		Order("n.id DESC").
+		Scan(&out).Error
+	if err != nil || len(out) == 0 {
+		return out, err
+	}
+
+	ids := make([]uint, len(out))
+	idx := make(map[uint]int, len(out))
+	for i, p := range out {
+		ids[i], idx[p.NegociacaoID] = p.NegociacaoID, i
+		out[i].Parcelas = []parcelacomissao.ParcelaComissao{}
+	}
+	var parcelas []struct {
+		parcelacomissao.ParcelaComissao
+		NegociacaoID uint
+	}
+	if err := db.Table("parcela_comissaos AS p").
+		Select("p.*, c.negociacao_id").
+		Joins("JOIN calculo_comissaos c ON c.id = p.calculo_comissao_id AND c.deleted_at IS NULL").
+		Where("c.negociacao_id IN ? AND p.consultor_id = ?", ids, consultorID).
+		Order("p.data_vencimento ASC").
+		Scan(&parcelas).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range parcelas {
+		i := idx[p.NegociacaoID]
+		out[i].Parcelas = append(out[i].Parcelas, p.ParcelaComissao)
+	}
+	return out, nil
+}
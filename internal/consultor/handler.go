@@ -115,7 +115,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Emite access token (RS256) e refresh cookie (httpOnly)
-	access, err := auth.IssueTokensOnLogin(h.DB, w, user.ID /* isAdmin */, user.IsAdmin)
+	access, err := auth.IssueTokensOnLogin(h.DB, w, user.ID /* isAdmin */, user.IsAdmin, auth.TipoConsultor)
 	if err != nil {
 		http.Error(w, "erro ao gerar token", http.StatusInternalServerError)
 		return
@@ -286,7 +286,7 @@ func (h *Handler) ObterResumoConsultor(w http.ResponseWriter, r *http.Request) {
 	}
 
 	negociacoes, _ := negociacao.NewRepository().ListarPorConsultor(h.DB, consultorObj.ID)
-	contratos, _ := contrato.NewRepository().ListarPorConsultor(h.DB, consultorObj.ID)
+	contratos, _ := contrato.NewRepository().ListarPorConsultor(h.DB, consultorObj.ID, contrato.Filtro{})
 	prodRepo := produtos.NewRepository(h.DB)
 	produtosList, _ := prodRepo.ListarPorConsultor(consultorObj.ID)
 	dto := MontarResumoConsultorDTO(*consultorObj, contratos, negociacoes, produtosList)
@@ -306,31 +306,27 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 		Preload("Negociacoes.Produtos").
 		Preload("Negociacoes.Comentarios").
 		Preload("Negociacoes.CalculosComissao").
-		Preload("Negociacoes.CalculosComissao.Parcelas").
+		// só as parcelas dele: nas próprias negociações, outro consultor pode
+		// ter parte (venda conjunta) ou ter ficado com parcelas (transferência)
+		Preload("Negociacoes.CalculosComissao.Parcelas", "consultor_id IS NULL OR consultor_id = ?", userID).
 		First(&c, userID).Error; err != nil {
 		http.Error(w, "Consultor não encontrado", http.StatusNotFound)
 		return
 	}
 
-	var aReceber float64
-	var recebido float64
-
-	for _, neg := range c.Negociacoes {
-		for _, calc := range neg.CalculosComissao {
-			for _, p := range calc.Parcelas {
-				switch p.Status {
-				case "Pago":
-					recebido += p.Valor
-				case "Pendente", "Atrasado":
-					aReceber += p.Valor
-				}
-			}
-		}
+	aReceber, recebido, err := totaisComissao(h.DB, userID)
+	if err != nil {
+		http.Error(w, "Erro ao somar comissões", http.StatusInternalServerError)
+		return
 	}
-
 	c.ComissaoAReceber = aReceber
 	c.ComissaoRecebida = recebido
 
+	if c.Participacoes, err = participacoes(h.DB, userID); err != nil {
+		http.Error(w, "Erro ao buscar participações", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(c)
 }
@@ -503,10 +499,12 @@ func (h *Handler) AtualizarMeuPerfil(w http.ResponseWriter, r *http.Request) {
 
 	// 3. Decodifica os novos dados POR CIMA do registro existente.
 	// Campos não enviados no JSON (como CNPJ) não serão alterados.
+	codigoIndicacao := consultorExistente.CodigoIndicacao
 	if err := json.NewDecoder(r.Body).Decode(&consultorExistente); err != nil {
 		http.Error(w, "Payload inválido", http.StatusBadRequest)
 		return
 	}
+	consultorExistente.CodigoIndicacao = codigoIndicacao // só muda pela rota de indicação
 
 	// 4. Salva o objeto completo e atualizado de volta no banco
 	if err := h.DB.Save(&consultorExistente).Error; err != nil {
@@ -630,12 +628,13 @@ func (h *ComissoesHandler) GetResumo(w http.ResponseWriter, r *http.Request) {
 	// RECEBIDAS (Pago)
 	h.DB.Table("parcela_comissaos AS pc").
 		Select(`
-            COALESCE(SUM(cc.energia_mensal), 0)            AS energia,
-            COALESCE(SUM(cc.valor_gestao_mensal), 0)       AS gestao
+            COALESCE(SUM(cc.energia_mensal * COALESCE(pc.percentual, 100) / 100), 0)      AS energia,
+            COALESCE(SUM(cc.valor_gestao_mensal * COALESCE(pc.percentual, 100) / 100), 0) AS gestao
         `).
 		Joins("JOIN calculo_comissaos AS cc ON cc.id = pc.calculo_comissao_id").
-		Joins("JOIN negociacoes     AS n  ON n.id  = cc.negociacao_id").
-		Where("n.consultor_id = ?", userID).
+		Joins("JOIN negociacaos     AS n  ON n.id  = cc.negociacao_id").
+		// venda conjunta ou divisão: cada um soma só a fatia gravada na parcela
+		Where("COALESCE(pc.consultor_id, n.consultor_id) = ?", userID).
 		// Se quiser, pode REMOVER esse filtro de status da negociação:
 		// Where("n.status IN ?", []string{"Fechada", "Contrato Assinado", "Ativa", "Vigente", "Em Execução"}).
 		Where("pc.status = ?", "Pago").
@@ -644,12 +643,13 @@ func (h *ComissoesHandler) GetResumo(w http.ResponseWriter, r *http.Request) {
 	// A RECEBER (Pendente OU Atrasado)
 	h.DB.Table("parcela_comissaos AS pc").
 		Select(`
-            COALESCE(SUM(cc.energia_mensal), 0)            AS energia,
-            COALESCE(SUM(cc.valor_gestao_mensal), 0)       AS gestao
+            COALESCE(SUM(cc.energia_mensal * COALESCE(pc.percentual, 100) / 100), 0)      AS energia,
+            COALESCE(SUM(cc.valor_gestao_mensal * COALESCE(pc.percentual, 100) / 100), 0) AS gestao
         `).
 		Joins("JOIN calculo_comissaos AS cc ON cc.id = pc.calculo_comissao_id").
-		Joins("JOIN negociacoes     AS n  ON n.id  = cc.negociacao_id").
-		Where("n.consultor_id = ?", userID).
+		Joins("JOIN negociacaos     AS n  ON n.id  = cc.negociacao_id").
+		// venda conjunta ou divisão: cada um soma só a fatia gravada na parcela
+		Where("COALESCE(pc.consultor_id, n.consultor_id) = ?", userID).
 		// Mesmo comentário do de cima: pode remover o filtro por status da negociação.
 		// Where("n.status IN ?", []string{"Fechada", "Contrato Assinado", "Ativa", "Vigente", "Em Execução"}).
 		Where("pc.status IN ?", []string{"Pendente", "Atrasado"}).
@@ -100,6 +100,7 @@ type Consultor struct {
 	EmailChangeApproved   bool                `json:"emailChangeApproved,omitempty"`
 	Telefone              string              `json:"telefone"`
 	Foto                  string              `json:"foto"`
+	FotoThumb             string              `json:"fotoThumb"`
 	DataNascimento        CustomDate          `json:"dataNascimento,omitempty"`
 	Estado                string              `json:"estado,omitempty"`
 	TermoDeParceria       string              `json:"termoDeParceria"`
@@ -108,8 +109,24 @@ type Consultor struct {
 	PrecisaRedefinirSenha bool                `json:"-"`
 	IsAdmin               bool                `json:"isAdmin"`
 	ComercialID           uint                `gorm:"not null" json:"comercial_id"`
+	CodigoIndicacao       string              `gorm:"size:12;uniqueIndex:idx_consultor_codigo_indicacao,where:codigo_indicacao <> ''" json:"codigoIndicacao"`
 	Negociacoes           []models.Negociacao `gorm:"foreignKey:ConsultorID" json:"negociacoes"`
 	ComissaoAReceber      float64             `gorm:"-" json:"comissaoAReceber"`
 	ComissaoRecebida      float64             `gorm:"-" json:"comissaoRecebida"`
-	Contratos             []contrato.Contrato `gorm:"foreignKey:NegociacaoID;constraint:OnDelete:CASCADE" json:"contratos"`
+	Participacoes         []Participacao      `gorm:"-" json:"participacoes,omitempty"` // negociações de outros em que recebe comissão
+	// Contratos das negociações do consultor; contratos.consultor_id acompanha as transferências
+	Contratos []contrato.Contrato `gorm:"foreignKey:ConsultorID;constraint:-" json:"contratos"`
+}
+
+// BeforeCreate dá ao consultor novo o código do link de indicação.
+func (c *Consultor) BeforeCreate(tx *gorm.DB) error {
+	if c.CodigoIndicacao != "" {
+		return nil
+	}
+	codigo, err := GerarCodigoIndicacao()
+	if err != nil {
+		return err
+	}
+	c.CodigoIndicacao = codigo
+	return nil
 }
@@ -2,7 +2,6 @@
 package consultor
 
 import (
-	"strings"
 	"time"
 
 	"github.com/KromaEnergia/api-consultor/internal/contrato"
@@ -53,17 +52,17 @@ func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*Consultor, error) {
 		Preload("Negociacoes").
 		Preload("Negociacoes.Produtos").
 		Preload("Negociacoes.Comentarios").
-		Preload("Negociacoes.Contrato").
+		Preload("Negociacoes.Contratos").
 		First(&c, id).Error
 	return &c, err
 }
 
 func (r *repositoryImpl) ListarTodos(db *gorm.DB) ([]Consultor, error) {
 	var consultores []Consultor
-	err := db.Preload("Negociacoes.Contrato").
+	err := db.Preload("Negociacoes.Contratos").
 		Preload("Negociacoes.Comentarios").
-		Preload("Contratos"). // Corrigido de "Contrato" para "Contratos"
-		Preload("Negociacoes.CalculoComissao").
+		Preload("Contratos").
+		Preload("Negociacoes.CalculosComissao").
 		Find(&consultores).Error
 	return consultores, err
 }
@@ -163,11 +162,10 @@ func MontarResumoConsultorDTO(
 		}
 	}
 
-	// conta negociações ativas…
+	// conta negociações ativas (ainda no pipeline)…
 	ativas := 0
 	for _, n := range negociacoes {
-		statusLower := strings.ToLower(strings.TrimSpace(n.Status))
-		if statusLower == "negociação ativa" || statusLower == "ativa" {
+		if !models.StatusEncerrado(n.Status) {
 			ativas++
 		}
 	}
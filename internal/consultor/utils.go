@@ -1,10 +1,35 @@
 // internal/consultor/utils.go
 package consultor
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"math/big"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// alfabetoIndicacao evita caracteres confundíveis (0/O, 1/I/L).
+const alfabetoIndicacao = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// tamanhoCodigoIndicacao: 31^8 combinações, suficiente para não colidir na prática.
+const tamanhoCodigoIndicacao = 8
 
 // CheckSenha compara o hash armazenado com a senha em texto
 func CheckSenha(hash, senha string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(senha))
 	return err == nil
 }
+
+// GerarCodigoIndicacao sorteia um código de indicação (ex.: "K7PX2MQA").
+func GerarCodigoIndicacao() (string, error) {
+	b := make([]byte, tamanhoCodigoIndicacao)
+	max := big.NewInt(int64(len(alfabetoIndicacao)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = alfabetoIndicacao[n.Int64()]
+	}
+	return string(b), nil
+}
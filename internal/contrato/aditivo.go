@@ -0,0 +1,511 @@
+// internal/contrato/aditivo.go
+package contrato
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Termos são as condições do contrato que um aditivo pode alterar. Num
+// aditivo, os campos nulos ficam como estavam; num snapshot, vêm todos.
+type Termos struct {
+	Valor            *float64   `json:"valor,omitempty"`
+	InicioSuprimento *time.Time `json:"inicioSuprimento,omitempty"`
+	FimSuprimento    *time.Time `json:"fimSuprimento,omitempty"`
+	ValorIntegral    *bool      `json:"valorIntegral,omitempty"`
+	Fee              *bool      `json:"fee,omitempty"`
+	FeePercent       *float64   `json:"feePercent,omitempty"`
+	UniPay           *bool      `json:"unipay,omitempty"`
+	UniPayPercent    *float64   `json:"unipayPercent,omitempty"`
+	MonPay           *bool      `json:"monPay,omitempty"`
+	MonthPay         *float64   `json:"monthPay,omitempty"`
+}
+
+// Aditivo altera as condições de um contrato a partir de VigenciaDesde. O
+// contrato guarda as condições originais em TermosOriginais e as atuais nos
+// próprios campos, recalculadas a cada aditivo que entra em vigor.
+type Aditivo struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ContratoID    uint       `gorm:"not null;uniqueIndex:idx_aditivo_contrato_numero,priority:1" json:"contratoId"`
+	Numero        int        `gorm:"not null;uniqueIndex:idx_aditivo_contrato_numero,priority:2" json:"numero"` // 1, 2, ... por contrato
+	VigenciaDesde time.Time  `gorm:"not null;index" json:"vigenciaDesde"`
+	Alteracoes    Termos     `gorm:"type:jsonb;serializer:json" json:"alteracoes"`
+	DocumentoURL  string     `gorm:"not null" json:"documentoUrl"`
+	Signatario    string     `gorm:"size:255;not null" json:"signatario"`
+	Motivo        string     `gorm:"type:text" json:"motivo"`
+	AplicadoEm    *time.Time `gorm:"index" json:"aplicadoEm"` // nulo enquanto a vigência não chegou
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (Aditivo) TableName() string { return "contrato_aditivos" }
+
+// Versao é o contrato como ficou depois de cada aditivo; a 0 é o original.
+type Versao struct {
+	Numero        int       `json:"numero"`
+	AditivoID     *uint     `json:"aditivoId,omitempty"`
+	VigenciaDesde time.Time `json:"vigenciaDesde"`
+	EmVigor       bool      `json:"emVigor"` // é a versão que vale hoje
+	Termos        Termos    `json:"termos"`
+	Alteracoes    *Termos   `json:"alteracoes,omitempty"`
+	DocumentoURL  string    `json:"documentoUrl,omitempty"`
+	Signatario    string    `json:"signatario,omitempty"`
+	Motivo        string    `json:"motivo,omitempty"`
+}
+
+// Reavaliacao resume o efeito de um aditivo nas parcelas de comissão.
+type Reavaliacao struct {
+	Fator              float64 `json:"fator"` // novo valor / valor anterior das parcelas em aberto
+	ParcelasAjustadas  int64   `json:"parcelasAjustadas"`
+	ParcelasCanceladas int64   `json:"parcelasCanceladas"` // venciam depois do novo fim do suprimento
+}
+
+// Parcelas que um aditivo ainda pode alterar
+var statusParcelaAbertos = []string{"Pendente", "Atrasado"}
+
+var (
+	errAditivoInvalido   = errors.New("aditivo inválido")
+	errAditivoVazio      = errors.New("o aditivo deve alterar ao menos uma condição")
+	errAditivoRetroativo = errors.New("a vigência não pode ser anterior à do último aditivo")
+)
+
+func val[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// termosDe tira o snapshot completo das condições atuais do contrato.
+func termosDe(c Contrato) Termos {
+	return Termos{
+		Valor:            ptr(c.Valor),
+		InicioSuprimento: ptr(c.InicioSuprimento),
+		FimSuprimento:    ptr(c.FimSuprimento),
+		ValorIntegral:    ptr(c.ValorIntegral),
+		Fee:              ptr(c.Fee),
+		FeePercent:       ptr(c.FeePercent),
+		UniPay:           ptr(c.UniPay),
+		UniPayPercent:    ptr(c.UniPayPercent),
+		MonPay:           ptr(c.MonPay),
+		MonthPay:         ptr(c.MonthPay),
+	}
+}
+
+// vazio diz se o aditivo não altera nada.
+func (t Termos) vazio() bool {
+	return t == Termos{}
+}
+
+// com devolve t com as alterações de a por cima.
+func (t Termos) com(a Termos) Termos {
+	if a.Valor != nil {
+		t.Valor = a.Valor
+	}
+	if a.InicioSuprimento != nil {
+		t.InicioSuprimento = a.InicioSuprimento
+	}
+	if a.FimSuprimento != nil {
+		t.FimSuprimento = a.FimSuprimento
+	}
+	if a.ValorIntegral != nil {
+		t.ValorIntegral = a.ValorIntegral
+	}
+	if a.Fee != nil {
+		t.Fee = a.Fee
+	}
+	if a.FeePercent != nil {
+		t.FeePercent = a.FeePercent
+	}
+	if a.UniPay != nil {
+		t.UniPay = a.UniPay
+	}
+	if a.UniPayPercent != nil {
+		t.UniPayPercent = a.UniPayPercent
+	}
+	if a.MonPay != nil {
+		t.MonPay = a.MonPay
+	}
+	if a.MonthPay != nil {
+		t.MonthPay = a.MonthPay
+	}
+	return t
+}
+
+// aplicarEm grava um snapshot completo nos campos do contrato.
+func (t Termos) aplicarEm(c *Contrato) {
+	c.Valor = val(t.Valor)
+	c.InicioSuprimento = val(t.InicioSuprimento)
+	c.FimSuprimento = val(t.FimSuprimento)
+	c.ValorIntegral = val(t.ValorIntegral)
+	c.Fee = val(t.Fee)
+	c.FeePercent = val(t.FeePercent)
+	c.UniPay = val(t.UniPay)
+	c.UniPayPercent = val(t.UniPayPercent)
+	c.MonPay = val(t.MonPay)
+	c.MonthPay = val(t.MonthPay)
+}
+
+// receita é a base da comissão nas condições t: a mensalidade nos contratos
+// com pagamento mensal, senão o valor do contrato, com o fee aplicado quando houver.
+func (t Termos) receita() float64 {
+	base := val(t.Valor)
+	if val(t.MonPay) {
+		base = val(t.MonthPay)
+	}
+	if val(t.Fee) && val(t.FeePercent) > 0 {
+		base *= val(t.FeePercent)
+	}
+	return base
+}
+
+// validar confere os valores informados no aditivo.
+func (t Termos) validar() error {
+	switch {
+	case t.vazio():
+		return errAditivoVazio
+	case t.Valor != nil && *t.Valor < 0, t.MonthPay != nil && *t.MonthPay < 0:
+		return errors.New("valores não podem ser negativos")
+	case t.FeePercent != nil && (*t.FeePercent < 0 || *t.FeePercent > 1),
+		t.UniPayPercent != nil && (*t.UniPayPercent < 0 || *t.UniPayPercent > 1):
+		return errors.New("percentuais devem estar entre 0 e 1")
+	}
+	return nil
+}
+
+// mudaTermos diz se t altera alguma condição em vigor de c. Depois do
+// primeiro aditivo, PUT/PATCH não podem mais mudar as condições direto.
+func mudaTermos(c Contrato, t Termos) bool {
+	difere := func(p *float64, v float64) bool { return p != nil && math.Abs(*p-v) > 0.000001 }
+	difereBool := func(p *bool, v bool) bool { return p != nil && *p != v }
+	difereData := func(p *time.Time, v time.Time) bool { return p != nil && !p.Equal(v) }
+	return difere(t.Valor, c.Valor) ||
+		difereData(t.InicioSuprimento, c.InicioSuprimento) ||
+		difereData(t.FimSuprimento, c.FimSuprimento) ||
+		difereBool(t.ValorIntegral, c.ValorIntegral) ||
+		difereBool(t.Fee, c.Fee) ||
+		difere(t.FeePercent, c.FeePercent) ||
+		difereBool(t.UniPay, c.UniPay) ||
+		difere(t.UniPayPercent, c.UniPayPercent) ||
+		difereBool(t.MonPay, c.MonPay) ||
+		difere(t.MonthPay, c.MonthPay)
+}
+
+// msgTermosComAditivo é a resposta do PUT/PATCH que tenta mudar as condições
+// de um contrato com aditivos.
+const msgTermosComAditivo = "Contrato com aditivos: altere as condições registrando um novo aditivo"
+
+// Versoes monta o histórico do contrato: o original e o resultado acumulado
+// de cada aditivo, na ordem de vigência.
+func Versoes(db *gorm.DB, c Contrato, hoje time.Time) ([]Versao, error) {
+	var aditivos []Aditivo
+	if err := db.Where("contrato_id = ?", c.ID).Order("vigencia_desde ASC, numero ASC").Find(&aditivos).Error; err != nil {
+		return nil, err
+	}
+	base := termosDe(c)
+	if c.TermosOriginais != nil {
+		base = *c.TermosOriginais
+	}
+
+	versoes := []Versao{{Numero: 0, VigenciaDesde: val(base.InicioSuprimento), Termos: base, EmVigor: true}}
+	atual := base
+	for _, a := range aditivos {
+		atual = atual.com(a.Alteracoes)
+		alteracoes := a.Alteracoes
+		v := Versao{
+			Numero:        a.Numero,
+			AditivoID:     &a.ID,
+			VigenciaDesde: a.VigenciaDesde,
+			Termos:        atual,
+			Alteracoes:    &alteracoes,
+			DocumentoURL:  a.DocumentoURL,
+			Signatario:    a.Signatario,
+			Motivo:        a.Motivo,
+		}
+		if !a.VigenciaDesde.After(hoje) {
+			for i := range versoes {
+				versoes[i].EmVigor = false
+			}
+			v.EmVigor = true
+		}
+		versoes = append(versoes, v)
+	}
+	return versoes, nil
+}
+
+// AplicarAditivos aplica ao contrato, travado pela transação, os aditivos que
+// entraram em vigor até hoje e ainda não foram aplicados, reavaliando as
+// parcelas de comissão a partir da vigência de cada um.
+func AplicarAditivos(tx *gorm.DB, c *Contrato, hoje time.Time, ator auth.Ator) ([]Aditivo, error) {
+	var pendentes []Aditivo
+	if err := tx.Where("contrato_id = ? AND aplicado_em IS NULL AND vigencia_desde < ?", c.ID, hoje.AddDate(0, 0, 1)).
+		Order("vigencia_desde ASC, numero ASC").Find(&pendentes).Error; err != nil {
+		return nil, err
+	}
+	if len(pendentes) == 0 {
+		return nil, nil
+	}
+
+	fimAnterior := c.FimSuprimento
+	agora := time.Now()
+	for i := range pendentes {
+		a := &pendentes[i]
+		antes := termosDe(*c)
+		depois := antes.com(a.Alteracoes)
+		depois.aplicarEm(c)
+
+		reav, err := reavaliarComissoes(tx, c.NegociacaoID, c.ID, antes, depois, a.VigenciaDesde)
+		if err != nil {
+			return nil, err
+		}
+		a.AplicadoEm = &agora
+		if err := tx.Model(a).Update("aplicado_em", agora).Error; err != nil {
+			return nil, err
+		}
+		if err := evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   c.NegociacaoID,
+			Tipo:           evento.AditivoAplicado,
+			Descricao:      fmt.Sprintf("Aditivo %d do contrato #%d em vigor desde %s", a.Numero, c.ID, a.VigenciaDesde.Format("02/01/2006")),
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   c.ID,
+			Dados:          map[string]any{"aditivoId": a.ID, "alteracoes": a.Alteracoes, "reavaliacao": reav},
+		}.Por(ator)); err != nil {
+			return nil, err
+		}
+	}
+
+	campos := map[string]any{
+		"valor": c.Valor, "inicio_suprimento": c.InicioSuprimento, "fim_suprimento": c.FimSuprimento,
+		"valor_integral": c.ValorIntegral, "fee": c.Fee, "fee_percent": c.FeePercent,
+		"uni_pay": c.UniPay, "uni_pay_percent": c.UniPayPercent, "mon_pay": c.MonPay, "month_pay": c.MonthPay,
+		"versao": gorm.Expr("versao + 1"),
+	}
+	// novo fim de suprimento: os avisos de vencimento recomeçam
+	if !c.FimSuprimento.Equal(fimAnterior) {
+		c.AlertaVencimentoDias = 0
+		campos["alerta_vencimento_dias"] = 0
+	}
+	if err := tx.Model(&Contrato{}).Where("id = ?", c.ID).Updates(campos).Error; err != nil {
+		return nil, err
+	}
+	c.Versao++
+	return pendentes, nil
+}
+
+// reavaliarComissoes ajusta as parcelas em aberto do contrato (geradas dele
+// ou de um cálculo gerado dele) que vencem a partir de desde: o valor
+// acompanha a variação da receita do contrato e as que venceriam depois de
+// um fim de suprimento antecipado são canceladas. Parcelas de outros
+// contratos ou lançadas sem contrato não mudam.
+func reavaliarComissoes(tx *gorm.DB, negID, contratoID uint, antes, depois Termos, desde time.Time) (Reavaliacao, error) {
+	res := Reavaliacao{Fator: 1}
+	calculos := `calculo_comissao_id IN (SELECT id FROM calculo_comissaos WHERE negociacao_id = ? AND deleted_at IS NULL)
+		AND (contrato_id = ? OR calculo_comissao_id IN (SELECT id FROM calculo_comissaos WHERE contrato_id = ?))`
+
+	if ra, rd := antes.receita(), depois.receita(); ra > 0 && math.Abs(rd-ra) > 0.000001 {
+		res.Fator = rd / ra
+		upd := tx.Table("parcela_comissaos").
+			Where(calculos, negID, contratoID, contratoID).
+			Where("status IN ? AND data_vencimento >= ?", statusParcelaAbertos, desde).
+			Updates(map[string]any{"valor": gorm.Expr("ROUND((valor * ?)::numeric, 2)", res.Fator), "updated_at": gorm.Expr("NOW()")})
+		if upd.Error != nil {
+			return res, upd.Error
+		}
+		res.ParcelasAjustadas = upd.RowsAffected
+	}
+
+	if fim := val(depois.FimSuprimento); !fim.IsZero() && fim.Before(val(antes.FimSuprimento)) {
+		upd := tx.Table("parcela_comissaos").
+			Where(calculos, negID, contratoID, contratoID).
+			Where("status IN ? AND data_vencimento > ?", statusParcelaAbertos, fim).
+			Updates(map[string]any{"status": "Cancelada", "updated_at": gorm.Expr("NOW()")})
+		if upd.Error != nil {
+			return res, upd.Error
+		}
+		res.ParcelasCanceladas = upd.RowsAffected
+	}
+
+	if res.ParcelasAjustadas+res.ParcelasCanceladas == 0 {
+		return res, nil
+	}
+	// mesmo critério de parcelacomissao: o total é a soma das parcelas, só
+	// nos cálculos que tiveram parcelas deste contrato alteradas
+	return res, tx.Exec(`
+		UPDATE calculo_comissaos cc SET total_receber = COALESCE((SELECT SUM(p.valor)
+			FROM parcela_comissaos p WHERE p.calculo_comissao_id = cc.id), 0), updated_at = NOW()
+		WHERE cc.negociacao_id = ? AND cc.deleted_at IS NULL
+			AND (cc.contrato_id = ? OR cc.id IN (SELECT calculo_comissao_id FROM parcela_comissaos WHERE contrato_id = ?))`,
+		negID, contratoID, contratoID).Error
+}
+
+type aditivoRequest struct {
+	VigenciaDesde time.Time `json:"vigenciaDesde"`
+	Alteracoes    Termos    `json:"alteracoes"`
+	DocumentoURL  string    `json:"documentoUrl"`
+	Signatario    string    `json:"signatario"`
+	Motivo        string    `json:"motivo"`
+}
+
+// RegistrarAditivo trata POST /contratos/{id}/aditivos (comercial do time/admin)
+// Body: { "vigenciaDesde": "...", "alteracoes": { "valor": 1200, "fimSuprimento": "..." },
+// "documentoUrl": "https://...", "signatario": "Nome", "motivo": "..." }
+// Com vigência até hoje, o aditivo é aplicado na hora; senão, pelo monitor de contratos.
+func (h *Handler) RegistrarAditivo(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+	if !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	atual, ok := h.carregarContrato(w, r)
+	if !ok {
+		return
+	}
+	id := atual.ID
+	var req aditivoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.DocumentoURL = strings.TrimSpace(req.DocumentoURL)
+	req.Signatario = strings.TrimSpace(req.Signatario)
+	switch {
+	case req.VigenciaDesde.IsZero():
+		http.Error(w, "o campo 'vigenciaDesde' é obrigatório", http.StatusBadRequest)
+		return
+	case req.DocumentoURL == "":
+		http.Error(w, "o campo 'documentoUrl' é obrigatório", http.StatusBadRequest)
+		return
+	case req.Signatario == "":
+		http.Error(w, "o campo 'signatario' é obrigatório", http.StatusBadRequest)
+		return
+	}
+	if err := req.Alteracoes.validar(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	var a Aditivo
+	var c Contrato
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, id).Error; err != nil {
+			return err
+		}
+		if c.Status == StatusCancelado || c.Status == StatusEncerrado {
+			return fmt.Errorf("%w: contrato %s", errAditivoInvalido, strings.ToLower(c.Status))
+		}
+
+		// o aditivo entra depois dos existentes e o resultado final precisa fechar
+		versoes, err := Versoes(tx, c, hoje)
+		if err != nil {
+			return err
+		}
+		ultima := versoes[len(versoes)-1]
+		if ultima.Numero > 0 && req.VigenciaDesde.Before(ultima.VigenciaDesde) {
+			return errAditivoRetroativo
+		}
+		final := ultima.Termos.com(req.Alteracoes)
+		if ini, fim := val(final.InicioSuprimento), val(final.FimSuprimento); !fim.IsZero() && !fim.After(ini) {
+			return fmt.Errorf("%w: o fim do suprimento deve ser posterior ao início", errAditivoInvalido)
+		}
+
+		if c.TermosOriginais == nil {
+			base := termosDe(c)
+			if err := tx.Model(&c).Update("termos_originais", &base).Error; err != nil {
+				return err
+			}
+			c.TermosOriginais = &base
+		}
+		a = Aditivo{
+			ContratoID:    c.ID,
+			Numero:        ultima.Numero + 1,
+			VigenciaDesde: req.VigenciaDesde,
+			Alteracoes:    req.Alteracoes,
+			DocumentoURL:  req.DocumentoURL,
+			Signatario:    req.Signatario,
+			Motivo:        strings.TrimSpace(req.Motivo),
+		}
+		if err := tx.Create(&a).Error; err != nil {
+			return err
+		}
+		if err := evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   c.NegociacaoID,
+			Tipo:           evento.AditivoRegistrado,
+			Descricao:      fmt.Sprintf("Aditivo %d do contrato #%d assinado por %s", a.Numero, c.ID, a.Signatario),
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   c.ID,
+			Dados:          map[string]any{"aditivoId": a.ID, "vigenciaDesde": a.VigenciaDesde, "alteracoes": a.Alteracoes},
+		}.Por(ator)); err != nil {
+			return err
+		}
+
+		aplicados, err := AplicarAditivos(tx, &c, hoje, ator)
+		for _, ap := range aplicados {
+			if ap.ID == a.ID {
+				a.AplicadoEm = ap.AplicadoEm
+			}
+		}
+		return err
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, errAditivoRetroativo), errors.Is(err, errAditivoInvalido):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao registrar aditivo", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]any{"aditivo": a, "contrato": c})
+}
+
+// ListarAditivos trata GET /contratos/{id}/aditivos (escopo da negociação)
+func (h *Handler) ListarAditivos(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarContrato(w, r)
+	if !ok {
+		return
+	}
+	aditivos := []Aditivo{}
+	if err := h.DB.Where("contrato_id = ?", c.ID).Order("numero ASC").Find(&aditivos).Error; err != nil {
+		http.Error(w, "Erro ao listar aditivos", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(aditivos)
+}
+
+// ListarVersoes trata GET /contratos/{id}/versoes: o contrato original e cada
+// versão resultante dos aditivos, com a que está em vigor hoje marcada.
+// Só dentro do escopo da negociação.
+func (h *Handler) ListarVersoes(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.carregarContrato(w, r)
+	if !ok {
+		return
+	}
+	versoes, err := Versoes(h.DB, c, time.Now())
+	if err != nil {
+		http.Error(w, "Erro ao montar versões do contrato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(versoes)
+}
@@ -0,0 +1,110 @@
+package contrato
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTermosReceita(t *testing.T) {
+	casos := []struct {
+		nome   string
+		termos Termos
+		quer   float64
+	}{
+		{"valor do contrato", Termos{Valor: ptr(1200.0)}, 1200},
+		{"fee sobre o valor", Termos{Valor: ptr(1200.0), Fee: ptr(true), FeePercent: ptr(0.1)}, 120},
+		{"fee desligado ignora o percentual", Termos{Valor: ptr(1200.0), Fee: ptr(false), FeePercent: ptr(0.1)}, 1200},
+		{"fee sem percentual", Termos{Valor: ptr(1200.0), Fee: ptr(true)}, 1200},
+		{"mensalidade no lugar do valor", Termos{Valor: ptr(1200.0), MonPay: ptr(true), MonthPay: ptr(300.0)}, 300},
+		{"fee sobre a mensalidade", Termos{MonPay: ptr(true), MonthPay: ptr(300.0), Fee: ptr(true), FeePercent: ptr(0.5)}, 150},
+		{"sem condições", Termos{}, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if got := c.termos.receita(); math.Abs(got-c.quer) > 1e-9 {
+				t.Errorf("receita = %v, quer %v", got, c.quer)
+			}
+		})
+	}
+}
+
+// O fator da reavaliação é receita depois / receita antes, sobre o snapshot
+// acumulado (com) e não só sobre as alterações do aditivo.
+func TestTermosComFatorReavaliacao(t *testing.T) {
+	fim := time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC)
+	atual := termosDe(Contrato{Valor: 1000, Fee: true, FeePercent: 0.1, FimSuprimento: fim})
+	casos := []struct {
+		nome       string
+		alteracoes Termos
+		fator      float64
+	}{
+		{"valor dobra", Termos{Valor: ptr(2000.0)}, 2},
+		{"fee cai pela metade", Termos{FeePercent: ptr(0.05)}, 0.5},
+		{"fee desligado", Termos{Fee: ptr(false)}, 10},
+		{"só o fim muda", Termos{FimSuprimento: ptr(fim.AddDate(0, -6, 0))}, 1},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			depois := atual.com(c.alteracoes)
+			if got := depois.receita() / atual.receita(); math.Abs(got-c.fator) > 1e-9 {
+				t.Errorf("fator = %v, quer %v", got, c.fator)
+			}
+			if c.alteracoes.FimSuprimento == nil && !val(depois.FimSuprimento).Equal(fim) {
+				t.Errorf("fim do suprimento mudou para %s", val(depois.FimSuprimento))
+			}
+		})
+	}
+}
+
+func TestTermosValidar(t *testing.T) {
+	casos := []struct {
+		nome     string
+		termos   Termos
+		invalido bool
+		err      error
+	}{
+		{"vazio", Termos{}, true, errAditivoVazio},
+		{"valor negativo", Termos{Valor: ptr(-1.0)}, true, nil},
+		{"mensalidade negativa", Termos{MonthPay: ptr(-1.0)}, true, nil},
+		{"fee acima de 1", Termos{FeePercent: ptr(1.5)}, true, nil},
+		{"unipay negativo", Termos{UniPayPercent: ptr(-0.1)}, true, nil},
+		{"valor zero", Termos{Valor: ptr(0.0)}, false, nil},
+		{"fee 100%", Termos{FeePercent: ptr(1.0)}, false, nil},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			err := c.termos.validar()
+			if (err != nil) != c.invalido {
+				t.Fatalf("validar() = %v, inválido quer %v", err, c.invalido)
+			}
+			if c.err != nil && !errors.Is(err, c.err) {
+				t.Errorf("erro = %v, quer %v", err, c.err)
+			}
+		})
+	}
+}
+
+func TestMudaTermos(t *testing.T) {
+	inicio := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	c := Contrato{Valor: 1000, InicioSuprimento: inicio, FeePercent: 0.1}
+	casos := []struct {
+		nome   string
+		termos Termos
+		quer   bool
+	}{
+		{"nada informado", Termos{}, false},
+		{"mesmos valores", Termos{Valor: ptr(1000.0), InicioSuprimento: ptr(inicio), FeePercent: ptr(0.1)}, false},
+		{"mesma data em outro fuso", Termos{InicioSuprimento: ptr(inicio.In(time.FixedZone("BRT", -3*3600)))}, false},
+		{"valor diferente", Termos{Valor: ptr(1000.01)}, true},
+		{"liga o mensal", Termos{MonPay: ptr(true)}, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if got := mudaTermos(c, caso.termos); got != caso.quer {
+				t.Errorf("mudaTermos = %v, quer %v", got, caso.quer)
+			}
+		})
+	}
+}
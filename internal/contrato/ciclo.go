@@ -0,0 +1,208 @@
+// internal/contrato/ciclo.go
+package contrato
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dataValida descarta contratos sem datas de suprimento (time.Time zero).
+const dataValida = "'1900-01-01'"
+
+// Monitor acompanha o ciclo de vida dos contratos: aplica os aditivos que
+// entram em vigor, marca Vigente/Encerrado pelas datas de suprimento e avisa
+// consultor e comercial antes do fim.
+type Monitor struct {
+	DB          *gorm.DB
+	Notificador notificacao.Notificador
+	Intervalo   time.Duration
+	AlertasDias []int // antecedências do aviso de vencimento, em ordem crescente
+}
+
+// NewMonitorFromEnv lê CONTRATO_MONITOR_INTERVALO (padrão 1h) e
+// CONTRATO_ALERTAS_DIAS (padrão "180,90,30").
+func NewMonitorFromEnv(db *gorm.DB, notif notificacao.Notificador) *Monitor {
+	m := &Monitor{DB: db, Notificador: notif, Intervalo: time.Hour, AlertasDias: []int{30, 90, 180}}
+	if v := os.Getenv("CONTRATO_MONITOR_INTERVALO"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			m.Intervalo = d
+		} else {
+			log.Printf("[contrato] CONTRATO_MONITOR_INTERVALO inválido (%q); usando %s", v, m.Intervalo)
+		}
+	}
+	if v := os.Getenv("CONTRATO_ALERTAS_DIAS"); v != "" {
+		var dias []int
+		for _, p := range strings.Split(v, ",") {
+			if d, err := strconv.Atoi(strings.TrimSpace(p)); err == nil && d > 0 {
+				dias = append(dias, d)
+			}
+		}
+		if len(dias) > 0 {
+			sort.Ints(dias)
+			m.AlertasDias = dias
+		} else {
+			log.Printf("[contrato] CONTRATO_ALERTAS_DIAS inválido (%q); usando %v", v, m.AlertasDias)
+		}
+	}
+	return m
+}
+
+// Iniciar roda o monitor em background até o contexto ser cancelado.
+func (m *Monitor) Iniciar(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.Intervalo)
+		defer ticker.Stop()
+		for {
+			m.Executar(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Executar faz uma varredura.
+func (m *Monitor) Executar(agora time.Time) {
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	m.aplicarAditivos(hoje)
+	m.atualizarStatus(hoje)
+	m.avisarVencimentos(hoje)
+}
+
+// aplicarAditivos aplica os aditivos cuja vigência chegou. Cada contrato é
+// travado na transação, então várias instâncias da API não aplicam duas vezes.
+func (m *Monitor) aplicarAditivos(hoje time.Time) {
+	var ids []uint
+	if err := m.DB.Model(&Aditivo{}).Distinct("contrato_id").
+		Where("aplicado_em IS NULL AND vigencia_desde < ?", hoje.AddDate(0, 0, 1)).
+		Pluck("contrato_id", &ids).Error; err != nil {
+		log.Printf("[contrato] erro ao buscar aditivos vigentes: %v", err)
+		return
+	}
+	for _, id := range ids {
+		err := m.DB.Transaction(func(tx *gorm.DB) error {
+			var c Contrato
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, id).Error; err != nil {
+				return err
+			}
+			_, err := AplicarAditivos(tx, &c, hoje, auth.AtorSistemaPadrao())
+			return err
+		})
+		if err != nil {
+			log.Printf("[contrato] erro ao aplicar aditivos do contrato %d: %v", id, err)
+		}
+	}
+}
+
+// atualizarStatus aplica Vigente (dentro do período de suprimento) e
+// Encerrado (depois do fim). Só contrato assinado fica vigente, inclusive os
+// sucessores de renovação.
+func (m *Monitor) atualizarStatus(hoje time.Time) {
+	vigentes := m.DB.Exec(`
+		UPDATE contratos SET status = ?, versao = versao + 1, updated_at = NOW()
+		WHERE deleted_at IS NULL AND status NOT IN (?, ?, ?)
+			AND inicio_suprimento > `+dataValida+` AND inicio_suprimento < ?
+			AND fim_suprimento >= ?
+			AND data_assinatura > `+dataValida,
+		StatusVigente, StatusVigente, StatusEncerrado, StatusCancelado,
+		hoje.AddDate(0, 0, 1), hoje)
+	if vigentes.Error != nil {
+		log.Printf("[contrato] erro ao marcar contratos vigentes: %v", vigentes.Error)
+	}
+
+	encerrados := m.DB.Exec(`
+		UPDATE contratos SET status = ?, versao = versao + 1, updated_at = NOW()
+		WHERE deleted_at IS NULL AND status NOT IN (?, ?)
+			AND fim_suprimento > `+dataValida+` AND fim_suprimento < ?`,
+		StatusEncerrado, StatusEncerrado, StatusCancelado, hoje)
+	if encerrados.Error != nil {
+		log.Printf("[contrato] erro ao encerrar contratos: %v", encerrados.Error)
+	}
+	if vigentes.RowsAffected+encerrados.RowsAffected > 0 {
+		log.Printf("[contrato] %d contrato(s) vigente(s), %d encerrado(s)", vigentes.RowsAffected, encerrados.RowsAffected)
+	}
+}
+
+type contratoVencendo struct {
+	ID            uint
+	NegociacaoID  uint
+	Nome          string
+	Tipo          string
+	FimSuprimento time.Time
+	ConsultorID   uint
+	ComercialID   *uint
+}
+
+// avisarVencimentos envia um aviso por antecedência configurada. As
+// antecedências são processadas da menor para a maior, então um contrato que
+// entrou tarde na janela recebe só o aviso mais urgente. O UPDATE condicional
+// reserva cada aviso, então várias instâncias da API não duplicam.
+func (m *Monitor) avisarVencimentos(hoje time.Time) {
+	for _, dias := range m.AlertasDias {
+		var lista []contratoVencendo
+		err := m.DB.Raw(`
+			UPDATE contratos c SET alerta_vencimento_dias = ?
+			FROM negociacaos n
+			WHERE n.id = c.negociacao_id AND n.deleted_at IS NULL
+				AND c.deleted_at IS NULL AND c.status NOT IN (?, ?) AND c.renovado_por_id IS NULL
+				AND c.fim_suprimento >= ? AND c.fim_suprimento < ?
+				AND (c.alerta_vencimento_dias = 0 OR c.alerta_vencimento_dias > ?)
+			RETURNING c.id, c.negociacao_id, n.nome, c.tipo, c.fim_suprimento, n.consultor_id,
+				(SELECT co.comercial_id FROM consultors co WHERE co.id = n.consultor_id) AS comercial_id`,
+			dias, StatusEncerrado, StatusCancelado, hoje, hoje.AddDate(0, 0, dias+1), dias).Scan(&lista).Error
+		if err != nil {
+			log.Printf("[contrato] erro ao buscar contratos vencendo em %d dias: %v", dias, err)
+			continue
+		}
+		for _, c := range lista {
+			restam := int(c.FimSuprimento.Sub(hoje).Hours() / 24)
+			msg := fmt.Sprintf("O contrato de %s de %s termina em %s (%d dias)",
+				c.Tipo, c.Nome, c.FimSuprimento.Format("02/01/2006"), restam)
+			if err := evento.Registrar(m.DB, evento.Evento{
+				NegociacaoID:   c.NegociacaoID,
+				Tipo:           evento.ContratoVencendo,
+				Descricao:      msg,
+				ReferenciaTipo: "contrato",
+				ReferenciaID:   c.ID,
+				Dados:          map[string]any{"antecedenciaDias": dias, "fimSuprimento": c.FimSuprimento},
+			}); err != nil {
+				log.Printf("[contrato] erro ao registrar aviso do contrato %d: %v", c.ID, err)
+			}
+			m.notificar(c, dias, msg)
+		}
+	}
+}
+
+func (m *Monitor) notificar(c contratoVencendo, dias int, msg string) {
+	if m.Notificador == nil {
+		return
+	}
+	destinos := []notificacao.Notificacao{{DestinatarioTipo: notificacao.DestinoConsultor, DestinatarioID: c.ConsultorID}}
+	if c.ComercialID != nil && *c.ComercialID != 0 {
+		destinos = append(destinos, notificacao.Notificacao{DestinatarioTipo: notificacao.DestinoComercial, DestinatarioID: *c.ComercialID})
+	}
+	for _, d := range destinos {
+		d.Tipo = "contrato_vencendo"
+		d.Titulo = fmt.Sprintf("Contrato vence em até %d dias", dias)
+		d.Mensagem = msg
+		d.NegociacaoID = c.NegociacaoID
+		d.Dados = map[string]any{"contratoId": c.ID, "antecedenciaDias": dias}
+		if err := m.Notificador.Notificar(d); err != nil {
+			log.Printf("[contrato] erro ao notificar vencimento do contrato %d: %v", c.ID, err)
+		}
+	}
+}
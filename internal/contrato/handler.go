@@ -2,10 +2,15 @@ package contrato
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
@@ -89,8 +94,21 @@ func (h *Handler) CriarParaNegociacao(w http.ResponseWriter, r *http.Request) {
 		MonthPay: dto.MonthPay,
 	}
 
-	// 5. Persiste no banco
-	if err := h.Repository.Salvar(h.DB, &c); err != nil {
+	// 5. Persiste no banco (contrato + evento da linha do tempo)
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := h.Repository.Salvar(tx, &c); err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   c.NegociacaoID,
+			Tipo:           evento.ContratoCriado,
+			Descricao:      "Contrato criado: " + c.Tipo,
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   c.ID,
+			Dados:          map[string]any{"valor": c.Valor},
+		}.Por(auth.AtorDaRequisicao(r)))
+	})
+	if err != nil {
 		http.Error(w, "Erro ao salvar contrato", http.StatusInternalServerError)
 		return
 	}
@@ -101,25 +119,61 @@ func (h *Handler) CriarParaNegociacao(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(c)
 }
 
-// BuscarPorNegociacao retorna o contrato
+// BuscarPorNegociacao trata GET /negociacoes/{id}/contrato?tipo=
+// Devolve só o primeiro contrato; a lista completa está em /negociacoes/{id}/contratos.
+// Fora do escopo do usuário, responde como se não houvesse contrato.
 func (h *Handler) BuscarPorNegociacao(w http.ResponseWriter, r *http.Request) {
-	negID, _ := strconv.Atoi(mux.Vars(r)["id"])
-	c, err := h.Repository.BuscarPorNegociacao(h.DB, uint(negID))
+	negID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	ok, err := e.AlcancaNegociacao(h.DB, uint(negID))
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	c, err := h.Repository.BuscarPorNegociacao(h.DB, uint(negID), FiltroDaQuery(r.URL.Query()))
 	if err != nil {
 		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
 		return
 	}
+	utils.DefinirETag(w, c.Versao)
 	json.NewEncoder(w).Encode(c)
 }
 
-// ListarPorConsultor retorna contratos de um consultor
+// ListarPorConsultor trata GET /consultores/{id}/contratos?tipo=&status=
+// Só o próprio consultor, o comercial do time dele ou um admin.
 func (h *Handler) ListarPorConsultor(w http.ResponseWriter, r *http.Request) {
-	consID, _ := strconv.Atoi(mux.Vars(r)["id"])
-	list, err := h.Repository.ListarPorConsultor(h.DB, uint(consID))
+	consID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	pode, err := podeVerConsultor(h.DB, auth.AtorDaRequisicao(r), uint(consID))
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if !pode {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	list, err := h.Repository.ListarPorConsultor(h.DB, uint(consID), FiltroDaQuery(r.URL.Query()))
 	if err != nil {
 		http.Error(w, "Erro ao listar contratos", http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(list)
 }
 
@@ -136,6 +190,10 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
 		return
 	}
+	if err := utils.ConferirIfMatch(r, existing.Versao); err != nil {
+		utils.ResponderErroVersao(w, err)
+		return
+	}
 
 	var dto contratoDTO
 	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
@@ -143,6 +201,19 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if existing.TermosOriginais != nil && mudaTermos(existing, Termos{
+		Valor: &dto.Valor, InicioSuprimento: &dto.InicioSuprimento, FimSuprimento: &dto.FimSuprimento,
+		ValorIntegral: &dto.ValorIntegral, Fee: &dto.Fee, FeePercent: &dto.FeePercent,
+		UniPay: &dto.UniPay, UniPayPercent: &dto.UniPayPercent, MonPay: &dto.MonPay, MonthPay: &dto.MonthPay,
+	}) {
+		http.Error(w, msgTermosComAditivo, http.StatusConflict)
+		return
+	}
+
+	// novo fim de suprimento: os avisos de vencimento recomeçam
+	if !dto.FimSuprimento.Equal(existing.FimSuprimento) {
+		existing.AlertaVencimentoDias = 0
+	}
 	existing.Valor = dto.Valor
 	existing.InicioSuprimento = dto.InicioSuprimento
 	existing.FimSuprimento = dto.FimSuprimento
@@ -155,11 +226,111 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 	existing.MonPay = dto.MonPay
 	existing.MonthPay = dto.MonthPay
 
-	if err := h.Repository.Atualizar(h.DB, &existing); err != nil {
-		http.Error(w, "Erro ao atualizar contrato", http.StatusInternalServerError)
+	if err := h.Repository.AtualizarComVersao(h.DB, &existing); err != nil {
+		if !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao atualizar contrato", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	utils.DefinirETag(w, existing.Versao)
+	json.NewEncoder(w).Encode(existing)
+}
+
+// contratoPatchDTO: só os campos presentes no JSON são alterados
+type contratoPatchDTO struct {
+	Tipo             *string    `json:"tipo"`
+	URL              *string    `json:"url"`
+	DataAssinatura   *time.Time `json:"dataAssinatura"`
+	Valor            *float64   `json:"valor"`
+	InicioSuprimento *time.Time `json:"inicioSuprimento"`
+	FimSuprimento    *time.Time `json:"fimSuprimento"`
+	ValorIntegral    *bool      `json:"valorIntegral"`
+	Status           *string    `json:"status"`
+
+	Fee        *bool    `json:"fee"`
+	FeePercent *float64 `json:"feePercent"`
+
+	UniPay        *bool    `json:"unipay"`
+	UniPayPercent *float64 `json:"unipayPercent"`
+
+	MonPay   *bool    `json:"monPay"`
+	MonthPay *float64 `json:"monthPay"`
+}
+
+// AtualizarParcial trata PATCH /contratos/{id} (exige If-Match)
+func (h *Handler) AtualizarParcial(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var existing Contrato
+	if err := h.DB.First(&existing, id).Error; err != nil {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	if err := utils.ConferirIfMatch(r, existing.Versao); err != nil {
+		utils.ResponderErroVersao(w, err)
+		return
+	}
+
+	var dto contratoPatchDTO
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&dto); err != nil {
+		http.Error(w, "JSON inválido ou campo não editável: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if existing.TermosOriginais != nil && mudaTermos(existing, Termos{
+		Valor: dto.Valor, InicioSuprimento: dto.InicioSuprimento, FimSuprimento: dto.FimSuprimento,
+		ValorIntegral: dto.ValorIntegral, Fee: dto.Fee, FeePercent: dto.FeePercent,
+		UniPay: dto.UniPay, UniPayPercent: dto.UniPayPercent, MonPay: dto.MonPay, MonthPay: dto.MonthPay,
+	}) {
+		http.Error(w, msgTermosComAditivo, http.StatusConflict)
+		return
+	}
+
+	campos := map[string]any{}
+	definir := func(coluna string, presente bool, aplicar func() any) {
+		if presente {
+			campos[coluna] = aplicar()
+		}
+	}
+	definir("tipo", dto.Tipo != nil, func() any { existing.Tipo = *dto.Tipo; return existing.Tipo })
+	definir("url", dto.URL != nil, func() any { existing.URL = *dto.URL; return existing.URL })
+	definir("data_assinatura", dto.DataAssinatura != nil, func() any { existing.DataAssinatura = *dto.DataAssinatura; return existing.DataAssinatura })
+	definir("valor", dto.Valor != nil, func() any { existing.Valor = *dto.Valor; return existing.Valor })
+	definir("inicio_suprimento", dto.InicioSuprimento != nil, func() any { existing.InicioSuprimento = *dto.InicioSuprimento; return existing.InicioSuprimento })
+	definir("fim_suprimento", dto.FimSuprimento != nil, func() any { existing.FimSuprimento = *dto.FimSuprimento; return existing.FimSuprimento })
+	definir("valor_integral", dto.ValorIntegral != nil, func() any { existing.ValorIntegral = *dto.ValorIntegral; return existing.ValorIntegral })
+	definir("status", dto.Status != nil, func() any { existing.Status = *dto.Status; return existing.Status })
+	definir("fee", dto.Fee != nil, func() any { existing.Fee = *dto.Fee; return existing.Fee })
+	definir("fee_percent", dto.FeePercent != nil, func() any { existing.FeePercent = *dto.FeePercent; return existing.FeePercent })
+	definir("uni_pay", dto.UniPay != nil, func() any { existing.UniPay = *dto.UniPay; return existing.UniPay })
+	definir("uni_pay_percent", dto.UniPayPercent != nil, func() any { existing.UniPayPercent = *dto.UniPayPercent; return existing.UniPayPercent })
+	definir("mon_pay", dto.MonPay != nil, func() any { existing.MonPay = *dto.MonPay; return existing.MonPay })
+	definir("month_pay", dto.MonthPay != nil, func() any { existing.MonthPay = *dto.MonthPay; return existing.MonthPay })
+	if len(campos) == 0 {
+		http.Error(w, "Nenhum campo para atualizar", http.StatusBadRequest)
+		return
+	}
+	if _, ok := campos["fim_suprimento"]; ok {
+		existing.AlertaVencimentoDias = 0
+		campos["alerta_vencimento_dias"] = 0
+	}
+
+	if err := h.Repository.AtualizarCampos(h.DB, &existing, campos); err != nil {
+		if !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao atualizar contrato", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.DefinirETag(w, existing.Versao)
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(existing)
 }
 
@@ -178,3 +349,55 @@ func (h *Handler) Deletar(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// podeVerConsultor: o próprio consultor, um admin ou o comercial do time do
+// consultor. Lê "consultors" direto (contrato não depende de consultor).
+func podeVerConsultor(db *gorm.DB, ator auth.Ator, consultorID uint) (bool, error) {
+	switch {
+	case ator.Admin:
+		return true, nil
+	case ator.Tipo == auth.AtorConsultor:
+		return ator.ID == consultorID, nil
+	case ator.Tipo == auth.AtorComercial:
+		var qtd int64
+		err := db.Table("consultors").
+			Where("id = ? AND comercial_id = ? AND deleted_at IS NULL", consultorID, ator.ID).
+			Count(&qtd).Error
+		return qtd > 0, err
+	}
+	return false, nil
+}
+
+// carregarContrato busca o contrato de {id} se a negociação dele estiver no
+// escopo do usuário; fora do escopo, o contrato "não existe".
+func (h *Handler) carregarContrato(w http.ResponseWriter, r *http.Request) (Contrato, bool) {
+	var c Contrato
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return c, false
+	}
+	err = h.DB.First(&c, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return c, false
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar contrato", http.StatusInternalServerError)
+		return c, false
+	}
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return c, false
+	}
+	ok, err := e.AlcancaNegociacao(h.DB, c.NegociacaoID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return c, false
+	}
+	if !ok {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+	}
+	return c, ok
+}
@@ -6,11 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// Status do ciclo de vida. Vigente e Encerrado são mantidos pelo monitor a
+// partir das datas de suprimento; Cancelado nunca é alterado por ele.
+const (
+	StatusVigente     = "Vigente"
+	StatusEncerrado   = "Encerrado"
+	StatusEmRenovacao = "Em Renovação" // sucessor criado pela renovação, aguardando assinatura
+	StatusCancelado   = "Cancelado"
+)
+
 // Contrato representa tanto contratos de gestão quanto de energia
 // e carrega toda a lógica de pagamento (fee, unipay, mensal).
 type Contrato struct {
 	gorm.Model
 
+	// Versão para controle de concorrência (exposta como ETag)
+	Versao uint `gorm:"not null;default:1" json:"versao"`
+
 	NegociacaoID uint `gorm:"not null;index" json:"negociacaoId"`
 	ConsultorID  uint `gorm:"not null;index" json:"consultorId"`
 
@@ -35,4 +47,29 @@ type Contrato struct {
 	// Pagamento mensal
 	MonPay   bool    `json:"monPay"`   // habilita pagamento mensal
 	MonthPay float64 `json:"monthPay"` // valor mensal fixo
+
+	// Renovação: contrato que este renova e o sucessor criado ao renová-lo
+	RenovaContratoID *uint `gorm:"index" json:"renovaContratoId,omitempty"`
+	RenovadoPorID    *uint `gorm:"index" json:"renovadoPorId,omitempty"`
+
+	// Condições antes do primeiro aditivo; os campos acima são sempre as
+	// condições em vigor (originais + aditivos já vigentes)
+	TermosOriginais *Termos `gorm:"type:jsonb;serializer:json" json:"termosOriginais,omitempty"`
+
+	// Menor antecedência (em dias) do aviso de vencimento já enviado; 0 = nenhum
+	AlertaVencimentoDias int `gorm:"not null;default:0" json:"-"`
+}
+
+// Migrate corrige o vínculo contrato → consultor. Versões antigas ligavam
+// Consultor.Contratos por negociacao_id e podem ter deixado uma FK de
+// contratos.negociacao_id para consultors; além disso, consultor_id passa a
+// ser o dono atual da negociação. Roda depois do AutoMigrate.
+func Migrate(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE contratos DROP CONSTRAINT IF EXISTS fk_consultors_contratos`).Error; err != nil {
+		return err
+	}
+	return db.Exec(`
+		UPDATE contratos ct SET consultor_id = n.consultor_id
+		FROM negociacaos n
+		WHERE n.id = ct.negociacao_id AND ct.consultor_id <> n.consultor_id`).Error
 }
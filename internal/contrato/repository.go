@@ -1,12 +1,43 @@
 package contrato
 
-import "gorm.io/gorm"
+import (
+	"net/url"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Filtro restringe as listagens de contratos; campos vazios não filtram.
+type Filtro struct {
+	Tipo   string // "Gestão" | "Energia" (sem diferenciar maiúsculas)
+	Status string
+}
+
+// FiltroDaQuery lê ?tipo=&status=
+func FiltroDaQuery(q url.Values) Filtro {
+	return Filtro{Tipo: strings.TrimSpace(q.Get("tipo")), Status: strings.TrimSpace(q.Get("status"))}
+}
+
+// aplicar restringe uma query sobre contratos (sem alias) ao filtro.
+func (f Filtro) aplicar(q *gorm.DB) *gorm.DB {
+	if f.Tipo != "" {
+		q = q.Where("LOWER(contratos.tipo) = LOWER(?)", f.Tipo)
+	}
+	if f.Status != "" {
+		q = q.Where("LOWER(contratos.status) = LOWER(?)", f.Status)
+	}
+	return q
+}
 
 type Repository interface {
 	Salvar(db *gorm.DB, c *Contrato) error
-	BuscarPorNegociacao(db *gorm.DB, negID uint) (*Contrato, error)
-	ListarPorConsultor(db *gorm.DB, consultorID uint) ([]Contrato, error)
+	BuscarPorNegociacao(db *gorm.DB, negID uint, f Filtro) (*Contrato, error)
+	ListarPorNegociacao(db *gorm.DB, negID uint, f Filtro) ([]Contrato, error)
+	ListarPorConsultor(db *gorm.DB, consultorID uint, f Filtro) ([]Contrato, error)
 	Atualizar(db *gorm.DB, c *Contrato) error
+	AtualizarComVersao(db *gorm.DB, c *Contrato) error
+	AtualizarCampos(db *gorm.DB, c *Contrato, campos map[string]any) error
 	Deletar(db *gorm.DB, id uint) error
 }
 
@@ -18,24 +49,68 @@ func (r *repositoryImpl) Salvar(db *gorm.DB, c *Contrato) error {
 	return db.Create(c).Error
 }
 
-func (r *repositoryImpl) BuscarPorNegociacao(db *gorm.DB, negID uint) (*Contrato, error) {
+// BuscarPorNegociacao devolve o primeiro contrato (o mais antigo) da
+// negociação que atende ao filtro.
+func (r *repositoryImpl) BuscarPorNegociacao(db *gorm.DB, negID uint, f Filtro) (*Contrato, error) {
 	var c Contrato
-	err := db.Where("negociacao_id = ?", negID).First(&c).Error
+	err := f.aplicar(db.Where("negociacao_id = ?", negID)).Order("id ASC").First(&c).Error
 	return &c, err
 }
 
-func (r *repositoryImpl) ListarPorConsultor(db *gorm.DB, consultorID uint) ([]Contrato, error) {
-	var list []Contrato
-	err := db.Joins("JOIN negociacaos n ON n.id = contratos.negociacao_id").
-		Where("n.consultor_id = ?", consultorID).
+// ListarPorNegociacao devolve todos os contratos da negociação (gestão e energia).
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negID uint, f Filtro) ([]Contrato, error) {
+	list := []Contrato{}
+	err := f.aplicar(db.Where("negociacao_id = ?", negID)).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// ListarPorConsultor devolve os contratos das negociações que hoje são do
+// consultor, inclusive as recebidas por transferência.
+func (r *repositoryImpl) ListarPorConsultor(db *gorm.DB, consultorID uint, f Filtro) ([]Contrato, error) {
+	list := []Contrato{}
+	err := f.aplicar(db.Joins("JOIN negociacaos n ON n.id = contratos.negociacao_id AND n.deleted_at IS NULL").
+		Where("n.consultor_id = ?", consultorID)).
+		Order("contratos.fim_suprimento DESC, contratos.id DESC").
 		Find(&list).Error
 	return list, err
 }
 
 func (r *repositoryImpl) Atualizar(db *gorm.DB, c *Contrato) error {
+	c.Versao++
 	return db.Save(c).Error
 }
 
+// AtualizarComVersao grava todos os campos só se a versão no banco ainda for
+// c.Versao; caso contrário devolve utils.ErrVersaoConflito.
+func (r *repositoryImpl) AtualizarComVersao(db *gorm.DB, c *Contrato) error {
+	lida := c.Versao
+	c.Versao = lida + 1
+	res := db.Model(c).Where("versao = ?", lida).Select("*").Updates(c)
+	if res.Error != nil {
+		c.Versao = lida
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		c.Versao = lida
+		return utils.ErrVersaoConflito
+	}
+	return nil
+}
+
+// AtualizarCampos grava só as colunas informadas, com a mesma checagem de versão.
+func (r *repositoryImpl) AtualizarCampos(db *gorm.DB, c *Contrato, campos map[string]any) error {
+	campos["versao"] = c.Versao + 1
+	res := db.Model(&Contrato{}).Where("id = ? AND versao = ?", c.ID, c.Versao).Updates(campos)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return utils.ErrVersaoConflito
+	}
+	c.Versao++
+	return nil
+}
+
 func (r *repositoryImpl) Deletar(db *gorm.DB, id uint) error {
 	return db.Delete(&Contrato{}, id).Error
 }
@@ -0,0 +1,112 @@
+// internal/documento/catalogo.go
+package documento
+
+import (
+	"fmt"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tiposPadrao são os documentos que antes eram colunas fixas da negociação,
+// mais a proposta comercial gerada em PDF.
+// Só são inseridos se o código ainda não existir, então edições feitas pelos
+// admins no catálogo são preservadas.
+var tiposPadrao = []models.TipoDocumento{
+	{Codigo: models.DocLogo, Nome: "Logo", Ordem: 10, Ativo: true},
+	{Codigo: models.DocProposta, Nome: "Proposta comercial", Ordem: 15, Ativo: true},
+	{Codigo: models.DocEstudo, Nome: "Estudo", Obrigatorio: true, Etapa: models.StatusEstudoFeito, Ordem: 20, Ativo: true},
+	{Codigo: models.DocEstudoViabilidade, Nome: "Estudo de viabilidade", Ordem: 30, Ativo: true},
+	{Codigo: models.DocContratoSocial, Nome: "Contrato social", Obrigatorio: true, Etapa: models.StatusContratoEnviado, Ordem: 40, Ativo: true, Escopo: models.EscopoCliente},
+	{Codigo: models.DocRepresentanteLegal, Nome: "Documento do representante legal", Obrigatorio: true, Etapa: models.StatusContratoEnviado, Ordem: 50, Ativo: true, Escopo: models.EscopoCliente},
+	{Codigo: models.DocProcuracao, Nome: "Procuração", Ordem: 60, Ativo: true, Escopo: models.EscopoCliente},
+	{Codigo: models.DocContratoKC, Nome: "Contrato KC", Obrigatorio: true, Etapa: models.StatusContratoAssinado, Ordem: 70, Ativo: true},
+}
+
+// colunaLegada mapeia as antigas colunas de Negociacao para o tipo de documento.
+type colunaLegada struct {
+	codigo, url, status, thumb string
+}
+
+var colunasLegadas = []colunaLegada{
+	{models.DocLogo, "logo", "logo_status", "logo_thumb"},
+	{models.DocEstudo, "anexo_estudo", "anexo_estudo_status", ""},
+	{models.DocContratoKC, "contrato_kc", "contrato_kc_status", ""},
+	{models.DocContratoSocial, "anexo_contrato_social", "anexo_contrato_social_status", ""},
+	{models.DocProcuracao, "anexo_procuracao", "anexo_procuracao_status", ""},
+	{models.DocRepresentanteLegal, "anexo_representante_legal", "anexo_representante_legal_status", ""},
+	{models.DocEstudoViabilidade, "anexo_estudo_de_viabilidade", "anexo_estudo_de_viabilidade_status", ""},
+}
+
+// Migrate popula o catálogo padrão e move as colunas antigas de anexo da
+// negociação para negociacao_documentos. Roda depois do AutoMigrate.
+func Migrate(db *gorm.DB) error {
+	tipos := append([]models.TipoDocumento(nil), tiposPadrao...)
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "codigo"}},
+		DoNothing: true,
+	}).Create(&tipos).Error; err != nil {
+		return err
+	}
+	if err := classificarEscopo(db); err != nil {
+		return err
+	}
+	return migrarColunasLegadas(db)
+}
+
+// classificarEscopo preenche o escopo dos tipos criados antes da coluna
+// existir: documentos da empresa ficam no cliente, o resto na negociação.
+func classificarEscopo(db *gorm.DB) error {
+	return db.Exec(`UPDATE tipo_documentos
+		SET escopo = CASE WHEN codigo IN ? THEN ? ELSE ? END
+		WHERE COALESCE(escopo, '') = ''`,
+		[]string{models.DocContratoSocial, models.DocProcuracao, models.DocRepresentanteLegal},
+		models.EscopoCliente, models.EscopoNegociacao).Error
+}
+
+// migrarColunasLegadas copia cada par url/status para um documento e remove
+// as colunas. Colunas já removidas são puladas, então é seguro rodar sempre.
+func migrarColunasLegadas(db *gorm.DB) error {
+	tabela := "negociacaos"
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		m := tx.Migrator()
+		for _, c := range colunasLegadas {
+			if !m.HasColumn(tabela, c.url) {
+				continue
+			}
+
+			status := "'" + models.StatusEnviado + "'"
+			if m.HasColumn(tabela, c.status) {
+				status = fmt.Sprintf("COALESCE(NULLIF(%s, ''), '%s')", c.status, models.StatusEnviado)
+			}
+			thumb := "''"
+			if c.thumb != "" && m.HasColumn(tabela, c.thumb) {
+				thumb = fmt.Sprintf("COALESCE(%s, '')", c.thumb)
+			}
+
+			sql := fmt.Sprintf(`
+				INSERT INTO negociacao_documentos
+					(negociacao_id, tipo_codigo, url, thumbnail_url, status, enviado_por_tipo, created_at, updated_at)
+				SELECT id, ?, %s, %s, %s, ?, updated_at, updated_at
+				FROM %s
+				WHERE %s IS NOT NULL AND %s <> ''
+				ON CONFLICT (negociacao_id, tipo_codigo) DO NOTHING`,
+				c.url, thumb, status, tabela, c.url, c.url)
+			if err := tx.Exec(sql, c.codigo, auth.AtorSistema).Error; err != nil {
+				return fmt.Errorf("migrando %s: %w", c.url, err)
+			}
+
+			for _, col := range []string{c.url, c.status, c.thumb} {
+				if col != "" && m.HasColumn(tabela, col) {
+					if err := m.DropColumn(tabela, col); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+// internal/documento/cliente.go
+package documento
+
+import (
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+)
+
+// DefinirDoCliente grava (ou substitui) o documento de um tipo no cliente.
+// O envio aparece na linha do tempo de todas as negociações ativas dele.
+func DefinirDoCliente(db *gorm.DB, clienteID uint, codigo, url, thumbnail, status string, ator auth.Ator) (*models.ClienteDocumento, error) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil, ErrURLObrigatoria
+	}
+	tipo, err := buscarTipo(db, codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	e := novoEnvio(codigo, url, thumbnail, status, ator)
+	d := models.ClienteDocumento{
+		ClienteID:      clienteID,
+		TipoCodigo:     codigo,
+		URL:            e.URL,
+		ThumbnailURL:   e.ThumbnailURL,
+		Status:         e.Status,
+		EnviadoPorTipo: e.EnviadoPorTipo,
+		EnviadoPorID:   e.EnviadoPorID,
+		RevisadoPorID:  e.RevisadoPorID,
+		RevisadoEm:     e.RevisadoEm,
+	}
+
+	var salvo *models.ClienteDocumento
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := repo.UpsertDoCliente(tx, &d); err != nil {
+			return err
+		}
+		if salvo, err = repo.BuscarDoCliente(tx, clienteID, codigo); err != nil {
+			return err
+		}
+		negs, err := NegociacoesDoCliente(tx, clienteID)
+		if err != nil {
+			return err
+		}
+		return registrarEnvio(tx, negs, tipo, "cliente_documento", salvo.ID, salvo.Status, d.URL, ator)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return salvo, nil
+}
+
+// RevisarDoCliente valida ou rejeita um documento do cliente.
+func RevisarDoCliente(db *gorm.DB, clienteID uint, codigo, status, motivo string, ator auth.Ator) (*models.ClienteDocumento, error) {
+	if !ator.EhComercial() {
+		return nil, ErrRevisaoSemPermissao
+	}
+	status, motivo, err := NormalizarRevisao(status, motivo)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := repo.BuscarDoCliente(db, clienteID, codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	id := ator.ID
+	if err := db.Model(d).Updates(camposRevisao(status, motivo, &id, &agora)).Error; err != nil {
+		return nil, err
+	}
+	d.Status = status
+	d.MotivoRejeicao = motivo
+	d.RevisadoPorID = &id
+	d.RevisadoEm = &agora
+
+	negs, err := NegociacoesDoCliente(db, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	if err := registrarRevisao(db, negs, codigo, "cliente_documento", d.ID, status, motivo, ator); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListarDoCliente devolve os documentos do cliente.
+func ListarDoCliente(db *gorm.DB, clienteID uint) ([]models.ClienteDocumento, error) {
+	return repo.ListarPorCliente(db, clienteID)
+}
+
+// RemoverDoCliente apaga o documento do tipo no cliente.
+func RemoverDoCliente(db *gorm.DB, clienteID uint, codigo string) error {
+	return repo.RemoverDoCliente(db, clienteID, codigo)
+}
+
+// NegociacoesDoCliente devolve os IDs das negociações não canceladas do cliente.
+func NegociacoesDoCliente(db *gorm.DB, clienteID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.Negociacao{}).
+		Where("cliente_id = ? AND status <> ?", clienteID, models.StatusCancelada).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+	return ids, err
+}
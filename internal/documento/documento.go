@@ -0,0 +1,499 @@
+// internal/documento/documento.go
+package documento
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/produtos"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTipoDesconhecido    = errors.New("tipo de documento desconhecido")
+	ErrURLObrigatoria      = errors.New("o campo 'url' é obrigatório")
+	ErrRevisaoSemPermissao = errors.New("apenas o comercial pode validar ou rejeitar documentos")
+	ErrDecisaoInvalida     = errors.New("o campo 'status' deve ser 'Validado' ou 'Rejeitado'")
+	ErrMotivoObrigatorio   = errors.New("o campo 'motivo' é obrigatório para rejeitar")
+	ErrRemocaoSemPermissao = errors.New("apenas o comercial pode remover documentos do cadastro do cliente")
+)
+
+var repo = NewRepository()
+
+// ItemChecklist é uma linha do checklist de documentos da negociação.
+type ItemChecklist struct {
+	Tipo        models.TipoDocumento        `json:"tipo"`
+	Obrigatorio bool                        `json:"obrigatorio"` // entra no cálculo de completude
+	Exigido     bool                        `json:"exigido"`     // obrigatório já na etapa atual
+	Status      string                      `json:"status"`      // status do documento, ou "Pendente" se não enviado
+	Documento   *models.NegociacaoDocumento `json:"documento"`
+}
+
+// Definir grava (ou substitui) o documento de um tipo na negociação.
+// Todo envio volta para "Enviado" e aguarda revisão; só o comercial (ou o
+// sistema, com documentos assinados eletronicamente) pode já enviar como
+// "Validado". Tipos de escopo "cliente" vão para o cadastro
+// do cliente da negociação, quando ela tiver um.
+func Definir(db *gorm.DB, negociacaoID uint, codigo, url, thumbnail, status string, ator auth.Ator) (*models.NegociacaoDocumento, error) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil, ErrURLObrigatoria
+	}
+	tipo, err := buscarTipo(db, codigo)
+	if err != nil {
+		return nil, err
+	}
+	clienteID, err := clienteDoTipo(db, negociacaoID, tipo)
+	if err != nil {
+		return nil, err
+	}
+	if clienteID != 0 {
+		cd, err := DefinirDoCliente(db, clienteID, codigo, url, thumbnail, status, ator)
+		if err != nil {
+			return nil, err
+		}
+		d := cd.ComoDocumentoDaNegociacao(negociacaoID)
+		return &d, nil
+	}
+
+	d := novoEnvio(codigo, url, thumbnail, status, ator)
+	d.NegociacaoID = negociacaoID
+	var salvo *models.NegociacaoDocumento
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := repo.Upsert(tx, &d); err != nil {
+			return err
+		}
+		if salvo, err = repo.Buscar(tx, negociacaoID, codigo); err != nil {
+			return err
+		}
+		return registrarEnvio(tx, []uint{negociacaoID}, tipo, "documento", salvo.ID, salvo.Status, url, ator)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return salvo, nil
+}
+
+// novoEnvio monta o documento recém-enviado (sem dono definido).
+func novoEnvio(codigo, url, thumbnail, status string, ator auth.Ator) models.NegociacaoDocumento {
+	d := models.NegociacaoDocumento{
+		TipoCodigo:     codigo,
+		URL:            url,
+		ThumbnailURL:   thumbnail,
+		Status:         models.StatusEnviado,
+		EnviadoPorTipo: ator.Tipo,
+	}
+	if ator.ID != 0 {
+		id := ator.ID
+		d.EnviadoPorID = &id
+	}
+	if (ator.EhComercial() || ator.Tipo == auth.AtorSistema) && strings.EqualFold(strings.TrimSpace(status), models.StatusValidado) {
+		agora := time.Now()
+		d.Status = models.StatusValidado
+		d.RevisadoPorID = d.EnviadoPorID
+		d.RevisadoEm = &agora
+	}
+	return d
+}
+
+// registrarEnvio grava o evento de envio na linha do tempo de cada negociação.
+func registrarEnvio(db *gorm.DB, negociacoes []uint, tipo *models.TipoDocumento, refTipo string, refID uint, status, url string, ator auth.Ator) error {
+	tipoEvento, verbo := evento.DocumentoEnviado, "enviado"
+	if status == models.StatusValidado {
+		tipoEvento, verbo = evento.DocumentoValidado, "enviado e validado"
+	}
+	for _, negID := range negociacoes {
+		if err := evento.Registrar(db, evento.Evento{
+			NegociacaoID:   negID,
+			Tipo:           tipoEvento,
+			Descricao:      fmt.Sprintf("Documento %s: %s", verbo, tipo.Nome),
+			ReferenciaTipo: refTipo,
+			ReferenciaID:   refID,
+			Dados:          map[string]any{"tipo": tipo.Codigo, "url": url},
+		}.Por(ator)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Revisar valida ou rejeita um documento enviado. Só o comercial revisa e a
+// rejeição exige motivo.
+func Revisar(db *gorm.DB, negociacaoID uint, codigo, status, motivo string, ator auth.Ator) (*models.NegociacaoDocumento, error) {
+	if !ator.EhComercial() {
+		return nil, ErrRevisaoSemPermissao
+	}
+	status, motivo, err := NormalizarRevisao(status, motivo)
+	if err != nil {
+		return nil, err
+	}
+
+	if tipo, err := repo.BuscarTipo(db, codigo); err == nil {
+		clienteID, err := clienteDoTipo(db, negociacaoID, tipo)
+		if err != nil {
+			return nil, err
+		}
+		if clienteID != 0 {
+			cd, err := RevisarDoCliente(db, clienteID, codigo, status, motivo, ator)
+			if err != nil {
+				return nil, err
+			}
+			d := cd.ComoDocumentoDaNegociacao(negociacaoID)
+			return &d, nil
+		}
+	}
+
+	d, err := repo.Buscar(db, negociacaoID, codigo)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	id := ator.ID
+	if err := db.Model(d).Updates(camposRevisao(status, motivo, &id, &agora)).Error; err != nil {
+		return nil, err
+	}
+	d.Status = status
+	d.MotivoRejeicao = motivo
+	d.RevisadoPorID = &id
+	d.RevisadoEm = &agora
+
+	if err := registrarRevisao(db, []uint{negociacaoID}, codigo, "documento", d.ID, status, motivo, ator); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func camposRevisao(status, motivo string, por *uint, em *time.Time) map[string]any {
+	return map[string]any{
+		"status":          status,
+		"motivo_rejeicao": motivo,
+		"revisado_por_id": por,
+		"revisado_em":     em,
+	}
+}
+
+// registrarRevisao grava o evento de validação/rejeição em cada negociação.
+func registrarRevisao(db *gorm.DB, negociacoes []uint, codigo, refTipo string, refID uint, status, motivo string, ator auth.Ator) error {
+	nome := nomeDoTipo(db, codigo)
+	for _, negID := range negociacoes {
+		e := evento.Evento{
+			NegociacaoID:   negID,
+			Tipo:           evento.DocumentoValidado,
+			Descricao:      "Documento validado: " + nome,
+			ReferenciaTipo: refTipo,
+			ReferenciaID:   refID,
+			Dados:          map[string]any{"tipo": codigo},
+		}
+		if status == models.StatusRejeitado {
+			e.Tipo = evento.DocumentoRejeitado
+			e.Descricao = "Documento rejeitado: " + nome
+			e.Dados["motivo"] = motivo
+		}
+		if err := evento.Registrar(db, e.Por(ator)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func buscarTipo(db *gorm.DB, codigo string) (*models.TipoDocumento, error) {
+	tipo, err := repo.BuscarTipo(db, codigo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTipoDesconhecido
+	}
+	return tipo, err
+}
+
+// clienteDoTipo devolve o cliente onde o documento do tipo deve ficar, ou 0
+// quando o tipo é da negociação (ou a negociação ainda não tem cliente).
+func clienteDoTipo(db *gorm.DB, negociacaoID uint, tipo *models.TipoDocumento) (uint, error) {
+	if !tipo.DoCliente() {
+		return 0, nil
+	}
+	var n models.Negociacao
+	if err := db.Select("id", "cliente_id").First(&n, negociacaoID).Error; err != nil {
+		return 0, err
+	}
+	if n.ClienteID == nil {
+		return 0, nil
+	}
+	return *n.ClienteID, nil
+}
+
+// nomeDoTipo devolve o nome do tipo no catálogo, ou o próprio código.
+func nomeDoTipo(db *gorm.DB, codigo string) string {
+	if t, err := repo.BuscarTipo(db, codigo); err == nil {
+		return t.Nome
+	}
+	return codigo
+}
+
+// NormalizarRevisao confere a decisão de revisão ("Validado" | "Rejeitado")
+// e devolve o motivo que deve ser gravado (vazio ao validar).
+func NormalizarRevisao(status, motivo string) (string, string, error) {
+	status = strings.TrimSpace(status)
+	motivo = strings.TrimSpace(motivo)
+	switch {
+	case strings.EqualFold(status, models.StatusValidado):
+		return models.StatusValidado, "", nil
+	case strings.EqualFold(status, models.StatusRejeitado):
+		if motivo == "" {
+			return "", "", ErrMotivoObrigatorio
+		}
+		return models.StatusRejeitado, motivo, nil
+	}
+	return "", "", ErrDecisaoInvalida
+}
+
+// Buscar devolve o documento do tipo, ou nil se ainda não foi enviado.
+func Buscar(db *gorm.DB, negociacaoID uint, codigo string) (*models.NegociacaoDocumento, error) {
+	if tipo, err := repo.BuscarTipo(db, codigo); err == nil {
+		clienteID, err := clienteDoTipo(db, negociacaoID, tipo)
+		if err != nil {
+			return nil, err
+		}
+		if clienteID != 0 {
+			cd, err := repo.BuscarDoCliente(db, clienteID, codigo)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			d := cd.ComoDocumentoDaNegociacao(negociacaoID)
+			return &d, nil
+		}
+	}
+	d, err := repo.Buscar(db, negociacaoID, codigo)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// Enviado diz se algum dos tipos informados tem arquivo na negociação ou no
+// cliente dela.
+func Enviado(db *gorm.DB, negociacaoID uint, codigos ...string) (bool, error) {
+	var ok bool
+	err := db.Raw(`SELECT EXISTS (
+			SELECT 1 FROM negociacao_documentos
+			WHERE negociacao_id = ? AND tipo_codigo IN ? AND url <> ''
+		) OR EXISTS (
+			SELECT 1 FROM cliente_documentos cd
+			JOIN negociacaos n ON n.cliente_id = cd.cliente_id
+			WHERE n.id = ? AND cd.tipo_codigo IN ? AND cd.url <> ''
+		)`, negociacaoID, codigos, negociacaoID, codigos).Scan(&ok).Error
+	return ok, err
+}
+
+// Remover apaga o documento do tipo (do cliente, se o tipo for de escopo
+// cliente). O documento do cliente vale para as negociações de todos os
+// consultores, então só o comercial o remove.
+func Remover(db *gorm.DB, negociacaoID uint, codigo string, ator auth.Ator) error {
+	if tipo, err := repo.BuscarTipo(db, codigo); err == nil {
+		clienteID, err := clienteDoTipo(db, negociacaoID, tipo)
+		if err != nil {
+			return err
+		}
+		if clienteID != 0 {
+			if !ator.EhComercial() {
+				return ErrRemocaoSemPermissao
+			}
+			return repo.RemoverDoCliente(db, clienteID, codigo)
+		}
+	}
+	return repo.Remover(db, negociacaoID, codigo)
+}
+
+// Checklist monta a lista de documentos aplicáveis à negociação: tipos ativos
+// do catálogo que valem para algum produto dela, mais qualquer documento já
+// enviado de tipo fora dessa lista.
+func Checklist(db *gorm.DB, neg *models.Negociacao) ([]ItemChecklist, error) {
+	tipos, err := repo.ListarTipos(db, false)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := repo.ListarPorNegociacao(db, neg.ID)
+	if err != nil {
+		return nil, err
+	}
+	var doCliente []models.ClienteDocumento
+	if neg.ClienteID != nil {
+		if doCliente, err = repo.ListarPorCliente(db, *neg.ClienteID); err != nil {
+			return nil, err
+		}
+	}
+	return montarChecklist(tipos, docs, doCliente, neg), nil
+}
+
+// CompletudeEmLote calcula a completude de várias negociações com poucas
+// consultas, em vez de um checklist por negociação. As negociações devem vir
+// com os Produtos (e o ClienteID) carregados.
+func CompletudeEmLote(db *gorm.DB, negs []models.Negociacao) (map[uint]int, error) {
+	out := make(map[uint]int, len(negs))
+	if len(negs) == 0 {
+		return out, nil
+	}
+	tipos, err := repo.ListarTipos(db, false)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(negs))
+	var clientes []uint
+	for i := range negs {
+		ids[i] = negs[i].ID
+		if negs[i].ClienteID != nil {
+			clientes = append(clientes, *negs[i].ClienteID)
+		}
+	}
+	var docs []models.NegociacaoDocumento
+	if err := db.Where("negociacao_id IN ?", ids).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	docsCliente, err := repo.ListarPorCliente(db, clientes...)
+	if err != nil {
+		return nil, err
+	}
+	porNeg := make(map[uint][]models.NegociacaoDocumento, len(negs))
+	for _, d := range docs {
+		porNeg[d.NegociacaoID] = append(porNeg[d.NegociacaoID], d)
+	}
+	porCliente := make(map[uint][]models.ClienteDocumento)
+	for _, d := range docsCliente {
+		porCliente[d.ClienteID] = append(porCliente[d.ClienteID], d)
+	}
+	for i := range negs {
+		var doCliente []models.ClienteDocumento
+		if negs[i].ClienteID != nil {
+			doCliente = porCliente[*negs[i].ClienteID]
+		}
+		out[negs[i].ID] = Completude(montarChecklist(tipos, porNeg[negs[i].ID], doCliente, &negs[i]))
+	}
+	return out, nil
+}
+
+// montarChecklist cruza o catálogo com os documentos. Para tipos de escopo
+// "cliente", o documento do cliente tem precedência sobre um eventual
+// documento antigo gravado na negociação.
+func montarChecklist(tipos []models.TipoDocumento, docs []models.NegociacaoDocumento, doCliente []models.ClienteDocumento, neg *models.Negociacao) []ItemChecklist {
+	porTipo := make(map[string]*models.NegociacaoDocumento, len(docs)+len(doCliente))
+	for i := range docs {
+		porTipo[docs[i].TipoCodigo] = &docs[i]
+	}
+	escopoCliente := make(map[string]bool, len(tipos))
+	for _, t := range tipos {
+		escopoCliente[t.Codigo] = t.DoCliente()
+	}
+	for _, cd := range doCliente {
+		if escopoCliente[cd.TipoCodigo] {
+			d := cd.ComoDocumentoDaNegociacao(neg.ID)
+			porTipo[cd.TipoCodigo] = &d
+		}
+	}
+
+	itens := []ItemChecklist{}
+	for _, t := range tipos {
+		doc := porTipo[t.Codigo]
+		if doc == nil && (!t.Ativo || !aplicavel(t, neg.Produtos)) {
+			continue
+		}
+		obrigatorio := t.Ativo && t.Obrigatorio && aplicavel(t, neg.Produtos)
+		item := ItemChecklist{
+			Tipo:        t,
+			Obrigatorio: obrigatorio,
+			Exigido:     obrigatorio && etapaAlcancada(neg.Status, t.Etapa),
+			Status:      models.StatusPendente,
+			Documento:   doc,
+		}
+		if doc != nil {
+			item.Status = doc.Status
+		}
+		itens = append(itens, item)
+	}
+	return itens
+}
+
+// Completude é o percentual (0–100) de documentos obrigatórios já validados.
+// Sem documentos obrigatórios, a negociação está completa.
+func Completude(itens []ItemChecklist) int {
+	total, validados := 0, 0
+	for _, it := range itens {
+		if !it.Obrigatorio {
+			continue
+		}
+		total++
+		if it.Status == models.StatusValidado {
+			validados++
+		}
+	}
+	if total == 0 {
+		return 100
+	}
+	return validados * 100 / total
+}
+
+func aplicavel(t models.TipoDocumento, lista []produtos.Produto) bool {
+	if strings.TrimSpace(t.TipoProduto) == "" {
+		return true
+	}
+	for _, p := range lista {
+		if strings.EqualFold(strings.TrimSpace(p.Tipo), strings.TrimSpace(t.TipoProduto)) {
+			return true
+		}
+	}
+	return false
+}
+
+// etapaAlcancada diz se `atual` já chegou (ou passou) de `etapa` no pipeline.
+func etapaAlcancada(atual, etapa string) bool {
+	if etapa == "" {
+		return true
+	}
+	ia, ie := -1, -1
+	for i, e := range models.EtapasPipeline {
+		if e == atual {
+			ia = i
+		}
+		if e == etapa {
+			ie = i
+		}
+	}
+	return ia >= 0 && ie >= 0 && ia >= ie
+}
+
+// RegistrarRejeicao publica a rejeição de um documento: comentário de sistema
+// na negociação e notificação para o consultor dono.
+func RegistrarRejeicao(db *gorm.DB, notif notificacao.Notificador, neg *models.Negociacao, nomeDocumento, motivo string) error {
+	com := models.Comentario{
+		NegociacaoID: neg.ID,
+		Texto:        fmt.Sprintf("Documento \"%s\" rejeitado: %s", nomeDocumento, motivo),
+		IsSystem:     true,
+	}
+	if err := db.Create(&com).Error; err != nil {
+		return err
+	}
+
+	if notif == nil || neg.ConsultorID == 0 {
+		return nil
+	}
+	if err := notif.Notificar(notificacao.Notificacao{
+		Tipo:             "documento_rejeitado",
+		DestinatarioTipo: notificacao.DestinoConsultor,
+		DestinatarioID:   neg.ConsultorID,
+		Titulo:           fmt.Sprintf("Documento rejeitado: %s", nomeDocumento),
+		Mensagem:         motivo,
+		NegociacaoID:     neg.ID,
+	}); err != nil {
+		// falha de notificação não desfaz a revisão
+		log.Printf("Erro ao notificar rejeição de documento: %v", err)
+	}
+	return nil
+}
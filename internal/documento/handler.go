@@ -0,0 +1,340 @@
+// internal/documento/handler.go
+package documento
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler encapsula DB, repository e o notificador das revisões
+type Handler struct {
+	DB          *gorm.DB
+	Repository  Repository
+	Notificador notificacao.Notificador
+}
+
+// NewHandler cria um novo handler de documentos
+func NewHandler(db *gorm.DB, notif notificacao.Notificador) *Handler {
+	return &Handler{
+		DB:          db,
+		Repository:  NewRepository(),
+		Notificador: notif,
+	}
+}
+
+var codigoValido = regexp.MustCompile(`^[a-z0-9_]{2,50}$`)
+
+type tipoDocumentoRequest struct {
+	Codigo      string `json:"codigo"`
+	Nome        string `json:"nome"`
+	Descricao   string `json:"descricao"`
+	Obrigatorio bool   `json:"obrigatorio"`
+	TipoProduto string `json:"tipoProduto"`
+	Etapa       string `json:"etapa"`
+	Ordem       int    `json:"ordem"`
+	Ativo       *bool  `json:"ativo"`  // default true
+	Escopo      string `json:"escopo"` // "negociacao" (padrão) | "cliente"
+}
+
+type definirDocumentoRequest struct {
+	URL    string `json:"url"`
+	Status string `json:"status"` // opcional; só o comercial pode enviar já "Validado"
+}
+
+type revisarDocumentoRequest struct {
+	Status string `json:"status"` // "Validado" | "Rejeitado"
+	Motivo string `json:"motivo"` // obrigatório ao rejeitar
+}
+
+/* ================== Catálogo ================== */
+
+// ListarTipos trata GET /tipos-documento (admin pode pedir ?todos=1 para ver inativos)
+func (h *Handler) ListarTipos(w http.ResponseWriter, r *http.Request) {
+	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
+	apenasAtivos := !(isAdmin && r.URL.Query().Get("todos") == "1")
+
+	list, err := h.Repository.ListarTipos(h.DB, apenasAtivos)
+	if err != nil {
+		http.Error(w, "Erro ao listar tipos de documento", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// CriarTipo trata POST /tipos-documento (somente admin)
+func (h *Handler) CriarTipo(w http.ResponseWriter, r *http.Request) {
+	if admin, _ := r.Context().Value(auth.CtxIsAdmin).(bool); !admin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	var req tipoDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Codigo = strings.ToLower(strings.TrimSpace(req.Codigo))
+	if !codigoValido.MatchString(req.Codigo) {
+		http.Error(w, "o campo 'codigo' deve ter letras minúsculas, números ou '_'", http.StatusBadRequest)
+		return
+	}
+
+	var t models.TipoDocumento
+	if !h.aplicarTipo(w, &t, req) {
+		return
+	}
+	t.Codigo = req.Codigo
+
+	if _, err := h.Repository.BuscarTipo(h.DB, t.Codigo); err == nil {
+		http.Error(w, "Já existe um tipo com esse código", http.StatusConflict)
+		return
+	}
+	if err := h.Repository.SalvarTipo(h.DB, &t); err != nil {
+		http.Error(w, "Erro ao salvar tipo de documento", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// AtualizarTipo trata PUT /tipos-documento/{id} (somente admin; o código não muda)
+func (h *Handler) AtualizarTipo(w http.ResponseWriter, r *http.Request) {
+	if admin, _ := r.Context().Value(auth.CtxIsAdmin).(bool); !admin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var t models.TipoDocumento
+	if err := h.DB.First(&t, id).Error; err != nil {
+		http.Error(w, "Tipo de documento não encontrado", http.StatusNotFound)
+		return
+	}
+
+	var req tipoDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if !h.aplicarTipo(w, &t, req) {
+		return
+	}
+	if err := h.Repository.SalvarTipo(h.DB, &t); err != nil {
+		http.Error(w, "Erro ao salvar tipo de documento", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// aplicarTipo valida o payload e copia para o tipo; em caso de erro já responde.
+func (h *Handler) aplicarTipo(w http.ResponseWriter, t *models.TipoDocumento, req tipoDocumentoRequest) bool {
+	if strings.TrimSpace(req.Nome) == "" {
+		http.Error(w, "O campo 'nome' é obrigatório", http.StatusBadRequest)
+		return false
+	}
+	etapa := strings.TrimSpace(req.Etapa)
+	if etapa != "" && !etapaConhecida(etapa) {
+		http.Error(w, "O campo 'etapa' deve ser uma etapa do pipeline", http.StatusBadRequest)
+		return false
+	}
+	escopo := strings.ToLower(strings.TrimSpace(req.Escopo))
+	switch escopo {
+	case "":
+		escopo = t.Escopo
+		if escopo == "" {
+			escopo = models.EscopoNegociacao
+		}
+	case models.EscopoNegociacao, models.EscopoCliente:
+	default:
+		http.Error(w, "O campo 'escopo' deve ser 'negociacao' ou 'cliente'", http.StatusBadRequest)
+		return false
+	}
+
+	t.Nome = strings.TrimSpace(req.Nome)
+	t.Descricao = strings.TrimSpace(req.Descricao)
+	t.Obrigatorio = req.Obrigatorio
+	t.TipoProduto = strings.TrimSpace(req.TipoProduto)
+	t.Etapa = etapa
+	t.Ordem = req.Ordem
+	t.Ativo = req.Ativo == nil || *req.Ativo
+	t.Escopo = escopo
+	return true
+}
+
+func etapaConhecida(etapa string) bool {
+	for _, e := range models.EtapasPipeline {
+		if e == etapa {
+			return true
+		}
+	}
+	return false
+}
+
+/* ================== Documentos da negociação ================== */
+
+// Checklist trata GET /negociacoes/{id}/documentos
+func (h *Handler) Checklist(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	if err := h.DB.Model(neg).Association("Produtos").Find(&neg.Produtos); err != nil {
+		http.Error(w, "Erro ao buscar produtos", http.StatusInternalServerError)
+		return
+	}
+
+	itens, err := Checklist(h.DB, neg)
+	if err != nil {
+		http.Error(w, "Erro ao montar checklist", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"completude": Completude(itens),
+		"itens":      itens,
+	})
+}
+
+// Definir trata PUT /negociacoes/{id}/documentos/{tipo}
+// Body: { "url": "https://...", "status": "Enviado" }
+func (h *Handler) Definir(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+
+	var req definirDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	doc, err := Definir(h.DB, neg.ID, mux.Vars(r)["tipo"], req.URL, "", req.Status, auth.AtorDaRequisicao(r))
+	if err != nil {
+		ResponderErro(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// Revisar trata PATCH /negociacoes/{id}/documentos/{tipo}/status (somente comercial/admin)
+// Body: { "status": "Validado" } ou { "status": "Rejeitado", "motivo": "..." }
+func (h *Handler) Revisar(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+
+	var req revisarDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	codigo := mux.Vars(r)["tipo"]
+	var doc *models.NegociacaoDocumento
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		doc, err = Revisar(tx, neg.ID, codigo, req.Status, req.Motivo, auth.AtorDaRequisicao(r))
+		if err != nil || doc.Status != models.StatusRejeitado {
+			return err
+		}
+		nome := codigo
+		if t, err := h.Repository.BuscarTipo(tx, codigo); err == nil {
+			nome = t.Nome
+		}
+		return RegistrarRejeicao(tx, h.Notificador, neg, nome, doc.MotivoRejeicao)
+	})
+	if err != nil {
+		ResponderErro(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// Remover trata DELETE /negociacoes/{id}/documentos/{tipo}
+func (h *Handler) Remover(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	err := Remover(h.DB, neg.ID, mux.Vars(r)["tipo"], auth.AtorDaRequisicao(r))
+	if errors.Is(err, ErrRemocaoSemPermissao) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao remover documento", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// carregarNegociacao lê {id} e confere se a negociação está no escopo do
+// usuário (dono, comercial do time ou admin); em caso de erro já responde.
+func (h *Handler) carregarNegociacao(w http.ResponseWriter, r *http.Request) (*models.Negociacao, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id", "status", "cliente_id").First(&neg, id).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return nil, false
+	}
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, false
+	}
+	pode, err := e.AlcancaNegociacao(h.DB, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !pode {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, false
+	}
+	return &neg, true
+}
+
+// ResponderErro traduz os erros de documento para HTTP.
+func ResponderErro(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrTipoDesconhecido):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrURLObrigatoria), errors.Is(err, ErrDecisaoInvalida), errors.Is(err, ErrMotivoObrigatorio):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrRevisaoSemPermissao):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Documento não enviado", http.StatusNotFound)
+	default:
+		http.Error(w, "Erro ao salvar documento", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,117 @@
+// internal/documento/repository.go
+package documento
+
+import (
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository define operações de persistência do catálogo e dos documentos
+type Repository interface {
+	ListarTipos(db *gorm.DB, apenasAtivos bool) ([]models.TipoDocumento, error)
+	BuscarTipo(db *gorm.DB, codigo string) (*models.TipoDocumento, error)
+	SalvarTipo(db *gorm.DB, t *models.TipoDocumento) error
+	ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]models.NegociacaoDocumento, error)
+	Buscar(db *gorm.DB, negociacaoID uint, codigo string) (*models.NegociacaoDocumento, error)
+	Upsert(db *gorm.DB, d *models.NegociacaoDocumento) error
+	Remover(db *gorm.DB, negociacaoID uint, codigo string) error
+
+	// Documentos de escopo "cliente"
+	ListarPorCliente(db *gorm.DB, clienteIDs ...uint) ([]models.ClienteDocumento, error)
+	BuscarDoCliente(db *gorm.DB, clienteID uint, codigo string) (*models.ClienteDocumento, error)
+	UpsertDoCliente(db *gorm.DB, d *models.ClienteDocumento) error
+	RemoverDoCliente(db *gorm.DB, clienteID uint, codigo string) error
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) ListarTipos(db *gorm.DB, apenasAtivos bool) ([]models.TipoDocumento, error) {
+	var list []models.TipoDocumento
+	q := db.Order("ordem ASC, id ASC")
+	if apenasAtivos {
+		q = q.Where("ativo = ?", true)
+	}
+	err := q.Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) BuscarTipo(db *gorm.DB, codigo string) (*models.TipoDocumento, error) {
+	var t models.TipoDocumento
+	if err := db.Where("codigo = ?", codigo).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repositoryImpl) SalvarTipo(db *gorm.DB, t *models.TipoDocumento) error {
+	return db.Save(t).Error
+}
+
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]models.NegociacaoDocumento, error) {
+	var list []models.NegociacaoDocumento
+	err := db.Where("negociacao_id = ?", negociacaoID).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) Buscar(db *gorm.DB, negociacaoID uint, codigo string) (*models.NegociacaoDocumento, error) {
+	var d models.NegociacaoDocumento
+	if err := db.Where("negociacao_id = ? AND tipo_codigo = ?", negociacaoID, codigo).First(&d).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Upsert grava o documento do tipo, substituindo o anterior (um por tipo).
+func (r *repositoryImpl) Upsert(db *gorm.DB, d *models.NegociacaoDocumento) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "negociacao_id"}, {Name: "tipo_codigo"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"url", "thumbnail_url", "status", "enviado_por_tipo", "enviado_por_id",
+			"motivo_rejeicao", "revisado_por_id", "revisado_em", "updated_at",
+		}),
+	}).Create(d).Error
+}
+
+func (r *repositoryImpl) Remover(db *gorm.DB, negociacaoID uint, codigo string) error {
+	return db.Where("negociacao_id = ? AND tipo_codigo = ?", negociacaoID, codigo).
+		Delete(&models.NegociacaoDocumento{}).Error
+}
+
+func (r *repositoryImpl) ListarPorCliente(db *gorm.DB, clienteIDs ...uint) ([]models.ClienteDocumento, error) {
+	list := []models.ClienteDocumento{}
+	if len(clienteIDs) == 0 {
+		return list, nil
+	}
+	err := db.Where("cliente_id IN ?", clienteIDs).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) BuscarDoCliente(db *gorm.DB, clienteID uint, codigo string) (*models.ClienteDocumento, error) {
+	var d models.ClienteDocumento
+	if err := db.Where("cliente_id = ? AND tipo_codigo = ?", clienteID, codigo).First(&d).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// UpsertDoCliente grava o documento do tipo no cliente, substituindo o anterior.
+func (r *repositoryImpl) UpsertDoCliente(db *gorm.DB, d *models.ClienteDocumento) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "cliente_id"}, {Name: "tipo_codigo"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"url", "thumbnail_url", "status", "enviado_por_tipo", "enviado_por_id",
+			"motivo_rejeicao", "revisado_por_id", "revisado_em", "updated_at",
+		}),
+	}).Create(d).Error
+}
+
+func (r *repositoryImpl) RemoverDoCliente(db *gorm.DB, clienteID uint, codigo string) error {
+	return db.Where("cliente_id = ? AND tipo_codigo = ?", clienteID, codigo).
+		Delete(&models.ClienteDocumento{}).Error
+}
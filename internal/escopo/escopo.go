@@ -0,0 +1,94 @@
+// internal/escopo/escopo.go
+package escopo
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"gorm.io/gorm"
+)
+
+// Escopo diz quais negociações o usuário da requisição pode ver:
+// consultor só as próprias, comercial as do seu time, admin todas. Fica num
+// pacote próprio (sem depender de models) para que contrato, documento e
+// evento, que não podem importar negociacao, apliquem a mesma regra.
+type Escopo struct {
+	Todos       bool
+	ConsultorID uint // != 0: só as negociações deste consultor
+	ComercialID uint // != 0: só as dos consultores deste comercial
+}
+
+// ErrComercialInexistente indica token de um comercial que não existe mais.
+var ErrComercialInexistente = errors.New("comercial do token não existe mais")
+
+// DaRequisicao monta o escopo a partir das claims "tipo" e isAdmin:
+// consultor vê as próprias (todas, se admin); comercial vê as do seu time,
+// ou todas se for admin. O is_admin do comercial é relido da tabela
+// "comercials" (direto, para não depender do pacote comercial), então
+// retirar o admin vale antes de o token expirar.
+func DaRequisicao(db *gorm.DB, r *http.Request) (Escopo, error) {
+	ator := auth.AtorDaRequisicao(r)
+	if ator.Tipo != auth.AtorComercial {
+		if ator.Admin {
+			return Escopo{Todos: true}, nil
+		}
+		return Escopo{ConsultorID: ator.ID}, nil
+	}
+
+	var comercial struct{ IsAdmin bool }
+	err := db.Table("comercials").Select("is_admin").Where("id = ?", ator.ID).Take(&comercial).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Escopo{}, ErrComercialInexistente
+	}
+	if err != nil {
+		return Escopo{}, err
+	}
+	if comercial.IsAdmin {
+		return Escopo{Todos: true}, nil
+	}
+	return Escopo{ComercialID: ator.ID}, nil
+}
+
+// SQL devolve a condição do escopo sobre a tabela de negociações `alias`.
+func (e Escopo) SQL(alias string) (string, []any) {
+	switch {
+	case e.Todos:
+		return "TRUE", nil
+	case e.ComercialID != 0:
+		return alias + ".consultor_id IN (SELECT id FROM consultors WHERE comercial_id = ? AND deleted_at IS NULL)", []any{e.ComercialID}
+	default:
+		return alias + ".consultor_id = ?", []any{e.ConsultorID}
+	}
+}
+
+// Aplicar restringe uma query sobre negociacaos (sem alias) ao escopo.
+func (e Escopo) Aplicar(q *gorm.DB) *gorm.DB {
+	cond, args := e.SQL("negociacaos")
+	return q.Where(cond, args...)
+}
+
+// AlcancaConsultor diz se o consultor está dentro do escopo.
+func (e Escopo) AlcancaConsultor(db *gorm.DB, consultorID uint) (bool, error) {
+	switch {
+	case e.Todos:
+		return true, nil
+	case e.ComercialID != 0:
+		var n int64
+		err := db.Table("consultors").
+			Where("id = ? AND comercial_id = ? AND deleted_at IS NULL", consultorID, e.ComercialID).
+			Count(&n).Error
+		return n > 0, err
+	default:
+		return e.ConsultorID == consultorID, nil
+	}
+}
+
+// AlcancaNegociacao diz se a negociação existe e está dentro do escopo.
+func (e Escopo) AlcancaNegociacao(db *gorm.DB, negociacaoID uint) (bool, error) {
+	var n int64
+	err := e.Aplicar(db.Table("negociacaos")).
+		Where("id = ? AND deleted_at IS NULL", negociacaoID).
+		Count(&n).Error
+	return n > 0, err
+}
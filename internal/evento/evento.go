@@ -0,0 +1,73 @@
+// internal/evento/evento.go
+package evento
+
+import (
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"gorm.io/gorm"
+)
+
+// Tipos de evento da linha do tempo da negociação
+const (
+	NegociacaoCriada      = "negociacao_criada"
+	StatusAlterado        = "status_alterado"
+	ComentarioCriado      = "comentario_criado"
+	DocumentoEnviado      = "documento_enviado"
+	DocumentoValidado     = "documento_validado"
+	DocumentoRejeitado    = "documento_rejeitado"
+	ContratoCriado        = "contrato_criado"
+	CalculoComissaoCriado = "calculo_comissao_criado"
+	ParcelaPaga           = "parcela_paga"
+	EstudoGerado          = "estudo_gerado"
+	NegociacaoTransferida = "negociacao_transferida"
+	DivisaoDefinida       = "divisao_definida" // participantes da venda conjunta
+	SLAExcedido           = "sla_excedido"     // negociação parada além do prazo da etapa
+	ContratoVencendo      = "contrato_vencendo"
+	ContratoRenovado      = "contrato_renovado"
+	AditivoRegistrado     = "aditivo_registrado"
+	AditivoAplicado       = "aditivo_aplicado" // condições do aditivo passaram a valer
+	AssinaturaEnviada     = "assinatura_enviada"
+	AssinaturaConcluida   = "assinatura_concluida"
+	AssinaturaRecusada    = "assinatura_recusada"
+	AssinaturaCancelada   = "assinatura_cancelada"
+	ContratoRenderizado   = "contrato_renderizado" // PDF do contrato gerado de um modelo
+)
+
+// Evento é um fato de domínio registrado no momento em que a ação acontece.
+// Não depende de models para poder ser usado por contrato, cálculo e parcelas.
+type Evento struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	NegociacaoID uint      `gorm:"not null;index:idx_evento_negociacao_data,priority:1" json:"negociacaoId"`
+	CreatedAt    time.Time `gorm:"index:idx_evento_negociacao_data,priority:2" json:"createdAt"`
+	Tipo         string    `gorm:"size:50;not null;index" json:"tipo"`
+	AtorTipo     string    `gorm:"size:20;not null" json:"atorTipo"` // "consultor" | "comercial" | "sistema"
+	AtorID       *uint     `json:"atorId"`                           // nulo para sistema
+	Descricao    string    `gorm:"type:text" json:"descricao"`
+
+	// Registro de origem (ex.: "comentario" #12, "documento" #3)
+	ReferenciaTipo string `gorm:"size:50" json:"referenciaTipo,omitempty"`
+	ReferenciaID   uint   `json:"referenciaId,omitempty"`
+
+	Dados map[string]any `gorm:"type:jsonb;serializer:json" json:"dados,omitempty"`
+}
+
+// Por define o autor do evento.
+func (e Evento) Por(ator auth.Ator) Evento {
+	e.AtorTipo = ator.Tipo
+	e.AtorID = nil
+	if ator.ID != 0 {
+		id := ator.ID
+		e.AtorID = &id
+	}
+	return e
+}
+
+// Registrar grava o evento. Use o mesmo *gorm.DB (transação) da ação para que
+// evento e ação sejam gravados juntos.
+func Registrar(db *gorm.DB, e Evento) error {
+	if e.AtorTipo == "" {
+		e.AtorTipo = auth.AtorSistema
+	}
+	return db.Create(&e).Error
+}
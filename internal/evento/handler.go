@@ -0,0 +1,91 @@
+// internal/evento/handler.go
+package evento
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+const (
+	porPaginaPadrao = 30
+	porPaginaMax    = 100
+)
+
+// Handler expõe a linha do tempo das negociações
+type Handler struct {
+	DB         *gorm.DB
+	Repository Repository
+}
+
+// NewHandler cria o handler da linha do tempo
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db, Repository: NewRepository()}
+}
+
+// Timeline trata GET /negociacoes/{id}/timeline?pagina=1&porPagina=30&tipo=a,b
+func (h *Handler) Timeline(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var neg struct{ ID uint }
+	res := h.DB.Table("negociacaos").Select("id").
+		Where("id = ? AND deleted_at IS NULL", id).Take(&neg)
+	if res.Error != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	pode, err := e.AlcancaNegociacao(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if !pode {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return
+	}
+
+	q := r.URL.Query()
+	pagina, _ := strconv.Atoi(q.Get("pagina"))
+	if pagina <= 0 {
+		pagina = 1
+	}
+	porPagina, _ := strconv.Atoi(q.Get("porPagina"))
+	if porPagina <= 0 {
+		porPagina = porPaginaPadrao
+	}
+	porPagina = min(porPagina, porPaginaMax)
+
+	var tipos []string
+	for _, t := range strings.Split(q.Get("tipo"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tipos = append(tipos, t)
+		}
+	}
+
+	eventos, total, err := h.Repository.ListarPorNegociacao(h.DB, uint(id), tipos, porPagina, (pagina-1)*porPagina)
+	if err != nil {
+		http.Error(w, "Erro ao carregar linha do tempo", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"total":     total,
+		"pagina":    pagina,
+		"porPagina": porPagina,
+		"eventos":   eventos,
+	})
+}
@@ -0,0 +1,86 @@
+// internal/evento/migracao.go
+package evento
+
+import "gorm.io/gorm"
+
+// Migrate popula a linha do tempo a partir dos dados já existentes (histórico
+// de status, comentários, documentos, contratos, cálculos e parcelas pagas).
+// Só roda com a tabela vazia, então é seguro chamar a cada subida.
+func Migrate(db *gorm.DB) error {
+	var qtd int64
+	if err := db.Model(&Evento{}).Count(&qtd).Error; err != nil || qtd > 0 {
+		return err
+	}
+
+	stmts := []string{
+		// histórico de status (a primeira entrada é a criação)
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, ator_id, descricao, referencia_tipo, referencia_id, dados)
+		SELECT h.negociacao_id, h.created_at,
+			CASE WHEN COALESCE(h.status_anterior, '') = '' THEN 'negociacao_criada' ELSE 'status_alterado' END,
+			h.ator_tipo, h.ator_id,
+			CASE WHEN COALESCE(h.status_anterior, '') = '' THEN 'Negociação criada em ' || h.status_novo
+				ELSE 'Status alterado de ' || h.status_anterior || ' para ' || h.status_novo END,
+			'status_historico', h.id,
+			json_build_object('de', h.status_anterior, 'para', h.status_novo, 'motivo', h.motivo)
+		FROM negociacao_status_historicos h`,
+
+		// comentários (os de sistema já viram eventos próprios, ex.: rejeição)
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, ator_id, descricao, referencia_tipo, referencia_id)
+		SELECT c.negociacao_id, c.created_at, 'comentario_criado',
+			CASE WHEN c.comercial_id IS NOT NULL THEN 'comercial' WHEN c.consultor_id > 0 THEN 'consultor' ELSE 'sistema' END,
+			COALESCE(c.comercial_id, NULLIF(c.consultor_id, 0)),
+			c.texto, 'comentario', c.id
+		FROM comentarios c
+		WHERE c.deleted_at IS NULL AND NOT COALESCE(c.system, false)`,
+
+		// documentos: envio e, se houver, a revisão
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, ator_id, descricao, referencia_tipo, referencia_id, dados)
+		SELECT d.negociacao_id, d.created_at, 'documento_enviado',
+			COALESCE(NULLIF(d.enviado_por_tipo, ''), 'sistema'), d.enviado_por_id,
+			'Documento enviado: ' || COALESCE(t.nome, d.tipo_codigo), 'documento', d.id,
+			json_build_object('tipo', d.tipo_codigo)
+		FROM negociacao_documentos d
+		LEFT JOIN tipo_documentos t ON t.codigo = d.tipo_codigo`,
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, ator_id, descricao, referencia_tipo, referencia_id, dados)
+		SELECT d.negociacao_id, d.revisado_em,
+			CASE WHEN d.status = 'Rejeitado' THEN 'documento_rejeitado' ELSE 'documento_validado' END,
+			CASE WHEN d.revisado_por_id IS NULL THEN 'sistema' ELSE 'comercial' END, d.revisado_por_id,
+			CASE WHEN d.status = 'Rejeitado' THEN 'Documento rejeitado: ' ELSE 'Documento validado: ' END || COALESCE(t.nome, d.tipo_codigo),
+			'documento', d.id,
+			json_build_object('tipo', d.tipo_codigo, 'motivo', d.motivo_rejeicao)
+		FROM negociacao_documentos d
+		LEFT JOIN tipo_documentos t ON t.codigo = d.tipo_codigo
+		WHERE d.revisado_em IS NOT NULL AND d.status IN ('Validado', 'Rejeitado')`,
+
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, descricao, referencia_tipo, referencia_id, dados)
+		SELECT ct.negociacao_id, ct.created_at, 'contrato_criado', 'sistema',
+			'Contrato criado: ' || ct.tipo, 'contrato', ct.id,
+			json_build_object('valor', ct.valor)
+		FROM contratos ct
+		WHERE ct.deleted_at IS NULL`,
+
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, descricao, referencia_tipo, referencia_id, dados)
+		SELECT cc.negociacao_id, cc.created_at, 'calculo_comissao_criado', 'sistema',
+			'Cálculo de comissão criado', 'calculo_comissao', cc.id,
+			json_build_object('totalReceber', cc.total_receber)
+		FROM calculo_comissaos cc
+		WHERE cc.deleted_at IS NULL`,
+
+		`INSERT INTO eventos (negociacao_id, created_at, tipo, ator_tipo, descricao, referencia_tipo, referencia_id, dados)
+		SELECT cc.negociacao_id, p.data_pagamento, 'parcela_paga', 'sistema',
+			'Parcela de comissão paga', 'parcela', p.id,
+			json_build_object('valor', p.valor, 'calculoComissaoId', p.calculo_comissao_id)
+		FROM parcela_comissaos p
+		JOIN calculo_comissaos cc ON cc.id = p.calculo_comissao_id
+		WHERE p.status = 'Pago' AND p.data_pagamento IS NOT NULL`,
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range stmts {
+			if err := tx.Exec(s).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
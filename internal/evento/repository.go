@@ -0,0 +1,32 @@
+// internal/evento/repository.go
+package evento
+
+import "gorm.io/gorm"
+
+// Repository define operações de leitura da linha do tempo
+type Repository interface {
+	ListarPorNegociacao(db *gorm.DB, negociacaoID uint, tipos []string, limite, offset int) ([]Evento, int64, error)
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+// ListarPorNegociacao retorna a página de eventos, do mais recente para o mais antigo, e o total.
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negociacaoID uint, tipos []string, limite, offset int) ([]Evento, int64, error) {
+	q := db.Model(&Evento{}).Where("negociacao_id = ?", negociacaoID)
+	if len(tipos) > 0 {
+		q = q.Where("tipo IN ?", tipos)
+	}
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	list := []Evento{}
+	err := q.Order("created_at DESC, id DESC").Limit(limite).Offset(offset).Find(&list).Error
+	return list, total, err
+}
@@ -0,0 +1,352 @@
+// internal/indicacao/handler.go
+package indicacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Tamanho máximo do corpo do formulário público.
+const maxBytesLead = 16 << 10
+
+// mensagemRecebido é a resposta pública de todo envio aceito: não revela se
+// o CNPJ já existia nem se o envio caiu no honeypot.
+const mensagemRecebido = "Recebemos seus dados. O consultor entrará em contato em breve."
+
+// Handler expõe o link de indicação (público) e sua gestão pelo consultor
+type Handler struct {
+	DB             *gorm.DB
+	Notificador    notificacao.Notificador
+	Assinador      *Assinador
+	LimiteEnvio    *Limitador // POST do formulário, por IP
+	LimiteConsulta *Limitador // GET do formulário, por IP (evita varrer códigos)
+}
+
+// NewHandler cria o handler de indicações. Lê LEAD_LIMITE_ENVIOS (padrão 5)
+// e LEAD_LIMITE_JANELA (padrão 10m) para o limite de envios por IP.
+func NewHandler(db *gorm.DB, notif notificacao.Notificador) *Handler {
+	envios, janela := 5, 10*time.Minute
+	if v, err := strconv.Atoi(os.Getenv("LEAD_LIMITE_ENVIOS")); err == nil && v > 0 {
+		envios = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("LEAD_LIMITE_JANELA")); err == nil && v > 0 {
+		janela = v
+	}
+	return &Handler{
+		DB:             db,
+		Notificador:    notif,
+		Assinador:      NewAssinadorFromEnv(),
+		LimiteEnvio:    NewLimitador(envios, janela),
+		LimiteConsulta: NewLimitador(envios*12, janela),
+	}
+}
+
+type leadRequest struct {
+	Nome            string `json:"nome"` // razão social
+	CNPJ            string `json:"cnpj"`
+	Contato         string `json:"contato"`
+	Email           string `json:"email"`
+	Telefone        string `json:"telefone"`
+	NumeroDoContato string `json:"numeroDoContato"`
+	UF              string `json:"uf"`
+	Campanha        string `json:"campanha"`
+	Token           string `json:"token"` // devolvido pelo GET do formulário
+	Site            string `json:"site"`  // honeypot: campo escondido que só robôs preenchem
+}
+
+type consultorIndicacao struct {
+	ID              uint
+	Nome            string
+	Sobrenome       string
+	CodigoIndicacao string
+}
+
+/* ================== Rotas públicas ================== */
+
+// Formulario trata GET /publico/indicacoes/{codigo}: dados para montar o
+// formulário e o token que deve voltar no envio.
+func (h *Handler) Formulario(w http.ResponseWriter, r *http.Request) {
+	if !h.dentroDoLimite(w, r, h.LimiteConsulta) {
+		return
+	}
+	c, ok := h.carregarPorCodigo(w, mux.Vars(r)["codigo"])
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"codigo":    c.CodigoIndicacao,
+		"consultor": c.Nome,
+		"token":     h.Assinador.Emitir(c.CodigoIndicacao, time.Now()),
+	})
+}
+
+// EnviarLead trata POST /publico/indicacoes/{codigo}/leads (sem autenticação):
+// cria a negociação "Aberta" do consultor do link e o avisa.
+func (h *Handler) EnviarLead(w http.ResponseWriter, r *http.Request) {
+	if !h.dentroDoLimite(w, r, h.LimiteEnvio) {
+		return
+	}
+	var req leadRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBytesLead)).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Site) != "" {
+		log.Printf("[indicacao] envio descartado pelo honeypot (ip %s)", ipDaRequisicao(r))
+		responderRecebido(w)
+		return
+	}
+
+	c, ok := h.carregarPorCodigo(w, mux.Vars(r)["codigo"])
+	if !ok {
+		return
+	}
+	if err := h.Assinador.Validar(req.Token, c.CodigoIndicacao, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validarLead(&req); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	n := models.Negociacao{
+		Nome:            req.Nome,
+		CNPJ:            req.CNPJ,
+		Contato:         req.Contato,
+		Email:           req.Email,
+		Telefone:        req.Telefone,
+		NumeroDoContato: req.NumeroDoContato,
+		UF:              req.UF,
+		ConsultorID:     c.ID,
+	}
+	lead := Lead{
+		ConsultorID: c.ID,
+		Codigo:      c.CodigoIndicacao,
+		CNPJ:        req.CNPJ,
+		Campanha:    req.Campanha,
+		IP:          ipDaRequisicao(r),
+		UserAgent:   limitar(r.UserAgent(), 255),
+	}
+
+	res, err := negociacao.CriarLead(h.DB, &n)
+	var bloqueado *negociacao.ErrCNPJBloqueado
+	switch {
+	case errors.As(err, &bloqueado):
+		lead.Resultado = ResultadoBloqueada
+	case err != nil:
+		http.Error(w, "Erro ao registrar contato", http.StatusInternalServerError)
+		return
+	case res.Existente:
+		lead.Resultado = ResultadoExistente
+	case res.EmRevisao:
+		lead.Resultado = ResultadoEmRevisao
+	default:
+		lead.Resultado = ResultadoCriada
+	}
+	if res.Negociacao != nil {
+		id := res.Negociacao.ID
+		lead.NegociacaoID = &id
+	}
+	if err := h.DB.Create(&lead).Error; err != nil {
+		log.Printf("[indicacao] erro ao registrar lead da negociação %v: %v", lead.NegociacaoID, err)
+	}
+
+	h.notificar(c, lead, req)
+	responderRecebido(w)
+}
+
+/* ================== Gestão pelo consultor ================== */
+
+// Meu trata GET /consultores/{id}/indicacao: código, link e contagem de leads.
+func (h *Handler) Meu(w http.ResponseWriter, r *http.Request) {
+	id, ok := autorizarConsultor(w, r)
+	if !ok {
+		return
+	}
+	var codigo string
+	if err := h.DB.Table("consultors").Select("codigo_indicacao").
+		Where("id = ? AND deleted_at IS NULL", id).Scan(&codigo).Error; err != nil {
+		http.Error(w, "Erro ao buscar consultor", http.StatusInternalServerError)
+		return
+	}
+	if codigo == "" {
+		http.Error(w, "Consultor não encontrado", http.StatusNotFound)
+		return
+	}
+
+	var porResultado []struct {
+		Resultado  string
+		Quantidade int64
+	}
+	if err := h.DB.Model(&Lead{}).Select("resultado, COUNT(*) AS quantidade").
+		Where("consultor_id = ?", id).Group("resultado").Scan(&porResultado).Error; err != nil {
+		http.Error(w, "Erro ao contar leads", http.StatusInternalServerError)
+		return
+	}
+	leads := map[string]int64{}
+	for _, p := range porResultado {
+		leads[p.Resultado] = p.Quantidade
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"codigo": codigo,
+		"link":   linkIndicacao(codigo),
+		"leads":  leads,
+	})
+}
+
+// Renovar trata POST /consultores/{id}/indicacao/renovar: troca o código (o
+// link antigo deixa de funcionar; os leads antigos continuam atribuídos).
+func (h *Handler) Renovar(w http.ResponseWriter, r *http.Request) {
+	id, ok := autorizarConsultor(w, r)
+	if !ok {
+		return
+	}
+	var qtd int64
+	if err := h.DB.Table("consultors").Where("id = ? AND deleted_at IS NULL", id).Count(&qtd).Error; err != nil || qtd == 0 {
+		http.Error(w, "Consultor não encontrado", http.StatusNotFound)
+		return
+	}
+	codigo, err := definirNovoCodigo(h.DB, id)
+	if err != nil {
+		http.Error(w, "Erro ao gerar código", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"codigo": codigo, "link": linkIndicacao(codigo)})
+}
+
+/* ================== Helpers ================== */
+
+func (h *Handler) dentroDoLimite(w http.ResponseWriter, r *http.Request, l *Limitador) bool {
+	ok, espera := l.Permitir(ipDaRequisicao(r), time.Now())
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(espera.Seconds()))))
+		http.Error(w, "Muitas tentativas; aguarde alguns minutos", http.StatusTooManyRequests)
+	}
+	return ok
+}
+
+func (h *Handler) carregarPorCodigo(w http.ResponseWriter, codigo string) (*consultorIndicacao, bool) {
+	codigo = strings.ToUpper(strings.TrimSpace(codigo))
+	var c consultorIndicacao
+	err := h.DB.Table("consultors").Select("id, nome, sobrenome, codigo_indicacao").
+		Where("codigo_indicacao = ? AND deleted_at IS NULL", codigo).
+		Take(&c).Error
+	if err != nil || codigo == "" {
+		http.Error(w, "Link de indicação inválido", http.StatusNotFound)
+		return nil, false
+	}
+	return &c, true
+}
+
+// validarLead limpa os campos e devolve a mensagem de erro, se houver.
+func validarLead(req *leadRequest) string {
+	for _, campo := range []*string{&req.Nome, &req.CNPJ, &req.Contato, &req.Email, &req.Telefone, &req.NumeroDoContato, &req.Campanha} {
+		*campo = limitar(strings.TrimSpace(*campo), 255)
+	}
+	req.Campanha = limitar(req.Campanha, 100)
+	req.UF = strings.ToUpper(strings.TrimSpace(req.UF))
+	switch {
+	case req.Nome == "":
+		return "informe a razão social"
+	case len(utils.NormalizarCNPJ(req.CNPJ)) != 14:
+		return "CNPJ inválido"
+	case req.Email == "" && req.Telefone == "" && req.NumeroDoContato == "":
+		return "informe um e-mail ou telefone para contato"
+	case req.Email != "" && !strings.Contains(req.Email, "@"):
+		return "e-mail inválido"
+	case req.UF != "" && len(req.UF) != 2:
+		return "UF inválida"
+	}
+	return ""
+}
+
+func (h *Handler) notificar(c *consultorIndicacao, lead Lead, req leadRequest) {
+	if h.Notificador == nil {
+		return
+	}
+	n := notificacao.Notificacao{
+		Tipo:             "lead_recebido",
+		DestinatarioTipo: notificacao.DestinoConsultor,
+		DestinatarioID:   c.ID,
+		Titulo:           "Novo lead pelo seu link de indicação",
+		Mensagem:         fmt.Sprintf("%s (CNPJ %s)", req.Nome, req.CNPJ),
+		Dados:            map[string]any{"resultado": lead.Resultado, "campanha": lead.Campanha},
+	}
+	switch lead.Resultado {
+	case ResultadoExistente:
+		n.Titulo = "Lead recebido para uma negociação que você já tem"
+	case ResultadoEmRevisao:
+		n.Titulo = "Novo lead pelo seu link de indicação (CNPJ em revisão)"
+	case ResultadoBloqueada:
+		n.Tipo = "lead_bloqueado"
+		n.Titulo = "Lead recebido, mas o CNPJ já está com outro consultor"
+	}
+	if lead.NegociacaoID != nil {
+		n.NegociacaoID = *lead.NegociacaoID
+	}
+	if err := h.Notificador.Notificar(n); err != nil {
+		log.Printf("[indicacao] erro ao notificar consultor %d: %v", c.ID, err)
+	}
+}
+
+// autorizarConsultor: o próprio consultor ou um admin/comercial.
+func autorizarConsultor(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	ator := auth.AtorDaRequisicao(r)
+	proprio := ator.Tipo == auth.AtorConsultor && ator.ID == uint(id)
+	if !proprio && !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// linkIndicacao monta o link público a partir de LEAD_FORM_URL (ex.:
+// "https://kromaenergia.com.br/indicacao/"); vazio se não configurado.
+func linkIndicacao(codigo string) string {
+	base := strings.TrimSpace(os.Getenv("LEAD_FORM_URL"))
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + "/" + codigo
+}
+
+func responderRecebido(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{"mensagem": mensagemRecebido})
+}
+
+func limitar(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
@@ -0,0 +1,103 @@
+// internal/indicacao/limite.go
+package indicacao
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limitador conta requisições por IP numa janela fixa. Fica em memória:
+// com várias instâncias, o limite efetivo é por instância.
+type Limitador struct {
+	Max    int
+	Janela time.Duration
+
+	mu       sync.Mutex
+	contagem map[string]*janelaIP
+}
+
+type janelaIP struct {
+	inicio time.Time
+	total  int
+}
+
+// NewLimitador cria um limitador de max requisições por janela.
+func NewLimitador(max int, janela time.Duration) *Limitador {
+	return &Limitador{Max: max, Janela: janela, contagem: map[string]*janelaIP{}}
+}
+
+// Permitir registra a requisição do IP e diz se ela cabe no limite; quando
+// não cabe, devolve quanto falta para a janela reabrir.
+func (l *Limitador) Permitir(ip string, agora time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	j, ok := l.contagem[ip]
+	if !ok && len(l.contagem) >= maxIPs {
+		l.limpar(agora)
+		if len(l.contagem) >= maxIPs {
+			l.descartarMaisAntiga()
+		}
+	}
+	if !ok || agora.Sub(j.inicio) >= l.Janela {
+		j = &janelaIP{inicio: agora}
+		l.contagem[ip] = j
+	}
+	if j.total >= l.Max {
+		return false, j.inicio.Add(l.Janela).Sub(agora)
+	}
+	j.total++
+	return true, 0
+}
+
+// maxIPs limita quantos IPs o limitador acompanha ao mesmo tempo.
+const maxIPs = 10000
+
+// limpar descarta as janelas já encerradas.
+func (l *Limitador) limpar(agora time.Time) {
+	for ip, j := range l.contagem {
+		if agora.Sub(j.inicio) >= l.Janela {
+			delete(l.contagem, ip)
+		}
+	}
+}
+
+// descartarMaisAntiga abre espaço quando todas as janelas ainda estão
+// abertas: sai a que começou primeiro.
+func (l *Limitador) descartarMaisAntiga() {
+	var alvo string
+	var inicio time.Time
+	for ip, j := range l.contagem {
+		if alvo == "" || j.inicio.Before(inicio) {
+			alvo, inicio = ip, j.inicio
+		}
+	}
+	delete(l.contagem, alvo)
+}
+
+// ipDaRequisicao usa o último endereço do X-Forwarded-For (o que o proxy
+// acrescentou; os anteriores vêm do cliente e podem ser forjados) só quando
+// a API está atrás de um proxy confiável (LEAD_CONFIAR_PROXY=true); senão,
+// o RemoteAddr.
+func ipDaRequisicao(r *http.Request) string {
+	if os.Getenv("LEAD_CONFIAR_PROXY") == "true" {
+		if valores := r.Header.Values("X-Forwarded-For"); len(valores) > 0 {
+			ultimo := valores[len(valores)-1]
+			if i := strings.LastIndex(ultimo, ","); i >= 0 {
+				ultimo = ultimo[i+1:]
+			}
+			if ip := strings.TrimSpace(ultimo); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+// internal/indicacao/migracao.go
+package indicacao
+
+import (
+	"fmt"
+
+	"github.com/KromaEnergia/api-consultor/internal/consultor"
+	"gorm.io/gorm"
+)
+
+// Migrate dá código de indicação aos consultores que ainda não têm um
+// (os criados depois ganham no BeforeCreate). Seguro rodar a cada subida.
+func Migrate(db *gorm.DB) error {
+	var ids []uint
+	if err := db.Table("consultors").
+		Where("codigo_indicacao IS NULL OR codigo_indicacao = ''").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := definirNovoCodigo(db, id); err != nil {
+			return fmt.Errorf("consultor %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// definirNovoCodigo sorteia e grava um código, tentando de novo se colidir.
+func definirNovoCodigo(db *gorm.DB, consultorID uint) (codigo string, err error) {
+	for tentativa := 0; tentativa < 3; tentativa++ {
+		if codigo, err = consultor.GerarCodigoIndicacao(); err != nil {
+			return "", err
+		}
+		var qtd int64
+		if err = db.Table("consultors").Where("codigo_indicacao = ?", codigo).Count(&qtd).Error; err != nil {
+			return "", err
+		}
+		if qtd > 0 {
+			continue
+		}
+		err = db.Table("consultors").Where("id = ?", consultorID).Update("codigo_indicacao", codigo).Error
+		return codigo, err
+	}
+	return "", fmt.Errorf("não foi possível gerar um código de indicação único")
+}
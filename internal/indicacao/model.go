@@ -0,0 +1,30 @@
+// internal/indicacao/model.go
+package indicacao
+
+import "time"
+
+// Resultado do envio do formulário público
+const (
+	ResultadoCriada    = "criada"     // nova negociação do consultor
+	ResultadoEmRevisao = "em_revisao" // criada, com conflito de CNPJ na fila dos admins
+	ResultadoExistente = "existente"  // o consultor já negociava esse CNPJ
+	ResultadoBloqueada = "bloqueada"  // CNPJ em posse de outro consultor
+)
+
+// Lead registra cada envio válido do formulário de indicação, inclusive os
+// que não viraram negociação, para o relatório de atribuição.
+type Lead struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time `gorm:"index" json:"createdAt"`
+	ConsultorID  uint      `gorm:"not null;index" json:"consultorId"`
+	Codigo       string    `gorm:"size:12;not null" json:"codigo"` // código usado no link
+	NegociacaoID *uint     `gorm:"index" json:"negociacaoId"`
+	Resultado    string    `gorm:"size:20;not null;index" json:"resultado"`
+
+	CNPJ      string `gorm:"size:20" json:"cnpj"`
+	Campanha  string `gorm:"size:100" json:"campanha,omitempty"` // utm_campaign/utm_source do link
+	IP        string `gorm:"size:45" json:"-"`
+	UserAgent string `gorm:"size:255" json:"-"`
+}
+
+func (Lead) TableName() string { return "leads_indicacao" }
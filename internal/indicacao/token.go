@@ -0,0 +1,80 @@
+// internal/indicacao/token.go
+package indicacao
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrTokenInvalido = errors.New("formulário inválido; recarregue a página")
+	ErrTokenExpirado = errors.New("formulário expirado; recarregue a página")
+	ErrEnvioRapido   = errors.New("envio rápido demais; tente novamente")
+)
+
+// Assinador emite e confere o token do formulário público. O token amarra o
+// código de indicação ao horário em que o formulário foi aberto: envios
+// rápidos demais (robôs) ou muito depois (token reaproveitado) são recusados.
+type Assinador struct {
+	segredo     []byte
+	TempoMinimo time.Duration
+	Validade    time.Duration
+}
+
+// NewAssinadorFromEnv lê LEAD_FORM_SEGREDO. Sem ele, usa um segredo aleatório
+// (os formulários abertos deixam de valer quando a API reinicia).
+func NewAssinadorFromEnv() *Assinador {
+	segredo := []byte(os.Getenv("LEAD_FORM_SEGREDO"))
+	if len(segredo) == 0 {
+		log.Println("[indicacao] LEAD_FORM_SEGREDO não definido; usando segredo temporário")
+		segredo = make([]byte, 32)
+		if _, err := rand.Read(segredo); err != nil {
+			log.Fatal("[indicacao] erro ao gerar segredo: ", err)
+		}
+	}
+	return &Assinador{segredo: segredo, TempoMinimo: 3 * time.Second, Validade: 2 * time.Hour}
+}
+
+// Emitir gera o token "<codigo>.<unix>.<assinatura>".
+func (a *Assinador) Emitir(codigo string, agora time.Time) string {
+	payload := codigo + "." + strconv.FormatInt(agora.Unix(), 10)
+	return payload + "." + a.assinar(payload)
+}
+
+// Validar confere assinatura, código e janela de tempo do token.
+func (a *Assinador) Validar(token, codigo string, agora time.Time) error {
+	partes := strings.Split(token, ".")
+	if len(partes) != 3 || partes[0] != codigo {
+		return ErrTokenInvalido
+	}
+	payload := partes[0] + "." + partes[1]
+	if !hmac.Equal([]byte(a.assinar(payload)), []byte(partes[2])) {
+		return ErrTokenInvalido
+	}
+	unix, err := strconv.ParseInt(partes[1], 10, 64)
+	if err != nil {
+		return ErrTokenInvalido
+	}
+	idade := agora.Sub(time.Unix(unix, 0))
+	switch {
+	case idade < a.TempoMinimo:
+		return ErrEnvioRapido
+	case idade > a.Validade:
+		return ErrTokenExpirado
+	}
+	return nil
+}
+
+func (a *Assinador) assinar(payload string) string {
+	mac := hmac.New(sha256.New, a.segredo)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+// models/cliente.go
+package models
+
+import (
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Escopo do tipo de documento: da negociação ou do cliente (compartilhado por
+// todas as negociações do mesmo cliente, ex.: contrato social).
+const (
+	EscopoNegociacao = "negociacao"
+	EscopoCliente    = "cliente"
+)
+
+// Cliente é a empresa negociada. Os dados básicos ficam aqui e são copiados
+// para cada negociação (nome, CNPJ, contato...) para manter as consultas e o
+// JSON das negociações como estavam.
+type Cliente struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+
+	Nome            string `gorm:"not null" json:"nome"`
+	CNPJ            string `json:"cnpj"`
+	CNPJNormalizado string `gorm:"size:14;uniqueIndex:idx_cliente_cnpj,where:cnpj_normalizado <> '' AND deleted_at IS NULL" json:"-"`
+	Email           string `json:"email"`
+	Contato         string `json:"contato"`
+	NumeroDoContato string `json:"numeroDoContato"`
+	Telefone        string `json:"telefone"`
+	UF              string `json:"uf"`
+
+	Contatos   []ClienteContato   `gorm:"foreignKey:ClienteID;constraint:OnDelete:CASCADE" json:"contatos,omitempty"`
+	Documentos []ClienteDocumento `gorm:"foreignKey:ClienteID;constraint:OnDelete:CASCADE" json:"documentos,omitempty"`
+}
+
+// BeforeSave mantém o CNPJ normalizado usado na deduplicação.
+func (c *Cliente) BeforeSave(tx *gorm.DB) error {
+	c.CNPJNormalizado = utils.NormalizarCNPJ(c.CNPJ)
+	return nil
+}
+
+// ClienteContato é uma pessoa de contato do cliente.
+type ClienteContato struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ClienteID uint      `gorm:"not null;index" json:"clienteId"`
+	Nome      string    `gorm:"not null" json:"nome"`
+	Cargo     string    `json:"cargo"`
+	Email     string    `json:"email"`
+	Telefone  string    `json:"telefone"`
+	Principal bool      `gorm:"not null;default:false" json:"principal"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ClienteDocumento é o documento de um tipo de escopo "cliente".
+// Um por tipo em cada cliente; vale para todas as negociações dele.
+type ClienteDocumento struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	ClienteID      uint   `gorm:"not null;uniqueIndex:idx_cli_doc_tipo" json:"clienteId"`
+	TipoCodigo     string `gorm:"size:50;not null;uniqueIndex:idx_cli_doc_tipo" json:"tipo"`
+	URL            string `gorm:"type:text" json:"url"`
+	ThumbnailURL   string `gorm:"type:text" json:"thumbnailUrl,omitempty"`
+	Status         string `gorm:"size:30;not null" json:"status"` // "Enviado" | "Validado" | "Rejeitado"
+	EnviadoPorTipo string `gorm:"size:20" json:"enviadoPorTipo"`
+	EnviadoPorID   *uint  `json:"enviadoPorId"`
+
+	// Revisão (comercial/admin)
+	MotivoRejeicao string     `gorm:"type:text" json:"motivoRejeicao,omitempty"`
+	RevisadoPorID  *uint      `json:"revisadoPorId"`
+	RevisadoEm     *time.Time `json:"revisadoEm"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (ClienteDocumento) TableName() string { return "cliente_documentos" }
+
+// ComoDocumentoDaNegociacao devolve o documento no formato usado pelo
+// checklist da negociação, marcado com a origem "cliente".
+func (d ClienteDocumento) ComoDocumentoDaNegociacao(negociacaoID uint) NegociacaoDocumento {
+	return NegociacaoDocumento{
+		ID:             d.ID,
+		NegociacaoID:   negociacaoID,
+		TipoCodigo:     d.TipoCodigo,
+		URL:            d.URL,
+		ThumbnailURL:   d.ThumbnailURL,
+		Status:         d.Status,
+		EnviadoPorTipo: d.EnviadoPorTipo,
+		EnviadoPorID:   d.EnviadoPorID,
+		MotivoRejeicao: d.MotivoRejeicao,
+		RevisadoPorID:  d.RevisadoPorID,
+		RevisadoEm:     d.RevisadoEm,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+		Origem:         EscopoCliente,
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Situação de um conflito de CNPJ na fila de revisão
+const (
+	ConflitoPendente  = "Pendente"
+	ConflitoMantido   = "Mantido"   // admin liberou as duas negociações
+	ConflitoCancelado = "Cancelado" // a negociação nova foi cancelada
+)
+
+// ConflitoCNPJ registra uma negociação aberta para um CNPJ que já está com
+// outro consultor. Fica na fila até um admin resolver.
+type ConflitoCNPJ struct {
+	ID                    uint       `gorm:"primaryKey" json:"id"`
+	CNPJ                  string     `gorm:"size:14;not null;index" json:"cnpj"`    // só dígitos
+	NegociacaoID          uint       `gorm:"not null;index" json:"negociacaoId"`    // a mais recente
+	ConsultorID           uint       `gorm:"not null" json:"consultorId"`           // dono da mais recente
+	NegociacaoExistenteID uint       `gorm:"not null" json:"negociacaoExistenteId"` // a que já existia
+	ConsultorExistenteID  uint       `gorm:"not null" json:"consultorExistenteId"`  // dono da que já existia
+	Regra                 string     `gorm:"size:20;not null" json:"regra"`         // regra vigente quando o conflito foi aberto
+	Status                string     `gorm:"size:20;not null;index" json:"status"`  // Pendente | Mantido | Cancelado
+	Observacao            string     `gorm:"type:text" json:"observacao"`
+	ResolvidoPorID        *uint      `json:"resolvidoPorId"`
+	ResolvidoEm           *time.Time `json:"resolvidoEm"`
+	CreatedAt             time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Códigos dos tipos de documento que já existiam como colunas em Negociacao.
+const (
+	DocLogo               = "logo"
+	DocEstudo             = "estudo"
+	DocContratoKC         = "contrato_kc"
+	DocContratoSocial     = "contrato_social"
+	DocProcuracao         = "procuracao"
+	DocRepresentanteLegal = "representante_legal"
+	DocEstudoViabilidade  = "estudo_viabilidade"
+)
+
+// Tipos de documento gerados pela API (PDF).
+const (
+	DocProposta = "proposta"
+)
+
+// TipoDocumento é um item do catálogo (checklist) de documentos da negociação.
+type TipoDocumento struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Codigo      string    `gorm:"size:50;not null;uniqueIndex" json:"codigo"`
+	Nome        string    `gorm:"size:120;not null" json:"nome"`
+	Descricao   string    `gorm:"type:text" json:"descricao"`
+	Obrigatorio bool      `gorm:"not null;default:false" json:"obrigatorio"`
+	TipoProduto string    `gorm:"size:255" json:"tipoProduto"` // vazio = qualquer produto
+	Etapa       string    `gorm:"size:50" json:"etapa"`        // obrigatório a partir desta etapa; vazio = desde o início
+	Escopo      string    `gorm:"size:20" json:"escopo"`       // "negociacao" | "cliente"
+	Ordem       int       `gorm:"not null;default:0" json:"ordem"`
+	Ativo       bool      `gorm:"not null" json:"ativo"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// NegociacaoDocumento é o documento enviado para um tipo do catálogo.
+// Um por tipo em cada negociação.
+type NegociacaoDocumento struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	NegociacaoID   uint   `gorm:"not null;uniqueIndex:idx_neg_doc_tipo" json:"negociacaoId"`
+	TipoCodigo     string `gorm:"size:50;not null;uniqueIndex:idx_neg_doc_tipo" json:"tipo"`
+	URL            string `gorm:"type:text" json:"url"`
+	ThumbnailURL   string `gorm:"type:text" json:"thumbnailUrl,omitempty"`
+	Status         string `gorm:"size:30;not null" json:"status"` // "Enviado" | "Validado" | "Rejeitado"
+	EnviadoPorTipo string `gorm:"size:20" json:"enviadoPorTipo"`
+	EnviadoPorID   *uint  `json:"enviadoPorId"`
+
+	// Revisão (comercial/admin)
+	MotivoRejeicao string     `gorm:"type:text" json:"motivoRejeicao,omitempty"`
+	RevisadoPorID  *uint      `json:"revisadoPorId"`
+	RevisadoEm     *time.Time `json:"revisadoEm"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// "cliente" quando o documento vem do cadastro do cliente (não persiste)
+	Origem string `gorm:"-" json:"origem,omitempty"`
+}
+
+// DoCliente diz se o tipo é guardado no cliente, e não na negociação.
+func (t TipoDocumento) DoCliente() bool { return t.Escopo == EscopoCliente }
+
+func (NegociacaoDocumento) TableName() string { return "negociacao_documentos" }
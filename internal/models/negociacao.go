@@ -7,20 +7,56 @@ import (
 	"github.com/KromaEnergia/api-consultor/internal/calculocomissao"
 	"github.com/KromaEnergia/api-consultor/internal/contrato"
 	produto "github.com/KromaEnergia/api-consultor/internal/produtos"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
 	"gorm.io/gorm"
 )
 
 // Convenção de status textual para anexos
 const (
-	StatusPendente = "Pendente"
-	StatusEnviado  = "Enviado"
-	StatusValidado = "Validado"
+	StatusPendente  = "Pendente"
+	StatusEnviado   = "Enviado"
+	StatusValidado  = "Validado"
+	StatusRejeitado = "Rejeitado" // exige motivo; só comercial/admin revisa
+)
+
+// Etapas do pipeline da negociação (transições em negociacao/status.go)
+const (
+	StatusAberta           = "Aberta"
+	StatusEmEstudo         = "Em Estudo"
+	StatusEstudoFeito      = "Estudo Feito"
+	StatusContratoEnviado  = "Contrato Enviado"
+	StatusContratoAssinado = "Contrato Assinado"
+	StatusFechada          = "Fechada"
+	StatusCancelada        = "Cancelada"
+)
+
+// EtapasPipeline lista as etapas em ordem de avanço (Cancelada fica de fora).
+var EtapasPipeline = []string{
+	StatusAberta,
+	StatusEmEstudo,
+	StatusEstudoFeito,
+	StatusContratoEnviado,
+	StatusContratoAssinado,
+	StatusFechada,
+}
+
+// StatusEncerrado diz se a negociação já saiu do pipeline (Fechada ou Cancelada).
+func StatusEncerrado(status string) bool {
+	return status == StatusFechada || status == StatusCancelada
+}
+
+// Origem da negociação
+const (
+	OrigemCadastro  = "cadastro"  // criada pelo consultor (POST /negociacoes)
+	OrigemIndicacao = "indicacao" // lead recebido pelo link de indicação do consultor
+	OrigemRenovacao = "renovacao" // criada ao renovar um contrato (POST /contratos/{id}/renovar)
 )
 
 // MultiAnexo representa anexos múltiplos com status textual (para Fatura).
 type MultiAnexo struct {
 	Itens  []string `json:"itens"`            // 1+ links
-	Status string   `json:"status,omitempty"` // "Pendente" | "Enviado" | "Validado" (ou outros que você aceitar)
+	Status string   `json:"status,omitempty"` // "Pendente" | "Enviado" | "Validado" | "Rejeitado"
+	Motivo string   `json:"motivoRejeicao,omitempty"`
 }
 
 type Negociacao struct {
@@ -29,6 +65,13 @@ type Negociacao struct {
 	UpdatedAt time.Time      `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
 
+	// Versão para controle de concorrência (exposta como ETag)
+	Versao uint `gorm:"not null;default:1" json:"versao"`
+
+	// Cliente da negociação; os dados básicos abaixo são uma cópia do cadastro
+	ClienteID *uint    `gorm:"index" json:"clienteId"`
+	Cliente   *Cliente `gorm:"constraint:OnDelete:SET NULL" json:"cliente,omitempty"`
+
 	// Dados básicos
 	Nome            string `json:"nome"`
 	Email           string `json:"email"`
@@ -36,24 +79,10 @@ type Negociacao struct {
 	NumeroDoContato string `json:"numeroDoContato"`
 	Telefone        string `json:"telefone"`
 	CNPJ            string `json:"cnpj"`
+	CNPJNormalizado string `gorm:"size:14;index" json:"-"` // só dígitos, preenchido no BeforeSave
 
-	// ---- Anexos simples + status textual ----
-	Logo                      string `json:"logo"`
-	LogoStatus                string `json:"logoStatus"` // ex.: "Pendente", "Enviado", "Validado"
-	AnexoEstudo               string `json:"anexoEstudo"`
-	AnexoEstudoStatus         string `json:"anexoEstudoStatus"`
-	ContratoKC                string `json:"contratoKC"`
-	ContratoKCStatus          string `json:"contratoKCStatus"`
-	AnexoContratoSocial       string `json:"anexoContratoSocial"`
-	AnexoContratoSocialStatus string `json:"anexoContratoSocialStatus"`
-
-	// ---- Novos anexos solicitados + status textual ----
-	AnexoProcuracao                string `json:"anexoProcuracao"`
-	AnexoProcuracaoStatus          string `json:"anexoProcuracaoStatus"`
-	AnexoRepresentanteLegal        string `json:"anexoRepresentanteLegal"`
-	AnexoRepresentanteLegalStatus  string `json:"anexoRepresentanteLegalStatus"`
-	AnexoEstudoDeViabilidade       string `json:"anexoEstudoDeViabilidade"`
-	AnexoEstudoDeViabilidadeStatus string `json:"anexoEstudoDeViabilidadeStatus"`
+	// Documentos do checklist (logo, estudo, contrato KC, ...); catálogo em TipoDocumento
+	Documentos []NegociacaoDocumento `gorm:"foreignKey:NegociacaoID;constraint:OnDelete:CASCADE" json:"documentos"`
 
 	// ---- Fatura: objeto (vários links) + status textual ----
 	AnexoFatura MultiAnexo `gorm:"type:jsonb;serializer:json" json:"anexoFatura"`
@@ -69,6 +98,31 @@ type Negociacao struct {
 	UF          string              `json:"uf"`
 	ConsultorID uint                `json:"consultorId"`
 
+	// Como a negociação chegou: cadastro pelo consultor, lead do link de
+	// indicação ou renovação de contrato (IndicadoPorID guarda o consultor do
+	// link, mesmo após transferências)
+	Origem        string `gorm:"size:20;not null;default:'cadastro';index" json:"origem"`
+	IndicadoPorID *uint  `gorm:"index" json:"indicadoPorId,omitempty"`
+
+	// Prazo (SLA) da etapa atual: quando a negociação entrou nela e quando o
+	// prazo configurado estourou (limpo a cada mudança de etapa)
+	StatusDesde  *time.Time `gorm:"index" json:"statusDesde"`
+	EmRiscoDesde *time.Time `gorm:"index" json:"emRiscoDesde,omitempty"`
+
 	Comentarios      []Comentario                      `gorm:"foreignKey:NegociacaoID" json:"comentarios"`
 	CalculosComissao []calculocomissao.CalculoComissao `gorm:"foreignKey:NegociacaoID;constraint:OnDelete:CASCADE" json:"calculosComissao"`
+
+	// Percentual de documentos obrigatórios validados (calculado, não persiste)
+	Completude int `gorm:"-" json:"completude"`
+}
+
+// BeforeSave mantém o CNPJ normalizado usado na detecção de duplicidade e
+// marca o início da etapa das negociações novas. Só vale para Create/Save; updates por map não passam por aqui.
+func (n *Negociacao) BeforeSave(tx *gorm.DB) error {
+	n.CNPJNormalizado = utils.NormalizarCNPJ(n.CNPJ)
+	if n.StatusDesde == nil {
+		agora := time.Now()
+		n.StatusDesde = &agora
+	}
+	return nil
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// NegociacaoParticipante é um consultor que divide a comissão da negociação
+// (venda conjunta). Sem participantes, o dono da negociação recebe tudo; com
+// participantes, os percentuais somam 100 e cada cálculo de comissão gera
+// parcelas para cada um.
+type NegociacaoParticipante struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	NegociacaoID uint      `gorm:"not null;uniqueIndex:idx_participante_negociacao_consultor,priority:1" json:"negociacaoId"`
+	ConsultorID  uint      `gorm:"not null;uniqueIndex:idx_participante_negociacao_consultor,priority:2;index" json:"consultorId"`
+	Percentual   float64   `gorm:"not null" json:"percentual"` // 0–100
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (NegociacaoParticipante) TableName() string { return "negociacao_participantes" }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// NegociacaoStatusHistorico registra cada mudança de etapa do pipeline.
+type NegociacaoStatusHistorico struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	NegociacaoID   uint      `gorm:"not null;index" json:"negociacaoId"`
+	StatusAnterior string    `gorm:"size:50" json:"statusAnterior"` // vazio na criação
+	StatusNovo     string    `gorm:"size:50;not null" json:"statusNovo"`
+	AtorTipo       string    `gorm:"size:20;not null" json:"atorTipo"` // "consultor" | "comercial" | "sistema"
+	AtorID         *uint     `json:"atorId"`                           // nulo para sistema
+	Motivo         string    `gorm:"type:text" json:"motivo"`
+	CreatedAt      time.Time `gorm:"index" json:"createdAt"`
+}
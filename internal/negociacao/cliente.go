@@ -0,0 +1,125 @@
+// internal/negociacao/cliente.go
+package negociacao
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrClienteNaoEncontrado = errors.New("cliente não encontrado")
+	ErrCNPJDiferenteCliente = errors.New("o CNPJ informado é diferente do CNPJ do cliente")
+)
+
+// preencherDoCliente liga a negociação a um cliente já cadastrado (clienteId
+// no POST). Campos básicos vazios na negociação são copiados do cliente.
+func preencherDoCliente(db *gorm.DB, n *models.Negociacao, clienteID uint) error {
+	var c models.Cliente
+	if err := db.First(&c, clienteID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrClienteNaoEncontrado
+		}
+		return err
+	}
+	if cnpj := utils.NormalizarCNPJ(n.CNPJ); cnpj != "" && c.CNPJNormalizado != "" && cnpj != c.CNPJNormalizado {
+		return ErrCNPJDiferenteCliente
+	}
+
+	campos := []struct {
+		dst *string
+		src string
+	}{
+		{&n.Nome, c.Nome},
+		{&n.CNPJ, c.CNPJ},
+		{&n.Email, c.Email},
+		{&n.Contato, c.Contato},
+		{&n.NumeroDoContato, c.NumeroDoContato},
+		{&n.Telefone, c.Telefone},
+		{&n.UF, c.UF},
+	}
+	for _, f := range campos {
+		if strings.TrimSpace(*f.dst) == "" {
+			*f.dst = f.src
+		}
+	}
+	n.ClienteID = &c.ID
+	return nil
+}
+
+// VincularCliente associa a negociação ao cliente do seu CNPJ, criando o
+// cadastro (com os dados básicos da negociação) quando ainda não existe. Um
+// cadastro existente nunca é alterado aqui: ele é compartilhado entre
+// negociações e só muda por PUT /clientes/{id} (comercial/admin). Deve rodar
+// na mesma transação que grava a negociação, antes do Save, para que o
+// cliente_id seja gravado junto.
+func VincularCliente(tx *gorm.DB, n *models.Negociacao) error {
+	cnpj := utils.NormalizarCNPJ(n.CNPJ)
+
+	var c models.Cliente
+	achou := false
+	if cnpj != "" {
+		err := tx.Where("cnpj_normalizado = ?", cnpj).First(&c).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		achou = err == nil
+	}
+	// cliente atual sem CNPJ (ou com o mesmo): mantém o vínculo
+	if !achou && n.ClienteID != nil {
+		err := tx.First(&c, *n.ClienteID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		achou = err == nil && (c.CNPJNormalizado == "" || c.CNPJNormalizado == cnpj)
+		if !achou {
+			c = models.Cliente{}
+		}
+	}
+
+	if !achou {
+		copiarParaCliente(&c, n)
+		if err := tx.Create(&c).Error; err != nil {
+			return err
+		}
+	}
+	n.ClienteID = &c.ID
+	return nil
+}
+
+// copiarParaCliente preenche o cadastro novo com os campos da negociação.
+func copiarParaCliente(c *models.Cliente, n *models.Negociacao) {
+	campos := []struct {
+		dst *string
+		src string
+	}{
+		{&c.Nome, n.Nome},
+		{&c.CNPJ, n.CNPJ},
+		{&c.Email, n.Email},
+		{&c.Contato, n.Contato},
+		{&c.NumeroDoContato, n.NumeroDoContato},
+		{&c.Telefone, n.Telefone},
+		{&c.UF, n.UF},
+	}
+	for _, f := range campos {
+		if v := strings.TrimSpace(f.src); v != "" {
+			*f.dst = v
+		}
+	}
+}
+
+// responderErroCliente devolve 404/400 para os erros de vínculo com o cliente.
+func responderErroCliente(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrClienteNaoEncontrado):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrCNPJDiferenteCliente):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Erro ao carregar cliente", http.StatusInternalServerError)
+	}
+}
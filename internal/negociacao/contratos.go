@@ -0,0 +1,48 @@
+// internal/negociacao/contratos.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListarContratos trata GET /negociacoes/{id}/contratos?tipo=&status=
+// Todos os contratos da negociação (gestão e energia), dentro do escopo do
+// usuário. Fica aqui porque o pacote contrato não enxerga o escopo.
+func (h *Handler) ListarContratos(w http.ResponseWriter, r *http.Request) {
+	negID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID de negociação inválido", http.StatusBadRequest)
+		return
+	}
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	var neg models.Negociacao
+	err = escopo.Aplicar(h.DB).Select("id").First(&neg, negID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
+		return
+	}
+
+	list, err := contrato.NewRepository().ListarPorNegociacao(h.DB, neg.ID, contrato.FiltroDaQuery(r.URL.Query()))
+	if err != nil {
+		http.Error(w, "Erro ao listar contratos", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
@@ -0,0 +1,160 @@
+// internal/negociacao/documentos.go
+package negociacao
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// As rotas abaixo são as antigas, uma por anexo. Continuam aceitando o mesmo
+// body e devolvendo a negociação, mas gravam em negociacao_documentos
+// (API genérica: /negociacoes/{id}/documentos/{tipo}).
+
+/* ================== PATCH /negociacoes/{id}/logo ================== */
+func (h *Handler) PatchLogo(w http.ResponseWriter, r *http.Request) {
+	h.patchDocumentoSimples(w, r, models.DocLogo)
+}
+
+func (h *Handler) PatchAnexoContratoSocial(w http.ResponseWriter, r *http.Request) {
+	h.patchDocumentoSimples(w, r, models.DocContratoSocial)
+}
+
+func (h *Handler) PatchAnexoProcuracao(w http.ResponseWriter, r *http.Request) {
+	h.patchDocumentoSimples(w, r, models.DocProcuracao)
+}
+
+func (h *Handler) PatchAnexoRepresentanteLegal(w http.ResponseWriter, r *http.Request) {
+	h.patchDocumentoSimples(w, r, models.DocRepresentanteLegal)
+}
+
+func (h *Handler) PatchAnexoEstudoDeViabilidade(w http.ResponseWriter, r *http.Request) {
+	h.patchDocumentoSimples(w, r, models.DocEstudoViabilidade)
+}
+
+// PatchAnexoEstudo trata PATCH /negociacoes/{id}/anexo-estudo
+func (h *Handler) PatchAnexoEstudo(w http.ResponseWriter, r *http.Request) {
+	var req PatchAnexoEstudoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if req.AnexoEstudo == "" {
+		http.Error(w, "O campo 'anexoEstudo' é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	// Estando "Em Estudo", o envio do estudo avança o pipeline, na mesma
+	// transação: se a transição falhar, o documento também não fica.
+	neg, ok := h.definirDocumento(w, r, models.DocEstudo, req.AnexoEstudo, models.StatusEnviado,
+		func(tx *gorm.DB, neg *models.Negociacao) error {
+			var atual models.Negociacao
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&atual, neg.ID).Error; err != nil {
+				return err
+			}
+			if atual.Status != models.StatusEmEstudo {
+				return nil
+			}
+			_, err := TransicionarStatus(tx, neg.ID, models.StatusEstudoFeito, auth.AtorDaRequisicao(r), "Anexo de estudo enviado")
+			return err
+		})
+	if !ok {
+		return
+	}
+	h.responderNegociacao(w, neg.ID)
+}
+
+// PatchContratoKC trata PATCH /negociacoes/{id}/contrato-kc
+// O envio não assina a negociação: "Contrato Assinado" exige o KC validado
+// e passa pela máquina de estados (PATCH /negociacoes/{id}/status).
+func (h *Handler) PatchContratoKC(w http.ResponseWriter, r *http.Request) {
+	var req PatchContratoKCRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if req.ContratoKC == "" {
+		http.Error(w, "O campo 'contratoKC' é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	neg, ok := h.definirDocumento(w, r, models.DocContratoKC, req.ContratoKC, models.StatusEnviado, nil)
+	if !ok {
+		return
+	}
+	h.responderNegociacao(w, neg.ID)
+}
+
+// patchDocumentoSimples atende o body { "url": "...", "status": "..." }.
+func (h *Handler) patchDocumentoSimples(w http.ResponseWriter, r *http.Request, codigo string) {
+	var req patchAnexoSimplesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	neg, ok := h.definirDocumento(w, r, codigo, req.URL, statusOrDefaultEnviado(req.Status), nil)
+	if !ok {
+		return
+	}
+	h.responderNegociacao(w, neg.ID)
+}
+
+// definirDocumento confere acesso à negociação {id} e grava o documento; em
+// caso de erro já responde. `depois`, se houver, roda na mesma transação
+// (uma transição de status; seus erros são os da máquina de estados).
+func (h *Handler) definirDocumento(w http.ResponseWriter, r *http.Request, codigo, url, status string, depois func(tx *gorm.DB, neg *models.Negociacao) error) (*models.Negociacao, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id", "status").First(&neg, id).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return nil, false
+	}
+	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
+	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
+	if !isAdmin && neg.ConsultorID != userID {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, false
+	}
+
+	var errDepois error
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := documento.Definir(tx, neg.ID, codigo, url, "", status, auth.AtorDaRequisicao(r)); err != nil {
+			return err
+		}
+		if depois != nil {
+			errDepois = depois(tx, &neg)
+		}
+		return errDepois
+	})
+	switch {
+	case errDepois != nil:
+		responderErroStatus(w, errDepois)
+		return nil, false
+	case err != nil:
+		documento.ResponderErro(w, err)
+		return nil, false
+	}
+	return &neg, true
+}
+
+func (h *Handler) responderNegociacao(w http.ResponseWriter, id uint) {
+	n, err := h.Repository.BuscarPorID(h.DB, id)
+	if err != nil {
+		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(n)
+}
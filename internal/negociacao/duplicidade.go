@@ -0,0 +1,337 @@
+// internal/negociacao/duplicidade.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Regras de posse do cliente quando dois consultores abrem o mesmo CNPJ
+// (env CNPJ_REGRA):
+//   - exclusividade (padrão): o primeiro consultor fica com o cliente por
+//     CNPJ_EXCLUSIVIDADE_DIAS; depois disso o segundo pode abrir, com revisão.
+//   - bloquear: o segundo consultor nunca consegue abrir.
+//   - revisao: os dois podem abrir; o conflito vai para a fila dos admins.
+const (
+	RegraExclusividade = "exclusividade"
+	RegraBloquear      = "bloquear"
+	RegraRevisao       = "revisao"
+)
+
+const diasExclusividadePadrao = 90
+
+// ErrCNPJBloqueado é devolvido quando a regra impede a negociação.
+type ErrCNPJBloqueado struct {
+	Ate *time.Time // fim da exclusividade; nil quando o bloqueio é permanente
+}
+
+func (e *ErrCNPJBloqueado) Error() string {
+	if e.Ate != nil {
+		return fmt.Sprintf("CNPJ já está em negociação com outro consultor (exclusividade até %s)", e.Ate.Format("02/01/2006"))
+	}
+	return "CNPJ já está em negociação com outro consultor"
+}
+
+// RegraCNPJ lê a regra vigente do ambiente.
+func RegraCNPJ() (regra string, dias int) {
+	regra = strings.ToLower(strings.TrimSpace(os.Getenv("CNPJ_REGRA")))
+	switch regra {
+	case RegraBloquear, RegraRevisao:
+	default:
+		regra = RegraExclusividade
+	}
+	dias = diasExclusividadePadrao
+	if v, err := strconv.Atoi(os.Getenv("CNPJ_EXCLUSIVIDADE_DIAS")); err == nil && v >= 0 {
+		dias = v
+	}
+	return regra, dias
+}
+
+// travarCNPJ serializa, até o fim da transação, quem verifica e grava
+// negociações do mesmo CNPJ normalizado. O lock é consultivo (e não de
+// linha) para cobrir também o CNPJ que ainda não tem nenhuma negociação.
+func travarCNPJ(tx *gorm.DB, cnpj string) error {
+	if cnpj == "" {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "cnpj:"+cnpj).Error
+}
+
+// buscarDonoCNPJ retorna a negociação ativa mais antiga do CNPJ que pertence
+// a outro consultor (ignorando `ignorarID`), travando a linha. Negociações
+// do mesmo consultor não contam como duplicidade.
+func buscarDonoCNPJ(db *gorm.DB, cnpj string, consultorID, ignorarID uint) (*models.Negociacao, error) {
+	if cnpj == "" {
+		return nil, nil
+	}
+	var n models.Negociacao
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "consultor_id", "created_at", "status").
+		Where("cnpj_normalizado = ? AND consultor_id <> ? AND id <> ?", cnpj, consultorID, ignorarID).
+		Where("status <> ?", models.StatusCancelada).
+		Order("created_at ASC").
+		First(&n).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// verificacaoCNPJ é o resultado de checar um CNPJ antes de gravar.
+type verificacaoCNPJ struct {
+	cnpj      string
+	existente *models.Negociacao // nil = sem duplicidade
+	regra     string
+	alerta    *notificacao.AlertaCNPJ // enviado por avisar, fora da transação
+}
+
+// avisar manda ao webhook o alerta da verificação, depois que a transação
+// terminou: o conflito só é avisado se foi gravado, e o bloqueio sempre
+// (err é o resultado da transação). Aceita verificação nil.
+func (v *verificacaoCNPJ) avisar(err error) {
+	if v == nil || v.alerta == nil {
+		return
+	}
+	var bloqueado *ErrCNPJBloqueado
+	if err != nil && !errors.As(err, &bloqueado) {
+		return
+	}
+	notificacao.EnviarWebhookAlerta(*v.alerta)
+}
+
+// verificarCNPJ aplica a regra de posse. Deve rodar na mesma transação que
+// grava a negociação: trava o CNPJ, e duas gravações simultâneas não passam
+// ambas pela checagem. Retorna *ErrCNPJBloqueado quando a gravação não deve
+// acontecer; caso contrário a verificação deve ser passada para
+// registrarConflitoCNPJ depois que a negociação tiver ID. Nos dois casos,
+// quem chamou termina com avisar, depois da transação.
+func verificarCNPJ(tx *gorm.DB, cnpjBruto string, consultorID, ignorarID uint) (*verificacaoCNPJ, error) {
+	regra, dias := RegraCNPJ()
+	v := &verificacaoCNPJ{cnpj: utils.NormalizarCNPJ(cnpjBruto), regra: regra}
+
+	if err := travarCNPJ(tx, v.cnpj); err != nil {
+		return v, err
+	}
+	existente, err := buscarDonoCNPJ(tx, v.cnpj, consultorID, ignorarID)
+	if err != nil || existente == nil {
+		return v, err
+	}
+	v.existente = existente
+
+	bloqueio := func(ate *time.Time) error {
+		v.alerta = &notificacao.AlertaCNPJ{
+			CNPJ:                  v.cnpj,
+			ConsultorID:           consultorID,
+			NegociacaoExistenteID: existente.ID,
+			ConsultorExistenteID:  existente.ConsultorID,
+			Acao:                  "bloqueada",
+		}
+		return &ErrCNPJBloqueado{Ate: ate}
+	}
+
+	switch regra {
+	case RegraBloquear:
+		return v, bloqueio(nil)
+	case RegraExclusividade:
+		ate := existente.CreatedAt.AddDate(0, 0, dias)
+		if time.Now().Before(ate) {
+			return v, bloqueio(&ate)
+		}
+	}
+	return v, nil
+}
+
+// registrarConflitoCNPJ coloca a duplicidade na fila de revisão e prepara o
+// alerta do webhook (enviado por avisar).
+func registrarConflitoCNPJ(db *gorm.DB, v *verificacaoCNPJ, n *models.Negociacao) error {
+	if v == nil || v.existente == nil {
+		return nil
+	}
+
+	// evita duplicar a fila quando a mesma negociação é salva várias vezes
+	var qtd int64
+	if err := db.Model(&models.ConflitoCNPJ{}).
+		Where("negociacao_id = ? AND negociacao_existente_id = ? AND status = ?", n.ID, v.existente.ID, models.ConflitoPendente).
+		Count(&qtd).Error; err != nil {
+		return err
+	}
+	if qtd > 0 {
+		return nil
+	}
+
+	c := models.ConflitoCNPJ{
+		CNPJ:                  v.cnpj,
+		NegociacaoID:          n.ID,
+		ConsultorID:           n.ConsultorID,
+		NegociacaoExistenteID: v.existente.ID,
+		ConsultorExistenteID:  v.existente.ConsultorID,
+		Regra:                 v.regra,
+		Status:                models.ConflitoPendente,
+	}
+	if err := db.Create(&c).Error; err != nil {
+		return err
+	}
+
+	v.alerta = &notificacao.AlertaCNPJ{
+		CNPJ:                  c.CNPJ,
+		NegociacaoID:          c.NegociacaoID,
+		ConsultorID:           c.ConsultorID,
+		NegociacaoExistenteID: c.NegociacaoExistenteID,
+		ConsultorExistenteID:  c.ConsultorExistenteID,
+		Acao:                  "em_revisao",
+	}
+	return nil
+}
+
+// responderErroCNPJ devolve 409 para bloqueio de CNPJ; false quando o erro
+// é outro e a resposta fica com quem chamou.
+func responderErroCNPJ(w http.ResponseWriter, err error) bool {
+	var bloqueado *ErrCNPJBloqueado
+	if !errors.As(err, &bloqueado) {
+		return false
+	}
+	http.Error(w, bloqueado.Error(), http.StatusConflict)
+	return true
+}
+
+var (
+	errConflitoInexistente = errors.New("conflito não encontrado")
+	errConflitoResolvido   = errors.New("conflito já resolvido")
+)
+
+/* ================== Fila de conflitos (admin) ================== */
+
+type resolverConflitoRequest struct {
+	Decisao    string `json:"decisao"` // "manter" | "cancelar"
+	Observacao string `json:"observacao"`
+}
+
+// ListarConflitosCNPJ trata GET /conflitos-cnpj?status=Pendente (somente admin)
+func (h *Handler) ListarConflitosCNPJ(w http.ResponseWriter, r *http.Request) {
+	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
+	if !isAdmin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ConflitoPendente
+	}
+
+	var list []models.ConflitoCNPJ
+	q := h.DB.Order("created_at ASC")
+	if status != "todos" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&list).Error; err != nil {
+		http.Error(w, "Erro ao listar conflitos", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// ResolverConflitoCNPJ trata POST /conflitos-cnpj/{id}/resolver (somente admin)
+// Body: { "decisao": "manter" | "cancelar", "observacao": "..." }
+// "cancelar" cancela a negociação mais recente pela máquina de estados.
+func (h *Handler) ResolverConflitoCNPJ(w http.ResponseWriter, r *http.Request) {
+	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
+	if !isAdmin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req resolverConflitoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Decisao = strings.ToLower(strings.TrimSpace(req.Decisao))
+	if req.Decisao != "manter" && req.Decisao != "cancelar" {
+		http.Error(w, "o campo 'decisao' deve ser 'manter' ou 'cancelar'", http.StatusBadRequest)
+		return
+	}
+
+	// conflito e CNPJ travados: duas resoluções simultâneas, ou uma
+	// resolução junto com uma nova gravação do CNPJ, não se cruzam
+	ator := auth.AtorDaRequisicao(r)
+	var c models.ConflitoCNPJ
+	var errStatus error
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&c, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errConflitoInexistente
+		}
+		if err != nil {
+			return err
+		}
+		if c.Status != models.ConflitoPendente {
+			return errConflitoResolvido
+		}
+		if err := travarCNPJ(tx, c.CNPJ); err != nil {
+			return err
+		}
+
+		if req.Decisao == "cancelar" {
+			motivo := "CNPJ já em negociação com outro consultor"
+			if obs := strings.TrimSpace(req.Observacao); obs != "" {
+				motivo += ": " + obs
+			}
+			if _, errStatus = TransicionarStatus(tx, c.NegociacaoID, models.StatusCancelada, ator, motivo); errStatus != nil {
+				return errStatus
+			}
+			c.Status = models.ConflitoCancelado
+		} else {
+			c.Status = models.ConflitoMantido
+		}
+
+		agora := time.Now()
+		c.Observacao = strings.TrimSpace(req.Observacao)
+		c.ResolvidoPorID = &ator.ID
+		c.ResolvidoEm = &agora
+		return tx.Save(&c).Error
+	})
+	switch {
+	case err == nil:
+	case errors.Is(err, errConflitoInexistente):
+		http.Error(w, "Conflito não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, errConflitoResolvido):
+		http.Error(w, "Conflito já resolvido", http.StatusConflict)
+		return
+	case errStatus != nil:
+		responderErroStatus(w, errStatus)
+		return
+	default:
+		http.Error(w, "Erro ao resolver conflito", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(c)
+}
@@ -0,0 +1,18 @@
+// internal/negociacao/escopo.go
+package negociacao
+
+import (
+	"net/http"
+
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"gorm.io/gorm"
+)
+
+// Escopo diz quais negociações o usuário da requisição pode ver (veja o
+// pacote escopo).
+type Escopo = escopo.Escopo
+
+// EscopoDaRequisicao monta o escopo do usuário da requisição.
+func EscopoDaRequisicao(db *gorm.DB, r *http.Request) (Escopo, error) {
+	return escopo.DaRequisicao(db, r)
+}
@@ -9,7 +9,11 @@ import (
 	"strings"
 
 	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
 	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
@@ -23,6 +27,7 @@ type patchFaturaItemRequest struct {
 }
 type patchFaturaStatusRequest struct {
 	Status string `json:"status"`
+	Motivo string `json:"motivo"` // obrigatório para "Rejeitado"
 }
 
 // AdicionarArquivosRequest representa o payload para adicionar arquivos
@@ -40,15 +45,17 @@ type PatchContratoKCRequest struct {
 	ContratoKC string `json:"contratoKC"`
 }
 
-// Handler encapsula DB e repository
+// Handler encapsula DB, repository e o notificador
 type Handler struct {
-	DB         *gorm.DB
-	Repository Repository
+	DB          *gorm.DB
+	Repository  Repository
+	Notificador notificacao.Notificador
 }
 
 // CORREÇÃO: Struct para o payload de atualização de status definida corretamente.
 type atualizarStatusRequest struct {
 	Status string `json:"status"`
+	Motivo string `json:"motivo"`
 }
 
 // se status não vier ou vier vazio, usamos "Enviado"
@@ -60,10 +67,11 @@ func statusOrDefaultEnviado(s *string) string {
 }
 
 // NewHandler cria um novo handler de negociações
-func NewHandler(db *gorm.DB) *Handler {
+func NewHandler(db *gorm.DB, notif notificacao.Notificador) *Handler {
 	return &Handler{
-		DB:         db,
-		Repository: NewRepository(),
+		DB:          db,
+		Repository:  NewRepository(),
+		Notificador: notif,
 	}
 }
 
@@ -75,6 +83,9 @@ type multiAnexoDTO struct {
 }
 
 type negociacaoCreateDTO struct {
+	// Cliente já cadastrado (opcional); sem ele o cliente é achado ou criado pelo CNPJ
+	ClienteID *uint `json:"clienteId"`
+
 	// Básico
 	Nome            string `json:"nome"`
 	Email           string `json:"email"`
@@ -83,26 +94,10 @@ type negociacaoCreateDTO struct {
 	Telefone        string `json:"telefone"`
 	CNPJ            string `json:"cnpj"`
 	UF              string `json:"uf"`
-	Status          string `json:"status"`
 	KromaTake       bool   `json:"kromaTake"`
 
-	// anexos simples + status (STRING)
-	Logo                      string `json:"logo"`
-	LogoStatus                string `json:"logoStatus"`
-	AnexoEstudo               string `json:"anexoEstudo"`
-	AnexoEstudoStatus         string `json:"anexoEstudoStatus"`
-	ContratoKC                string `json:"contratoKC"`
-	ContratoKCStatus          string `json:"contratoKCStatus"`
-	AnexoContratoSocial       string `json:"anexoContratoSocial"`
-	AnexoContratoSocialStatus string `json:"anexoContratoSocialStatus"`
-
-	// novos anexos + status (STRING)
-	AnexoProcuracao                string `json:"anexoProcuracao"`
-	AnexoProcuracaoStatus          string `json:"anexoProcuracaoStatus"`
-	AnexoRepresentanteLegal        string `json:"anexoRepresentanteLegal"`
-	AnexoRepresentanteLegalStatus  string `json:"anexoRepresentanteLegalStatus"`
-	AnexoEstudoDeViabilidade       string `json:"anexoEstudoDeViabilidade"`
-	AnexoEstudoDeViabilidadeStatus string `json:"anexoEstudoDeViabilidadeStatus"`
+	// Anexos no formato antigo (viram documentos, como no PUT)
+	anexosLegadosDTO
 
 	// Fatura
 	AnexoFatura multiAnexoDTO `json:"anexoFatura"`
@@ -121,10 +116,20 @@ type negociacaoUpdateDTO struct {
 	Telefone        string `json:"telefone"`
 	CNPJ            string `json:"cnpj"`
 	UF              string `json:"uf"`
-	Status          string `json:"status"`
 	KromaTake       bool   `json:"kromaTake"`
 
-	// anexos simples + status (STRING)
+	anexosLegadosDTO
+
+	// fatura
+	AnexoFatura multiAnexoDTO `json:"anexoFatura"`
+
+	// anexos soltos
+	Arquivos []string `json:"arquivos"`
+}
+
+// anexosLegadosDTO são os anexos + status (STRING) do formato antigo, aceitos
+// no POST e no PUT e gravados em negociacao_documentos.
+type anexosLegadosDTO struct {
 	Logo                      string `json:"logo"`
 	LogoStatus                string `json:"logoStatus"`
 	AnexoEstudo               string `json:"anexoEstudo"`
@@ -141,12 +146,33 @@ type negociacaoUpdateDTO struct {
 	AnexoRepresentanteLegalStatus  string `json:"anexoRepresentanteLegalStatus"`
 	AnexoEstudoDeViabilidade       string `json:"anexoEstudoDeViabilidade"`
 	AnexoEstudoDeViabilidadeStatus string `json:"anexoEstudoDeViabilidadeStatus"`
+}
 
-	// fatura
-	AnexoFatura multiAnexoDTO `json:"anexoFatura"`
-
-	// anexos soltos
-	Arquivos []string `json:"arquivos"`
+// gravar transforma os anexos preenchidos em documentos (vazios são
+// ignorados); sem status, o documento fica "Pendente".
+func (a anexosLegadosDTO) gravar(tx *gorm.DB, negociacaoID uint, ator auth.Ator) error {
+	legados := []struct{ codigo, url, status string }{
+		{models.DocLogo, a.Logo, a.LogoStatus},
+		{models.DocEstudo, a.AnexoEstudo, a.AnexoEstudoStatus},
+		{models.DocContratoKC, a.ContratoKC, a.ContratoKCStatus},
+		{models.DocContratoSocial, a.AnexoContratoSocial, a.AnexoContratoSocialStatus},
+		{models.DocProcuracao, a.AnexoProcuracao, a.AnexoProcuracaoStatus},
+		{models.DocRepresentanteLegal, a.AnexoRepresentanteLegal, a.AnexoRepresentanteLegalStatus},
+		{models.DocEstudoViabilidade, a.AnexoEstudoDeViabilidade, a.AnexoEstudoDeViabilidadeStatus},
+	}
+	for _, l := range legados {
+		if strings.TrimSpace(l.url) == "" {
+			continue
+		}
+		status := strings.TrimSpace(l.status)
+		if status == "" {
+			status = models.StatusPendente
+		}
+		if _, err := documento.Definir(tx, negociacaoID, l.codigo, l.url, "", status, ator); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Aceita tanto "anexoFatura": "https://..." quanto
@@ -194,11 +220,6 @@ func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
 		dto.Arquivos = []string{}
 	}
 
-	// Default de status, se não vier
-	if strings.TrimSpace(dto.Status) == "" {
-		dto.Status = "Pendente"
-	}
-
 	// Normaliza o anexoFatura vindo do DTO (já pode ter vindo como string -> itens[0])
 	anexoFatura := models.MultiAnexo{
 		Itens:  dto.AnexoFatura.Itens,
@@ -211,6 +232,10 @@ func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
 		// se veio só a URL em formato string, seta status default
 		anexoFatura.Status = "Pendente"
 	}
+	if statusDeRevisao(anexoFatura.Status) {
+		// validação/rejeição só pelo PATCH de status (comercial)
+		anexoFatura.Status = models.StatusEnviado
+	}
 
 	n := models.Negociacao{
 		Nome:            dto.Nome,
@@ -220,7 +245,8 @@ func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
 		Telefone:        dto.Telefone,
 		CNPJ:            dto.CNPJ,
 		UF:              dto.UF,
-		Status:          dto.Status,
+		Status:          models.StatusAberta, // toda negociação nasce no início do pipeline
+		Origem:          models.OrigemCadastro,
 		KromaTake:       dto.KromaTake,
 
 		// Fatura (objeto)
@@ -230,12 +256,43 @@ func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
 		Arquivos:    dto.Arquivos,
 		ConsultorID: consultorID,
 	}
+	if dto.ClienteID != nil {
+		if err := preencherDoCliente(h.DB, &n, *dto.ClienteID); err != nil {
+			responderErroCliente(w, err)
+			return
+		}
+	}
 
-	if err := h.Repository.Salvar(h.DB, &n); err != nil {
-		http.Error(w, "Erro ao salvar negociação", http.StatusInternalServerError)
+	var verificacao *verificacaoCNPJ
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// Duplicidade de CNPJ: conforme a regra, bloqueia ou manda para revisão
+		var err error
+		if verificacao, err = verificarCNPJ(tx, n.CNPJ, consultorID, 0); err != nil {
+			return err
+		}
+		if err := VincularCliente(tx, &n); err != nil {
+			return err
+		}
+		if err := h.Repository.Salvar(tx, &n); err != nil {
+			return err
+		}
+		if err := registrarHistorico(tx, n.ID, "", n.Status, auth.AtorDaRequisicao(r), "Negociação criada"); err != nil {
+			return err
+		}
+		if err := dto.anexosLegadosDTO.gravar(tx, n.ID, auth.AtorDaRequisicao(r)); err != nil {
+			return err
+		}
+		return registrarConflitoCNPJ(tx, verificacao, &n)
+	})
+	verificacao.avisar(err)
+	if err != nil {
+		if !responderErroCNPJ(w, err) {
+			http.Error(w, "Erro ao salvar negociação", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	utils.DefinirETag(w, n.Versao)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(n)
@@ -279,6 +336,14 @@ func (h *Handler) BuscarPorID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	itens, err := documento.Checklist(h.DB, n)
+	if err != nil {
+		http.Error(w, "Erro ao montar checklist de documentos", http.StatusInternalServerError)
+		return
+	}
+	n.Completude = documento.Completude(itens)
+
+	utils.DefinirETag(w, n.Versao)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(n)
 }
@@ -312,6 +377,10 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Acesso negado", http.StatusForbidden)
 		return
 	}
+	if err := utils.ConferirIfMatch(r, existing.Versao); err != nil {
+		utils.ResponderErroVersao(w, err)
+		return
+	}
 
 	// Decodifica JSON com o shape correto
 	var dto negociacaoUpdateDTO
@@ -320,14 +389,6 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Helpers de normalização
-	def := func(s string) string {
-		if strings.TrimSpace(s) == "" {
-			return models.StatusPendente
-		}
-		return strings.TrimSpace(s)
-	}
-
 	// Defaults de slices
 	if dto.Arquivos == nil {
 		dto.Arquivos = []string{}
@@ -351,46 +412,57 @@ func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
 	existing.Telefone = dto.Telefone
 	existing.CNPJ = dto.CNPJ
 	existing.UF = dto.UF
-	existing.Status = dto.Status
 	existing.KromaTake = dto.KromaTake
+	// Status não é alterado aqui: use PATCH /negociacoes/{id}/status
 
-	// Anexos simples + status (string) com defaults "Pendente" se vierem vazios
-	existing.Logo = dto.Logo
-	existing.LogoStatus = def(dto.LogoStatus)
-
-	existing.AnexoEstudo = dto.AnexoEstudo
-	existing.AnexoEstudoStatus = def(dto.AnexoEstudoStatus)
-
-	existing.ContratoKC = dto.ContratoKC
-	existing.ContratoKCStatus = def(dto.ContratoKCStatus)
-
-	existing.AnexoContratoSocial = dto.AnexoContratoSocial
-	existing.AnexoContratoSocialStatus = def(dto.AnexoContratoSocialStatus)
-
-	// Novos anexos + status
-	existing.AnexoProcuracao = dto.AnexoProcuracao
-	existing.AnexoProcuracaoStatus = def(dto.AnexoProcuracaoStatus)
-
-	existing.AnexoRepresentanteLegal = dto.AnexoRepresentanteLegal
-	existing.AnexoRepresentanteLegalStatus = def(dto.AnexoRepresentanteLegalStatus)
-
-	existing.AnexoEstudoDeViabilidade = dto.AnexoEstudoDeViabilidade
-	existing.AnexoEstudoDeViabilidadeStatus = def(dto.AnexoEstudoDeViabilidadeStatus)
-
-	// Fatura (objeto jsonb)
+	// Fatura (objeto jsonb); consultor não altera o resultado da revisão
+	if !isAdmin && (statusDeRevisao(anexoFatura.Status) || statusDeRevisao(existing.AnexoFatura.Status)) {
+		anexoFatura.Status = existing.AnexoFatura.Status
+		anexoFatura.Motivo = existing.AnexoFatura.Motivo
+	}
 	existing.AnexoFatura = anexoFatura
 
 	// Outros anexos soltos
 	existing.Arquivos = dto.Arquivos
 
-	// mantém o dono como quem está atualizando
-	existing.ConsultorID = consultorID
+	// O dono não muda aqui: use POST /negociacoes/{id}/transferir
 
-	if err := h.Repository.Atualizar(h.DB, &existing); err != nil {
-		http.Error(w, "Erro ao atualizar negociação", http.StatusInternalServerError)
+	ator := auth.AtorDaRequisicao(r)
+
+	var verificacao *verificacaoCNPJ
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// CNPJ alterado: mesma regra de duplicidade da criação
+		trocouCNPJ := utils.NormalizarCNPJ(existing.CNPJ) != existing.CNPJNormalizado
+		if trocouCNPJ {
+			var err error
+			if verificacao, err = verificarCNPJ(tx, existing.CNPJ, existing.ConsultorID, existing.ID); err != nil {
+				return err
+			}
+		}
+		// só liga ao cliente; o cadastro muda por PUT /clientes/{id}
+		if trocouCNPJ || existing.ClienteID == nil {
+			if err := VincularCliente(tx, &existing); err != nil {
+				return err
+			}
+		}
+		if err := h.Repository.AtualizarComVersao(tx, &existing); err != nil {
+			return err
+		}
+		// Anexos legados que vierem preenchidos viram documentos
+		if err := dto.anexosLegadosDTO.gravar(tx, existing.ID, ator); err != nil {
+			return err
+		}
+		return registrarConflitoCNPJ(tx, verificacao, &existing)
+	})
+	verificacao.avisar(err)
+	if err != nil {
+		if !responderErroCNPJ(w, err) && !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao atualizar negociação", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	utils.DefinirETag(w, existing.Versao)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(existing)
 }
@@ -458,11 +530,15 @@ func (h *Handler) AdicionarArquivos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	existente.Arquivos = append(existente.Arquivos, req.NovosArquivos...)
-	if err := h.Repository.Atualizar(h.DB, &existente); err != nil {
-		http.Error(w, "Erro ao salvar os novos arquivos", http.StatusInternalServerError)
+	campos := map[string]any{"arquivos": existente.Arquivos}
+	if err := h.Repository.AtualizarCampos(h.DB, &existente, campos); err != nil {
+		if !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao salvar os novos arquivos", http.StatusInternalServerError)
+		}
 		return
 	}
 
+	utils.DefinirETag(w, existente.Versao)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(existente)
 }
@@ -491,7 +567,7 @@ func (h *Handler) RemoverProduto(w http.ResponseWriter, r *http.Request) {
 	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
 
 	var existente models.Negociacao
-	if err := h.DB.First(&existente, id).Error; err != nil {
+	if err := h.DB.Preload("Produtos").First(&existente, id).Error; err != nil {
 		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
 		return
 	}
@@ -506,12 +582,24 @@ func (h *Handler) RemoverProduto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	existente.Produtos = append(existente.Produtos[:idx], existente.Produtos[idx+1:]...)
-	if err := h.Repository.Atualizar(h.DB, &existente); err != nil {
-		http.Error(w, "Erro ao remover produto", http.StatusInternalServerError)
+	// O produto é uma linha própria: apaga a linha e sobe a versão da
+	// negociação na mesma transação, para o índice valer sobre a versão lida.
+	removido := existente.Produtos[idx]
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := h.Repository.AtualizarCampos(tx, &existente, map[string]any{}); err != nil {
+			return err
+		}
+		return tx.Delete(&removido).Error
+	})
+	if err != nil {
+		if !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao remover produto", http.StatusInternalServerError)
+		}
 		return
 	}
+	existente.Produtos = append(existente.Produtos[:idx], existente.Produtos[idx+1:]...)
 
+	utils.DefinirETag(w, existente.Versao)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(existente)
 }
@@ -555,126 +643,22 @@ func (h *Handler) RemoverArquivo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	existente.Arquivos = append(existente.Arquivos[:idx], existente.Arquivos[idx+1:]...)
-	if err := h.Repository.Atualizar(h.DB, &existente); err != nil {
-		http.Error(w, "Erro ao remover arquivo", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(existente)
-}
-
-// PatchAnexoEstudo trata PATCH /negociacoes/{id}/anexo-estudo
-func (h *Handler) PatchAnexoEstudo(w http.ResponseWriter, r *http.Request) {
-	// 1. Extrair ID e validar permissões
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
-		return
-	}
-
-	// 2. Decodificar o corpo da requisição (payload)
-	var req PatchAnexoEstudoRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-	if req.AnexoEstudo == "" {
-		http.Error(w, "O campo 'anexoEstudo' é obrigatório", http.StatusBadRequest)
-		return
-	}
-
-	// 3. Buscar negociação para garantir que existe
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-			return
+	campos := map[string]any{"arquivos": existente.Arquivos}
+	if err := h.Repository.AtualizarCampos(h.DB, &existente, campos); err != nil {
+		if !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao remover arquivo", http.StatusInternalServerError)
 		}
-		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
-		return
-	}
-
-	// Permissão: admin ou dono
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	updates := map[string]interface{}{
-		"anexo_estudo": req.AnexoEstudo,
-		"status":       "Estudo Feito",
-	}
-
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar a negociação", http.StatusInternalServerError)
 		return
 	}
 
+	utils.DefinirETag(w, existente.Versao)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(neg)
-}
-
-// PatchContratoKC trata PATCH /negociacoes/{id}/contrato-kc
-func (h *Handler) PatchContratoKC(w http.ResponseWriter, r *http.Request) {
-	// 1. Extrair ID
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
-		return
-	}
-
-	// 2. Decodificar o corpo da requisição
-	var req PatchContratoKCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-	if req.ContratoKC == "" {
-		http.Error(w, "O campo 'contratoKC' é obrigatório", http.StatusBadRequest)
-		return
-	}
-
-	// 3. Buscar negociação
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
-		return
-	}
-
-	// Permissão: admin ou dono
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	updates := map[string]interface{}{
-		"contrato_kc": req.ContratoKC,
-		"status":      "Contrato Assinado",
-	}
-
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar a negociação", http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(neg)
+	_ = json.NewEncoder(w).Encode(existente)
 }
 
 // AtualizarStatus trata PATCH /negociacoes/{id}/status
+// Body: { "status": "Em Estudo", "motivo": "..." } — o motivo é obrigatório
+// para cancelar ou reabrir.
 func (h *Handler) AtualizarStatus(w http.ResponseWriter, r *http.Request) {
 	// 1) Pega o ID da URL
 	idStr := mux.Vars(r)["id"]
@@ -694,9 +678,6 @@ func (h *Handler) AtualizarStatus(w http.ResponseWriter, r *http.Request) {
 	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
 
 	// 3) Body
-	type atualizarStatusRequest struct {
-		Status string `json:"status"`
-	}
 	var payload atualizarStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "JSON inválido", http.StatusBadRequest)
@@ -708,8 +689,8 @@ func (h *Handler) AtualizarStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 4) Busca a negociação pra checar dono/permissão
-	neg, err := h.Repository.BuscarPorID(h.DB, uint(id))
-	if err != nil {
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id").First(&neg, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			http.Error(w, "negociação não encontrada", http.StatusNotFound)
 			return
@@ -722,227 +703,53 @@ func (h *Handler) AtualizarStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// (Opcional) validar status permitido — comente se não quiser travar
-	// allowed := map[string]bool{"Aberta":true,"Em Estudo":true,"Estudo Feito":true,"Contrato Enviado":true,"Contrato Assinado":true,"Fechada":true,"Cancelada":true}
-	// if !allowed[payload.Status] { http.Error(w, "status inválido", http.StatusBadRequest); return }
-
-	// 5) Atualiza no repositório
-	if err := h.Repository.AtualizarStatus(h.DB, uint(id), payload.Status); err != nil {
-		http.Error(w, "erro ao atualizar status", http.StatusInternalServerError)
+	// 5) Aplica a transição (valida pipeline, perfil e guardas; grava histórico)
+	atualizada, err := TransicionarStatus(h.DB, uint(id), payload.Status, auth.AtorDaRequisicao(r), payload.Motivo)
+	if err != nil {
+		responderErroStatus(w, err)
 		return
 	}
 
 	// 6) Retorna a negociação atualizada
-	neg.Status = payload.Status
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(neg)
-}
-
-/* ================== PATCH /negociacoes/{id}/logo ================== */
-func (h *Handler) PatchLogo(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
-	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
-		return
-	}
-
-	var req patchAnexoSimplesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-		return
-	}
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	status := statusOrDefaultEnviado(req.Status)
-	updates := map[string]any{
-		"logo":        req.URL,
-		"logo_status": status,
-	}
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar logo", http.StatusInternalServerError)
-		return
-	}
-	neg.Logo = req.URL
-	neg.LogoStatus = status
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(neg)
-}
-
-func (h *Handler) PatchAnexoContratoSocial(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
-	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
-		return
-	}
-
-	var req patchAnexoSimplesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-		return
-	}
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	status := statusOrDefaultEnviado(req.Status)
-	updates := map[string]any{
-		"anexo_contrato_social":        req.URL,
-		"anexo_contrato_social_status": status,
-	}
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar contrato social", http.StatusInternalServerError)
-		return
-	}
-	neg.AnexoContratoSocial = req.URL
-	neg.AnexoContratoSocialStatus = status
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(neg)
-}
-
-func (h *Handler) PatchAnexoProcuracao(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
-	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
-		return
-	}
-
-	var req patchAnexoSimplesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-		return
-	}
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	status := statusOrDefaultEnviado(req.Status)
-	updates := map[string]any{
-		"anexo_procuracao":        req.URL,
-		"anexo_procuracao_status": status,
-	}
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar procuração", http.StatusInternalServerError)
-		return
-	}
-	neg.AnexoProcuracao = req.URL
-	neg.AnexoProcuracaoStatus = status
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(neg)
+	_ = json.NewEncoder(w).Encode(atualizada)
 }
 
-func (h *Handler) PatchAnexoRepresentanteLegal(w http.ResponseWriter, r *http.Request) {
+// HistoricoStatus trata GET /negociacoes/{id}/status/historico
+// Retorna a etapa atual, as próximas etapas possíveis para quem consulta
+// e todas as transições registradas.
+func (h *Handler) HistoricoStatus(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
-		return
-	}
-
-	var req patchAnexoSimplesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
 		return
 	}
 
 	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+	if err := h.DB.Select("id", "consultor_id", "status").First(&neg, id).Error; err != nil {
+		http.Error(w, "negociação não encontrada", http.StatusNotFound)
 		return
 	}
 	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
 	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
 	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	status := statusOrDefaultEnviado(req.Status)
-	updates := map[string]any{
-		"anexo_representante_legal":        req.URL,
-		"anexo_representante_legal_status": status,
-	}
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar representante legal", http.StatusInternalServerError)
+		http.Error(w, "acesso negado", http.StatusForbidden)
 		return
 	}
-	neg.AnexoRepresentanteLegal = req.URL
-	neg.AnexoRepresentanteLegalStatus = status
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(neg)
-}
 
-func (h *Handler) PatchAnexoEstudoDeViabilidade(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	historico, err := h.Repository.ListarHistoricoStatus(h.DB, uint(id))
 	if err != nil {
-		http.Error(w, "ID inválido", http.StatusBadRequest)
+		http.Error(w, "erro ao buscar histórico", http.StatusInternalServerError)
 		return
 	}
 
-	var req patchAnexoSimplesRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "JSON inválido", http.StatusBadRequest)
-		return
-	}
-
-	var neg models.Negociacao
-	if err := h.DB.First(&neg, id).Error; err != nil {
-		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
-		return
-	}
-	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
-	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
-	if !isAdmin && neg.ConsultorID != userID {
-		http.Error(w, "Acesso negado", http.StatusForbidden)
-		return
-	}
-
-	status := statusOrDefaultEnviado(req.Status)
-	updates := map[string]any{
-		"anexo_estudo_de_viabilidade":        req.URL,
-		"anexo_estudo_de_viabilidade_status": status,
-	}
-	if err := h.DB.Model(&neg).Updates(updates).Error; err != nil {
-		http.Error(w, "Erro ao atualizar estudo de viabilidade", http.StatusInternalServerError)
-		return
-	}
-	neg.AnexoEstudoDeViabilidade = req.URL
-	neg.AnexoEstudoDeViabilidadeStatus = status
-
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(neg)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"statusAtual": neg.Status,
+		"proximos":    ProximosStatus(neg.Status, auth.AtorDaRequisicao(r)),
+		"historico":   historico,
+	})
 }
 
 func (h *Handler) PostFaturaItem(w http.ResponseWriter, r *http.Request) {
@@ -982,13 +789,30 @@ func (h *Handler) PostFaturaItem(w http.ResponseWriter, r *http.Request) {
 		neg.AnexoFatura.Itens = append(neg.AnexoFatura.Itens, req.URL)
 	}
 
-	// se sem status ou "Pendente", marca "Enviado"
+	// se sem status, "Pendente" ou "Rejeitado", marca "Enviado" (volta para revisão)
 	if strings.TrimSpace(neg.AnexoFatura.Status) == "" ||
-		strings.EqualFold(neg.AnexoFatura.Status, models.StatusPendente) {
+		strings.EqualFold(neg.AnexoFatura.Status, models.StatusPendente) ||
+		strings.EqualFold(neg.AnexoFatura.Status, models.StatusRejeitado) {
 		neg.AnexoFatura.Status = models.StatusEnviado
+		neg.AnexoFatura.Motivo = ""
 	}
 
-	if err := h.DB.Model(&neg).Update("anexo_fatura", neg.AnexoFatura).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&neg).Updates(map[string]any{"anexo_fatura": neg.AnexoFatura, "versao": gorm.Expr("versao + 1")}).Error; err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   neg.ID,
+			Tipo:           evento.DocumentoEnviado,
+			Descricao:      "Documento enviado: Fatura",
+			ReferenciaTipo: "fatura",
+			Dados:          map[string]any{"tipo": "fatura", "url": req.URL},
+		}.Por(auth.AtorDaRequisicao(r)))
+	})
+	if err != nil {
 		http.Error(w, "Erro ao adicionar item de fatura", http.StatusInternalServerError)
 		return
 	}
@@ -1027,7 +851,7 @@ func (h *Handler) DeleteFaturaItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	neg.AnexoFatura.Itens = append(neg.AnexoFatura.Itens[:idx], neg.AnexoFatura.Itens[idx+1:]...)
-	if err := h.DB.Model(&neg).Update("anexo_fatura", neg.AnexoFatura).Error; err != nil {
+	if err := h.DB.Model(&neg).Updates(map[string]any{"anexo_fatura": neg.AnexoFatura, "versao": gorm.Expr("versao + 1")}).Error; err != nil {
 		http.Error(w, "Erro ao remover item de fatura", http.StatusInternalServerError)
 		return
 	}
@@ -1061,8 +885,51 @@ func (h *Handler) PatchFaturaStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	neg.AnexoFatura.Status = strings.TrimSpace(req.Status)
-	if err := h.DB.Model(&neg).Update("anexo_fatura", neg.AnexoFatura).Error; err != nil {
+	// Validar/rejeitar é revisão: só o comercial, e rejeição exige motivo
+	status := strings.TrimSpace(req.Status)
+	motivo := ""
+	if statusDeRevisao(status) {
+		if !isAdmin {
+			http.Error(w, documento.ErrRevisaoSemPermissao.Error(), http.StatusForbidden)
+			return
+		}
+		status, motivo, err = documento.NormalizarRevisao(status, req.Motivo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	neg.AnexoFatura.Status = status
+	neg.AnexoFatura.Motivo = motivo
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&neg).Updates(map[string]any{"anexo_fatura": neg.AnexoFatura, "versao": gorm.Expr("versao + 1")}).Error; err != nil {
+			return err
+		}
+		if !statusDeRevisao(status) {
+			return nil
+		}
+		e := evento.Evento{
+			NegociacaoID:   neg.ID,
+			Tipo:           evento.DocumentoValidado,
+			Descricao:      "Documento validado: Fatura",
+			ReferenciaTipo: "fatura",
+			Dados:          map[string]any{"tipo": "fatura"},
+		}
+		if status == models.StatusRejeitado {
+			e.Tipo = evento.DocumentoRejeitado
+			e.Descricao = "Documento rejeitado: Fatura"
+			e.Dados["motivo"] = motivo
+		}
+		if err := evento.Registrar(tx, e.Por(auth.AtorDaRequisicao(r))); err != nil {
+			return err
+		}
+		if status != models.StatusRejeitado {
+			return nil
+		}
+		return documento.RegistrarRejeicao(tx, h.Notificador, &neg, "Fatura", motivo)
+	})
+	if err != nil {
 		http.Error(w, "Erro ao atualizar status da fatura", http.StatusInternalServerError)
 		return
 	}
@@ -1070,3 +937,8 @@ func (h *Handler) PatchFaturaStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(neg.AnexoFatura)
 }
+
+// statusDeRevisao diz se o status só pode ser dado pelo comercial.
+func statusDeRevisao(status string) bool {
+	return strings.EqualFold(status, models.StatusValidado) || strings.EqualFold(status, models.StatusRejeitado)
+}
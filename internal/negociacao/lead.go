@@ -0,0 +1,74 @@
+// internal/negociacao/lead.go
+package negociacao
+
+import (
+	"errors"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ResultadoLead diz o que aconteceu com um lead do link de indicação.
+type ResultadoLead struct {
+	Negociacao *models.Negociacao
+	Existente  bool // o consultor já tinha negociação ativa com o CNPJ; nada foi criado
+	EmRevisao  bool // CNPJ com outro consultor: criada e enviada para a fila de conflitos
+}
+
+// CriarLead grava o lead como negociação "Aberta" do consultor n.ConsultorID,
+// com as mesmas regras de duplicidade de CNPJ do cadastro manual. Devolve
+// *ErrCNPJBloqueado quando a regra impede a criação.
+func CriarLead(db *gorm.DB, n *models.Negociacao) (ResultadoLead, error) {
+	var res ResultadoLead
+	n.Status = models.StatusAberta
+	n.Origem = models.OrigemIndicacao
+	indicadoPor := n.ConsultorID
+	n.IndicadoPorID = &indicadoPor
+	if n.Arquivos == nil {
+		n.Arquivos = []string{}
+	}
+
+	var verificacao *verificacaoCNPJ
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// lead repetido para o mesmo consultor: reaproveita a negociação ativa
+		if cnpj := utils.NormalizarCNPJ(n.CNPJ); cnpj != "" {
+			if err := travarCNPJ(tx, cnpj); err != nil {
+				return err
+			}
+			var atual models.Negociacao
+			err := tx.Where("cnpj_normalizado = ? AND consultor_id = ? AND status <> ?", cnpj, n.ConsultorID, models.StatusCancelada).
+				Order("created_at ASC").
+				First(&atual).Error
+			if err == nil {
+				res.Negociacao, res.Existente = &atual, true
+				return nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		var err error
+		if verificacao, err = verificarCNPJ(tx, n.CNPJ, n.ConsultorID, 0); err != nil {
+			return err
+		}
+		if err := VincularCliente(tx, n); err != nil {
+			return err
+		}
+		if err := NewRepository().Salvar(tx, n); err != nil {
+			return err
+		}
+		if err := registrarHistorico(tx, n.ID, "", n.Status, auth.AtorSistemaPadrao(), "Lead recebido pelo link de indicação"); err != nil {
+			return err
+		}
+		return registrarConflitoCNPJ(tx, verificacao, n)
+	})
+	verificacao.avisar(err)
+	if err != nil || res.Existente {
+		return res, err
+	}
+	res.Negociacao, res.EmRevisao = n, verificacao.existente != nil
+	return res, nil
+}
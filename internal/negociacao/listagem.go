@@ -0,0 +1,310 @@
+// internal/negociacao/listagem.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/calculocomissao"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	produto "github.com/KromaEnergia/api-consultor/internal/produtos"
+	"gorm.io/gorm"
+)
+
+const (
+	porPaginaPadrao = 20
+	porPaginaMax    = 100
+	layoutData      = "2006-01-02"
+)
+
+// Associações que podem ser pedidas em ?include= na listagem.
+var includesValidos = map[string]string{
+	"contratos":   "Contratos",
+	"produtos":    "Produtos",
+	"comentarios": "Comentarios",
+	"documentos":  "Documentos",
+	"calculos":    "CalculosComissao.Parcelas",
+}
+
+// ResumoNegociacao é a projeção leve usada na listagem. As associações só
+// vêm quando pedidas em ?include=.
+type ResumoNegociacao struct {
+	ID               uint      `json:"id"`
+	Nome             string    `json:"nome"`
+	CNPJ             string    `json:"cnpj"`
+	Status           string    `json:"status"`
+	UF               string    `json:"uf"`
+	KromaTake        bool      `json:"kromaTake"`
+	ConsultorID      uint      `json:"consultorId"`
+	ClienteID        *uint     `json:"clienteId"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UltimaAtividade  time.Time `json:"ultimaAtividade"`  // última alteração, comentário, transição ou documento
+	ComissaoEstimada float64   `json:"comissaoEstimada"` // soma dos cálculos de comissão
+	Completude       int       `json:"completude"`
+	TarefasAtrasadas int64     `json:"tarefasAtrasadas"` // tarefas abertas já vencidas
+
+	Contratos        []contrato.Contrato               `json:"contratos,omitempty"`
+	Produtos         []produto.Produto                 `json:"produtos,omitempty"`
+	Comentarios      []models.Comentario               `json:"comentarios,omitempty"`
+	Documentos       []models.NegociacaoDocumento      `json:"documentos,omitempty"`
+	CalculosComissao []calculocomissao.CalculoComissao `json:"calculosComissao,omitempty"`
+}
+
+// FiltrosListagem recortam a listagem de negociações.
+type FiltrosListagem struct {
+	Status      []string
+	UF          string
+	KromaTake   *bool
+	De          *time.Time
+	Ate         *time.Time // inclusivo (dia inteiro)
+	ConsultorID uint
+	ClienteID   uint
+	Include     []string
+	Pagina      int
+	PorPagina   int
+}
+
+// PaginaNegociacoes é a resposta paginada da listagem.
+type PaginaNegociacoes struct {
+	Total       int64              `json:"total"`
+	Pagina      int                `json:"pagina"`
+	PorPagina   int                `json:"porPagina"`
+	Negociacoes []ResumoNegociacao `json:"negociacoes"`
+}
+
+// FiltrosListagemDaQuery lê ?status=A,B&uf=&kromaTake=&de=&ate=&consultorId=&clienteId=&include=&pagina=&porPagina=
+func FiltrosListagemDaQuery(q url.Values) (FiltrosListagem, error) {
+	f := FiltrosListagem{Pagina: 1, PorPagina: porPaginaPadrao}
+
+	for _, s := range strings.Split(q.Get("status"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			if !StatusValido(s) {
+				return f, errors.New("status inválido: " + s)
+			}
+			f.Status = append(f.Status, s)
+		}
+	}
+	f.UF = strings.ToUpper(strings.TrimSpace(q.Get("uf")))
+
+	if v := strings.TrimSpace(q.Get("kromaTake")); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, errors.New("kromaTake deve ser true ou false")
+		}
+		f.KromaTake = &b
+	}
+	for _, c := range []struct {
+		nome string
+		dst  **time.Time
+	}{{"de", &f.De}, {"ate", &f.Ate}} {
+		if v := strings.TrimSpace(q.Get(c.nome)); v != "" {
+			t, err := time.ParseInLocation(layoutData, v, time.Local)
+			if err != nil {
+				return f, errors.New("datas devem estar no formato AAAA-MM-DD")
+			}
+			*c.dst = &t
+		}
+	}
+	if v := strings.TrimSpace(q.Get("consultorId")); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return f, errors.New("consultorId inválido")
+		}
+		f.ConsultorID = uint(n)
+	}
+	if v := strings.TrimSpace(q.Get("clienteId")); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return f, errors.New("clienteId inválido")
+		}
+		f.ClienteID = uint(n)
+	}
+	for _, inc := range strings.Split(q.Get("include"), ",") {
+		if inc = strings.ToLower(strings.TrimSpace(inc)); inc == "" {
+			continue
+		}
+		if _, ok := includesValidos[inc]; !ok {
+			return f, errors.New("include inválido: " + inc)
+		}
+		f.Include = append(f.Include, inc)
+	}
+
+	if v, err := strconv.Atoi(q.Get("pagina")); err == nil && v > 0 {
+		f.Pagina = v
+	}
+	if v, err := strconv.Atoi(q.Get("porPagina")); err == nil && v > 0 {
+		f.PorPagina = min(v, porPaginaMax)
+	}
+	return f, nil
+}
+
+// ListarResumos devolve uma página de negociações do escopo, da atividade
+// mais recente para a mais antiga.
+func ListarResumos(db *gorm.DB, escopo Escopo, f FiltrosListagem) (*PaginaNegociacoes, error) {
+	cond, args := escopo.SQL("n")
+	q := db.Table("negociacaos AS n").Where("n.deleted_at IS NULL").Where(cond, args...)
+	if len(f.Status) > 0 {
+		q = q.Where("n.status IN ?", f.Status)
+	}
+	if f.UF != "" {
+		q = q.Where("UPPER(n.uf) = ?", f.UF)
+	}
+	if f.KromaTake != nil {
+		q = q.Where("n.kroma_take = ?", *f.KromaTake)
+	}
+	if f.De != nil {
+		q = q.Where("n.created_at >= ?", *f.De)
+	}
+	if f.Ate != nil {
+		q = q.Where("n.created_at < ?", f.Ate.AddDate(0, 0, 1))
+	}
+	if f.ConsultorID != 0 {
+		q = q.Where("n.consultor_id = ?", f.ConsultorID)
+	}
+	if f.ClienteID != 0 {
+		q = q.Where("n.cliente_id = ?", f.ClienteID)
+	}
+
+	out := &PaginaNegociacoes{Pagina: f.Pagina, PorPagina: f.PorPagina, Negociacoes: []ResumoNegociacao{}}
+	if err := q.Session(&gorm.Session{}).Count(&out.Total).Error; err != nil {
+		return nil, err
+	}
+
+	var linhas []struct {
+		ID               uint
+		Nome             string
+		CNPJ             string `gorm:"column:cnpj"`
+		Status           string
+		UF               string `gorm:"column:uf"`
+		KromaTake        bool
+		ConsultorID      uint
+		ClienteID        *uint
+		CreatedAt        time.Time
+		UltimaAtividade  time.Time
+		ComissaoEstimada float64
+		TarefasAtrasadas int64
+	}
+	err := q.Select(`n.id, n.nome, n.cnpj, n.status, n.uf, n.kroma_take, n.consultor_id, n.cliente_id, n.created_at,
+		GREATEST(n.updated_at,
+			(SELECT MAX(c.created_at) FROM comentarios c WHERE c.negociacao_id = n.id AND c.deleted_at IS NULL),
+			(SELECT MAX(h.created_at) FROM negociacao_status_historicos h WHERE h.negociacao_id = n.id),
+			(SELECT MAX(d.updated_at) FROM negociacao_documentos d WHERE d.negociacao_id = n.id)) AS ultima_atividade,
+		COALESCE((SELECT SUM(cc.total_receber) FROM calculo_comissaos cc
+			WHERE cc.negociacao_id = n.id AND cc.deleted_at IS NULL), 0) AS comissao_estimada,
+		(SELECT COUNT(*) FROM tarefas t WHERE t.negociacao_id = n.id AND t.deleted_at IS NULL
+			AND NOT t.concluida AND t.vencimento < NOW()) AS tarefas_atrasadas`).
+		Order("ultima_atividade DESC, n.id DESC").
+		Limit(f.PorPagina).
+		Offset((f.Pagina - 1) * f.PorPagina).
+		Scan(&linhas).Error
+	if err != nil || len(linhas) == 0 {
+		return out, err
+	}
+
+	ids := make([]uint, len(linhas))
+	for i, l := range linhas {
+		ids[i] = l.ID
+		out.Negociacoes = append(out.Negociacoes, ResumoNegociacao{
+			ID:               l.ID,
+			Nome:             l.Nome,
+			CNPJ:             l.CNPJ,
+			Status:           l.Status,
+			UF:               l.UF,
+			KromaTake:        l.KromaTake,
+			ConsultorID:      l.ConsultorID,
+			ClienteID:        l.ClienteID,
+			CreatedAt:        l.CreatedAt,
+			UltimaAtividade:  l.UltimaAtividade,
+			ComissaoEstimada: l.ComissaoEstimada,
+			TarefasAtrasadas: l.TarefasAtrasadas,
+		})
+	}
+
+	// Completude precisa dos produtos (tipos de documento por produto)
+	var prods []produto.Produto
+	if err := db.Where("negociacao_id IN ?", ids).Find(&prods).Error; err != nil {
+		return nil, err
+	}
+	negs := make([]models.Negociacao, len(linhas))
+	for i, l := range linhas {
+		negs[i].ID, negs[i].Status, negs[i].ClienteID = l.ID, l.Status, l.ClienteID
+		for _, p := range prods {
+			if p.NegociacaoID == l.ID {
+				negs[i].Produtos = append(negs[i].Produtos, p)
+			}
+		}
+	}
+	completude, err := documento.CompletudeEmLote(db, negs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range out.Negociacoes {
+		out.Negociacoes[i].Completude = completude[out.Negociacoes[i].ID]
+	}
+
+	if len(f.Include) > 0 {
+		if err := incluirAssociacoes(db, out.Negociacoes, ids, f.Include); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// incluirAssociacoes carrega as associações pedidas para as negociações da página.
+func incluirAssociacoes(db *gorm.DB, resumos []ResumoNegociacao, ids []uint, include []string) error {
+	q := db
+	for _, inc := range include {
+		q = q.Preload(includesValidos[inc])
+	}
+	var negs []models.Negociacao
+	if err := q.Where("id IN ?", ids).Find(&negs).Error; err != nil {
+		return err
+	}
+	porID := make(map[uint]*models.Negociacao, len(negs))
+	for i := range negs {
+		porID[negs[i].ID] = &negs[i]
+	}
+	for i := range resumos {
+		n := porID[resumos[i].ID]
+		if n == nil {
+			continue
+		}
+		resumos[i].Contratos = n.Contratos
+		resumos[i].Produtos = n.Produtos
+		resumos[i].Comentarios = n.Comentarios
+		resumos[i].Documentos = n.Documentos
+		resumos[i].CalculosComissao = n.CalculosComissao
+	}
+	return nil
+}
+
+// Listar trata GET /negociacoes
+// Filtros: ?status=A,B&uf=&kromaTake=&de=AAAA-MM-DD&ate=AAAA-MM-DD&consultorId=
+// Paginação: ?pagina=1&porPagina=20 — Associações: ?include=contratos,produtos,comentarios,documentos,calculos
+func (h *Handler) Listar(w http.ResponseWriter, r *http.Request) {
+	f, err := FiltrosListagemDaQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	pagina, err := ListarResumos(h.DB, escopo, f)
+	if err != nil {
+		http.Error(w, "Erro ao listar negociações", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pagina)
+}
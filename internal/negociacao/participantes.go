@@ -0,0 +1,238 @@
+// internal/negociacao/participantes.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type participanteRequest struct {
+	ConsultorID uint    `json:"consultorId"`
+	Percentual  float64 `json:"percentual"`
+}
+
+type definirParticipantesRequest struct {
+	Participantes []participanteRequest `json:"participantes"`
+}
+
+// validarParticipantes confere a divisão: consultores distintos, percentuais
+// positivos somando 100 e o dono da negociação entre eles.
+func validarParticipantes(donoID uint, ps []participanteRequest) error {
+	if len(ps) == 0 {
+		return nil
+	}
+	if len(ps) == 1 {
+		return errors.New("a divisão precisa de ao menos dois consultores")
+	}
+	vistos := map[uint]bool{}
+	var soma float64
+	for _, p := range ps {
+		switch {
+		case p.ConsultorID == 0:
+			return errors.New("'consultorId' é obrigatório em cada participante")
+		case vistos[p.ConsultorID]:
+			return fmt.Errorf("consultor %d repetido na divisão", p.ConsultorID)
+		case p.Percentual <= 0 || p.Percentual > 100:
+			return errors.New("cada percentual deve estar entre 0 e 100")
+		}
+		vistos[p.ConsultorID] = true
+		soma += p.Percentual
+	}
+	if math.Abs(soma-100) > 0.001 {
+		return fmt.Errorf("os percentuais devem somar 100 (somam %.2f)", soma)
+	}
+	if !vistos[donoID] {
+		return errors.New("o consultor responsável pela negociação deve participar da divisão")
+	}
+	return nil
+}
+
+// ListarParticipantes trata GET /negociacoes/{id}/participantes
+// Visível para quem vê a negociação e para os próprios participantes.
+func (h *Handler) ListarParticipantes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
+		return
+	}
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	cond, args := escopo.SQL("n")
+	if escopo.ConsultorID != 0 {
+		cond = "(" + cond + " OR EXISTS (SELECT 1 FROM negociacao_participantes p WHERE p.negociacao_id = n.id AND p.consultor_id = ?))"
+		args = append(args, escopo.ConsultorID)
+	}
+	var qtd int64
+	if err := h.DB.Table("negociacaos AS n").
+		Where("n.id = ? AND n.deleted_at IS NULL", id).
+		Where(cond, args...).
+		Count(&qtd).Error; err != nil {
+		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
+		return
+	}
+	if qtd == 0 {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+
+	list := []models.NegociacaoParticipante{}
+	if err := h.DB.Where("negociacao_id = ?", id).Order("percentual DESC, consultor_id ASC").Find(&list).Error; err != nil {
+		http.Error(w, "Erro ao listar participantes", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// DefinirParticipantes trata PUT /negociacoes/{id}/participantes (comercial/admin)
+// Body: { "participantes": [ { "consultorId": 3, "percentual": 60 }, { "consultorId": 8, "percentual": 40 } ] }
+// Lista vazia desfaz a divisão. Vale para os cálculos de comissão criados
+// depois; as parcelas já geradas não mudam.
+func (h *Handler) DefinirParticipantes(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+	if !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
+		return
+	}
+	var req definirParticipantesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	var neg models.Negociacao
+	if err := escopo.Aplicar(h.DB.Select("id", "nome", "consultor_id")).First(&neg, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao buscar negociação", http.StatusInternalServerError)
+		return
+	}
+	if err := validarParticipantes(neg.ConsultorID, req.Participantes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ids := make([]uint, len(req.Participantes))
+	for i, p := range req.Participantes {
+		ids[i] = p.ConsultorID
+	}
+	if len(ids) > 0 {
+		var existentes int64
+		if err := h.DB.Table("consultors").Where("id IN ? AND deleted_at IS NULL", ids).Count(&existentes).Error; err != nil {
+			http.Error(w, "Erro ao buscar consultores", http.StatusInternalServerError)
+			return
+		}
+		if int(existentes) != len(ids) {
+			http.Error(w, "Consultor participante não encontrado", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	list := make([]models.NegociacaoParticipante, len(req.Participantes))
+	for i, p := range req.Participantes {
+		list[i] = models.NegociacaoParticipante{NegociacaoID: neg.ID, ConsultorID: p.ConsultorID, Percentual: p.Percentual}
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Negociacao{}, neg.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("negociacao_id = ?", neg.ID).Delete(&models.NegociacaoParticipante{}).Error; err != nil {
+			return err
+		}
+		if len(list) > 0 {
+			if err := tx.Create(&list).Error; err != nil {
+				return err
+			}
+		}
+		divisao := make([]map[string]any, len(list))
+		for i, p := range list {
+			divisao[i] = map[string]any{"consultorId": p.ConsultorID, "percentual": p.Percentual}
+		}
+		descricao := "Divisão de comissão entre consultores definida"
+		if len(list) == 0 {
+			descricao = "Divisão de comissão entre consultores removida"
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID: neg.ID,
+			Tipo:         evento.DivisaoDefinida,
+			Descricao:    descricao,
+			Dados:        map[string]any{"participantes": divisao},
+		}.Por(ator))
+	})
+	if err != nil {
+		http.Error(w, "Erro ao salvar participantes", http.StatusInternalServerError)
+		return
+	}
+
+	if h.Notificador != nil {
+		for _, p := range list {
+			if p.ConsultorID == neg.ConsultorID {
+				continue
+			}
+			if err := h.Notificador.Notificar(notificacao.Notificacao{
+				Tipo:             "participacao_definida",
+				DestinatarioTipo: notificacao.DestinoConsultor,
+				DestinatarioID:   p.ConsultorID,
+				Titulo:           "Você participa de uma venda conjunta",
+				Mensagem:         fmt.Sprintf("%s: %.2f%% da comissão", neg.Nome, p.Percentual),
+				NegociacaoID:     neg.ID,
+				Dados:            map[string]any{"percentual": p.Percentual},
+			}); err != nil {
+				log.Printf("[negociacao] erro ao notificar participante %d: %v", p.ConsultorID, err)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// transferirParticipacao passa a fatia do dono anterior para o novo dono,
+// somando se o novo já participava da divisão.
+func transferirParticipacao(tx *gorm.DB, negID, de, para uint) error {
+	var anterior models.NegociacaoParticipante
+	err := tx.Where("negociacao_id = ? AND consultor_id = ?", negID, de).First(&anterior).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	res := tx.Model(&models.NegociacaoParticipante{}).
+		Where("negociacao_id = ? AND consultor_id = ?", negID, para).
+		Update("percentual", gorm.Expr("percentual + ?", anterior.Percentual))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		return tx.Delete(&anterior).Error
+	}
+	return tx.Model(&anterior).Update("consultor_id", para).Error
+}
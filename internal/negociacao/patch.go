@@ -0,0 +1,134 @@
+// internal/negociacao/patch.go
+package negociacao
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// negociacaoPatchDTO: só os campos presentes no JSON são alterados.
+// Status, documentos e fatura têm endpoints próprios.
+type negociacaoPatchDTO struct {
+	Nome            *string   `json:"nome"`
+	Email           *string   `json:"email"`
+	Contato         *string   `json:"contato"`
+	NumeroDoContato *string   `json:"numeroDoContato"`
+	Telefone        *string   `json:"telefone"`
+	CNPJ            *string   `json:"cnpj"`
+	UF              *string   `json:"uf"`
+	KromaTake       *bool     `json:"kromaTake"`
+	Arquivos        *[]string `json:"arquivos"`
+}
+
+// AtualizarParcial trata PATCH /negociacoes/{id} (exige If-Match)
+func (h *Handler) AtualizarParcial(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var existing models.Negociacao
+	if err := h.DB.First(&existing, id).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+	isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool)
+	userID, _ := r.Context().Value(auth.CtxUserID).(uint)
+	if !isAdmin && existing.ConsultorID != userID {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return
+	}
+	if err := utils.ConferirIfMatch(r, existing.Versao); err != nil {
+		utils.ResponderErroVersao(w, err)
+		return
+	}
+
+	var dto negociacaoPatchDTO
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&dto); err != nil {
+		http.Error(w, "JSON inválido ou campo não editável: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	campos := map[string]any{}
+	textos := []struct {
+		coluna string
+		valor  *string
+		dst    *string
+	}{
+		{"nome", dto.Nome, &existing.Nome},
+		{"email", dto.Email, &existing.Email},
+		{"contato", dto.Contato, &existing.Contato},
+		{"numero_do_contato", dto.NumeroDoContato, &existing.NumeroDoContato},
+		{"telefone", dto.Telefone, &existing.Telefone},
+		{"cnpj", dto.CNPJ, &existing.CNPJ},
+		{"uf", dto.UF, &existing.UF},
+	}
+	for _, t := range textos {
+		if t.valor != nil {
+			*t.dst = strings.TrimSpace(*t.valor)
+			campos[t.coluna] = *t.dst
+		}
+	}
+	if dto.KromaTake != nil {
+		existing.KromaTake = *dto.KromaTake
+		campos["kroma_take"] = existing.KromaTake
+	}
+	if dto.Arquivos != nil {
+		existing.Arquivos = *dto.Arquivos
+		if existing.Arquivos == nil {
+			existing.Arquivos = []string{}
+		}
+		campos["arquivos"] = existing.Arquivos
+	}
+	if len(campos) == 0 {
+		http.Error(w, "Nenhum campo para atualizar", http.StatusBadRequest)
+		return
+	}
+
+	var verificacao *verificacaoCNPJ
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// CNPJ alterado: mesma regra de duplicidade da criação
+		trocouCNPJ := dto.CNPJ != nil && utils.NormalizarCNPJ(existing.CNPJ) != existing.CNPJNormalizado
+		if trocouCNPJ {
+			var err error
+			if verificacao, err = verificarCNPJ(tx, existing.CNPJ, existing.ConsultorID, existing.ID); err != nil {
+				return err
+			}
+			existing.CNPJNormalizado = utils.NormalizarCNPJ(existing.CNPJ)
+			campos["cnpj_normalizado"] = existing.CNPJNormalizado
+		}
+		// só liga ao cliente; o cadastro muda por PUT /clientes/{id}
+		if trocouCNPJ || existing.ClienteID == nil {
+			if err := VincularCliente(tx, &existing); err != nil {
+				return err
+			}
+			campos["cliente_id"] = existing.ClienteID
+		}
+		if err := h.Repository.AtualizarCampos(tx, &existing, campos); err != nil {
+			return err
+		}
+		return registrarConflitoCNPJ(tx, verificacao, &existing)
+	})
+	verificacao.avisar(err)
+	if err != nil {
+		if !responderErroCNPJ(w, err) && !utils.ResponderErroVersao(w, err) {
+			http.Error(w, "Erro ao atualizar negociação", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	utils.DefinirETag(w, existing.Versao)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(existing)
+}
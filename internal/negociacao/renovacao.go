@@ -0,0 +1,213 @@
+// internal/negociacao/renovacao.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errContratoRenovado  = errors.New("este contrato já foi renovado")
+	errContratoCancelado = errors.New("contrato cancelado não pode ser renovado")
+	errPeriodoInvalido   = errors.New("o fim do suprimento deve ser posterior ao início")
+)
+
+type renovarRequest struct {
+	InicioSuprimento *time.Time `json:"inicioSuprimento"` // padrão: dia seguinte ao fim do atual
+	FimSuprimento    *time.Time `json:"fimSuprimento"`    // padrão: mesma duração do atual
+	Valor            *float64   `json:"valor"`            // padrão: valor do atual
+}
+
+// ResultadoRenovacao traz a negociação de renovação e o contrato sucessor.
+type ResultadoRenovacao struct {
+	Negociacao *models.Negociacao `json:"negociacao"`
+	Contrato   *contrato.Contrato `json:"contrato"`
+}
+
+// RenovarContrato trata POST /contratos/{id}/renovar (dono da negociação ou comercial do time)
+// Body opcional: { "inicioSuprimento": "...", "fimSuprimento": "...", "valor": 1000 }
+// Abre uma negociação de renovação com os dados do cliente e cria o contrato
+// sucessor "Em Renovação", ligado ao atual; o monitor o torna vigente depois
+// de assinado.
+func (h *Handler) RenovarContrato(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID do contrato inválido", http.StatusBadRequest)
+		return
+	}
+	var req renovarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if req.Valor != nil && *req.Valor < 0 {
+		http.Error(w, "o campo 'valor' não pode ser negativo", http.StatusBadRequest)
+		return
+	}
+
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	ator := auth.AtorDaRequisicao(r)
+
+	var res ResultadoRenovacao
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var atual contrato.Contrato
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&atual, id).Error; err != nil {
+			return err
+		}
+		var origem models.Negociacao
+		if err := escopo.Aplicar(tx).First(&origem, atual.NegociacaoID).Error; err != nil {
+			return err
+		}
+		switch {
+		case atual.RenovadoPorID != nil:
+			return errContratoRenovado
+		case atual.Status == contrato.StatusCancelado:
+			return errContratoCancelado
+		}
+
+		inicio, fim, valor := periodoRenovacao(atual, req)
+		if !fim.After(inicio) {
+			return errPeriodoInvalido
+		}
+
+		neg := models.Negociacao{
+			ClienteID:       origem.ClienteID,
+			Nome:            origem.Nome,
+			Email:           origem.Email,
+			Contato:         origem.Contato,
+			NumeroDoContato: origem.NumeroDoContato,
+			Telefone:        origem.Telefone,
+			CNPJ:            origem.CNPJ,
+			UF:              origem.UF,
+			KromaTake:       origem.KromaTake,
+			Status:          models.StatusAberta,
+			Origem:          models.OrigemRenovacao,
+			ConsultorID:     origem.ConsultorID,
+			Arquivos:        []string{},
+		}
+		if err := NewRepository().Salvar(tx, &neg); err != nil {
+			return err
+		}
+		motivo := fmt.Sprintf("Renovação do contrato #%d", atual.ID)
+		if err := registrarHistorico(tx, neg.ID, "", neg.Status, ator, motivo); err != nil {
+			return err
+		}
+		// a venda conjunta continua na renovação
+		if err := tx.Exec(`
+			INSERT INTO negociacao_participantes (negociacao_id, consultor_id, percentual, created_at, updated_at)
+			SELECT ?, consultor_id, percentual, NOW(), NOW()
+			FROM negociacao_participantes WHERE negociacao_id = ?`, neg.ID, origem.ID).Error; err != nil {
+			return err
+		}
+
+		novo := contrato.Contrato{
+			NegociacaoID:     neg.ID,
+			ConsultorID:      neg.ConsultorID,
+			Tipo:             atual.Tipo,
+			Valor:            valor,
+			InicioSuprimento: inicio,
+			FimSuprimento:    fim,
+			ValorIntegral:    atual.ValorIntegral,
+			Status:           contrato.StatusEmRenovacao,
+			Fee:              atual.Fee,
+			FeePercent:       atual.FeePercent,
+			UniPay:           atual.UniPay,
+			UniPayPercent:    atual.UniPayPercent,
+			MonPay:           atual.MonPay,
+			MonthPay:         atual.MonthPay,
+			RenovaContratoID: &atual.ID,
+		}
+		if err := tx.Create(&novo).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&atual).Updates(map[string]any{
+			"renovado_por_id": novo.ID,
+			"versao":          gorm.Expr("versao + 1"),
+		}).Error; err != nil {
+			return err
+		}
+
+		dados := map[string]any{
+			"contratoAnterior": atual.ID, "contratoNovo": novo.ID,
+			"negociacaoAnterior": origem.ID, "negociacaoNova": neg.ID,
+			"inicioSuprimento": inicio, "fimSuprimento": fim, "valor": valor,
+		}
+		if err := evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   origem.ID,
+			Tipo:           evento.ContratoRenovado,
+			Descricao:      fmt.Sprintf("Contrato #%d renovado pela negociação #%d", atual.ID, neg.ID),
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   atual.ID,
+			Dados:          dados,
+		}.Por(ator)); err != nil {
+			return err
+		}
+		if err := evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   neg.ID,
+			Tipo:           evento.ContratoRenovado,
+			Descricao:      motivo,
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   novo.ID,
+			Dados:          dados,
+		}.Por(ator)); err != nil {
+			return err
+		}
+
+		res = ResultadoRenovacao{Negociacao: &neg, Contrato: &novo}
+		return nil
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, errContratoRenovado):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, errContratoCancelado), errors.Is(err, errPeriodoInvalido):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao renovar contrato", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// periodoRenovacao completa o pedido com os padrões: começa no dia seguinte
+// ao fim do contrato atual, mantém a duração e o valor.
+func periodoRenovacao(atual contrato.Contrato, req renovarRequest) (inicio, fim time.Time, valor float64) {
+	inicio = atual.FimSuprimento.AddDate(0, 0, 1)
+	if req.InicioSuprimento != nil {
+		inicio = *req.InicioSuprimento
+	}
+	if req.FimSuprimento != nil {
+		fim = *req.FimSuprimento
+	} else if atual.FimSuprimento.After(atual.InicioSuprimento) {
+		fim = inicio.Add(atual.FimSuprimento.Sub(atual.InicioSuprimento))
+	}
+	valor = atual.Valor
+	if req.Valor != nil {
+		valor = *req.Valor
+	}
+	return inicio, fim, valor
+}
@@ -3,7 +3,9 @@ package negociacao
 
 import (
 	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository define operações de persistência para models.Negociacao
@@ -11,11 +13,11 @@ type Repository interface {
 	Salvar(db *gorm.DB, n *models.Negociacao) error
 	ListarPorConsultor(db *gorm.DB, consultorID uint) ([]models.Negociacao, error)
 	BuscarPorID(db *gorm.DB, id uint) (*models.Negociacao, error)
-	Atualizar(db *gorm.DB, n *models.Negociacao) error
+	AtualizarComVersao(db *gorm.DB, n *models.Negociacao) error
+	AtualizarCampos(db *gorm.DB, n *models.Negociacao, campos map[string]any) error
 	Deletar(db *gorm.DB, id uint) error
 	AtualizarStatus(db *gorm.DB, id uint, status string) error
-
-	// AtualizarStatus(db *gorm.DB, id uint, status string) error
+	ListarHistoricoStatus(db *gorm.DB, negociacaoID uint) ([]models.NegociacaoStatusHistorico, error)
 }
 
 // repositoryImpl implementa Repository
@@ -39,6 +41,7 @@ func (r *repositoryImpl) ListarPorConsultor(db *gorm.DB, consultorID uint) ([]mo
 		Preload("Contratos").
 		Preload("Produtos").
 		Preload("Comentarios").
+		Preload("Documentos").
 		Preload("CalculosComissao").
 		Preload("CalculosComissao.Parcelas").
 		Find(&list).Error
@@ -52,6 +55,7 @@ func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*models.Negociacao,
 		Preload("Contratos").
 		Preload("Produtos").
 		Preload("Comentarios").
+		Preload("Documentos").
 		Preload("CalculosComissao").
 		Preload("CalculosComissao.Parcelas").
 		First(&n, id).Error
@@ -61,9 +65,36 @@ func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*models.Negociacao,
 	return &n, nil
 }
 
-// Atualizar salva alterações em uma negociação existente
-func (r *repositoryImpl) Atualizar(db *gorm.DB, n *models.Negociacao) error {
-	return db.Save(n).Error
+// AtualizarComVersao grava todos os campos só se a versão no banco ainda for
+// n.Versao; caso contrário devolve utils.ErrVersaoConflito.
+func (r *repositoryImpl) AtualizarComVersao(db *gorm.DB, n *models.Negociacao) error {
+	lida := n.Versao
+	n.Versao = lida + 1
+	res := db.Model(n).Where("versao = ?", lida).Select("*").Omit(clause.Associations).Updates(n)
+	if res.Error != nil {
+		n.Versao = lida
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		n.Versao = lida
+		return utils.ErrVersaoConflito
+	}
+	return nil
+}
+
+// AtualizarCampos grava só as colunas informadas, com a mesma checagem de
+// versão de AtualizarComVersao.
+func (r *repositoryImpl) AtualizarCampos(db *gorm.DB, n *models.Negociacao, campos map[string]any) error {
+	campos["versao"] = n.Versao + 1
+	res := db.Model(&models.Negociacao{}).Where("id = ? AND versao = ?", n.ID, n.Versao).Updates(campos)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return utils.ErrVersaoConflito
+	}
+	n.Versao++
+	return nil
 }
 
 // Deletar remove negociação do banco
@@ -74,7 +105,8 @@ func (r *repositoryImpl) Deletar(db *gorm.DB, id uint) error {
 // AtualizarStatus atualiza apenas o campo de status de uma negociação específica.
 func (r *repositoryImpl) AtualizarStatus(db *gorm.DB, id uint, status string) error {
 	// A operação de atualização é feita aqui
-	result := db.Model(&models.Negociacao{}).Where("id = ?", id).Update("status", status)
+	result := db.Model(&models.Negociacao{}).Where("id = ?", id).
+		Updates(map[string]any{"status": status, "versao": gorm.Expr("versao + 1")})
 
 	// O erro da operação é verificado primeiro
 	if result.Error != nil {
@@ -89,3 +121,13 @@ func (r *repositoryImpl) AtualizarStatus(db *gorm.DB, id uint, status string) er
 
 	return nil
 }
+
+// ListarHistoricoStatus retorna as transições de uma negociação, da mais antiga para a mais recente.
+func (r *repositoryImpl) ListarHistoricoStatus(db *gorm.DB, negociacaoID uint) ([]models.NegociacaoStatusHistorico, error) {
+	var list []models.NegociacaoStatusHistorico
+	err := db.
+		Where("negociacao_id = ?", negociacaoID).
+		Order("created_at ASC, id ASC").
+		Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,306 @@
+// internal/negociacao/status.go
+package negociacao
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStatusInvalido        = errors.New("status inválido")
+	ErrTransicaoNaoPermitida = errors.New("transição de status não permitida")
+	ErrTransicaoSemPermissao = errors.New("perfil sem permissão para esta transição")
+	ErrMotivoObrigatorio     = errors.New("o campo 'motivo' é obrigatório para esta transição")
+)
+
+// ErrGuarda indica que a transição existe, mas a negociação ainda não
+// cumpre os pré-requisitos (ex.: contrato não cadastrado).
+type ErrGuarda struct {
+	Motivo string
+}
+
+func (e *ErrGuarda) Error() string { return e.Motivo }
+
+// transicao descreve uma aresta do pipeline.
+type transicao struct {
+	Para             string
+	SomenteComercial bool // consultor não pode executar
+	ExigeMotivo      bool
+	Guarda           func(db *gorm.DB, n *models.Negociacao) error
+}
+
+// pipeline: Aberta → Em Estudo → Estudo Feito → Contrato Enviado → Contrato Assinado → Fechada,
+// com retorno de uma etapa e cancelamento a partir de qualquer etapa não final.
+var transicoes = map[string][]transicao{
+	models.StatusAberta: {
+		{Para: models.StatusEmEstudo},
+		{Para: models.StatusCancelada, ExigeMotivo: true},
+	},
+	models.StatusEmEstudo: {
+		{Para: models.StatusEstudoFeito, Guarda: guardaEstudoAnexado},
+		{Para: models.StatusAberta},
+		{Para: models.StatusCancelada, ExigeMotivo: true},
+	},
+	models.StatusEstudoFeito: {
+		{Para: models.StatusContratoEnviado},
+		{Para: models.StatusEmEstudo},
+		{Para: models.StatusCancelada, ExigeMotivo: true},
+	},
+	models.StatusContratoEnviado: {
+		{Para: models.StatusContratoAssinado, SomenteComercial: true, Guarda: guardaContratoAssinado},
+		{Para: models.StatusEstudoFeito},
+		{Para: models.StatusCancelada, ExigeMotivo: true},
+	},
+	models.StatusContratoAssinado: {
+		{Para: models.StatusFechada, SomenteComercial: true},
+		{Para: models.StatusCancelada, SomenteComercial: true, ExigeMotivo: true},
+	},
+	models.StatusFechada: {},
+	models.StatusCancelada: {
+		{Para: models.StatusAberta, SomenteComercial: true, ExigeMotivo: true},
+	},
+}
+
+// StatusValido diz se o texto é uma etapa conhecida do pipeline.
+func StatusValido(s string) bool {
+	_, ok := transicoes[s]
+	return ok
+}
+
+// ProximosStatus lista as etapas que o ator pode escolher a partir de `atual`
+// (sem avaliar guardas).
+func ProximosStatus(atual string, ator auth.Ator) []string {
+	out := []string{}
+	for _, t := range transicoes[atual] {
+		if t.SomenteComercial && ator.Tipo == auth.AtorConsultor && !ator.EhComercial() {
+			continue
+		}
+		out = append(out, t.Para)
+	}
+	return out
+}
+
+func buscarTransicao(de, para string) (transicao, bool) {
+	for _, t := range transicoes[de] {
+		if t.Para == para {
+			return t, true
+		}
+	}
+	return transicao{}, false
+}
+
+func guardaEstudoAnexado(db *gorm.DB, n *models.Negociacao) error {
+	ok, err := documento.Enviado(db, n.ID, models.DocEstudo, models.DocEstudoViabilidade)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// um estudo de economia salvo pela simulação também vale
+		if err := db.Raw("SELECT EXISTS (SELECT 1 FROM estudos WHERE negociacao_id = ?)", n.ID).Scan(&ok).Error; err != nil {
+			return err
+		}
+	}
+	if !ok {
+		return &ErrGuarda{Motivo: "anexe ou gere o estudo antes de marcar como 'Estudo Feito'"}
+	}
+	return nil
+}
+
+func guardaContratoAssinado(db *gorm.DB, n *models.Negociacao) error {
+	var qtd int64
+	if err := db.Model(&contrato.Contrato{}).Where("negociacao_id = ?", n.ID).Count(&qtd).Error; err != nil {
+		return err
+	}
+	if qtd == 0 {
+		return &ErrGuarda{Motivo: "cadastre o contrato da negociação antes de marcá-la como assinada"}
+	}
+	kc, err := documento.Buscar(db, n.ID, models.DocContratoKC)
+	if err != nil {
+		return err
+	}
+	if kc == nil || kc.Status != models.StatusValidado {
+		return &ErrGuarda{Motivo: "o Contrato KC precisa estar validado"}
+	}
+	return nil
+}
+
+// TransicionarStatus aplica uma mudança de etapa validando a máquina de
+// estados, o perfil do ator e as guardas, e grava o histórico na mesma
+// transação. Use esta função em vez de atualizar a coluna diretamente.
+func TransicionarStatus(db *gorm.DB, negID uint, para string, ator auth.Ator, motivo string) (*models.Negociacao, error) {
+	para = strings.TrimSpace(para)
+	motivo = strings.TrimSpace(motivo)
+	if !StatusValido(para) {
+		return nil, ErrStatusInvalido
+	}
+
+	var out models.Negociacao
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&out, negID).Error; err != nil {
+			return err
+		}
+
+		t, ok := buscarTransicao(out.Status, para)
+		if !ok {
+			return fmt.Errorf("%w: de '%s' para '%s'", ErrTransicaoNaoPermitida, out.Status, para)
+		}
+		if t.SomenteComercial && ator.Tipo == auth.AtorConsultor && !ator.EhComercial() {
+			return ErrTransicaoSemPermissao
+		}
+		if t.ExigeMotivo && motivo == "" {
+			return ErrMotivoObrigatorio
+		}
+		if t.Guarda != nil {
+			if err := t.Guarda(tx, &out); err != nil {
+				return err
+			}
+		}
+
+		anterior := out.Status
+		agora := time.Now()
+		if err := tx.Model(&out).Updates(map[string]any{
+			"status":         para,
+			"versao":         gorm.Expr("versao + 1"),
+			"status_desde":   agora,
+			"em_risco_desde": nil,
+		}).Error; err != nil {
+			return err
+		}
+		out.Status = para
+		out.Versao++
+		out.StatusDesde, out.EmRiscoDesde = &agora, nil
+		return registrarHistorico(tx, negID, anterior, para, ator, motivo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// responderErroStatus traduz os erros da máquina de estados para HTTP.
+func responderErroStatus(w http.ResponseWriter, err error) {
+	var guarda *ErrGuarda
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "negociação não encontrada", http.StatusNotFound)
+	case errors.Is(err, ErrStatusInvalido), errors.Is(err, ErrMotivoObrigatorio):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrTransicaoSemPermissao):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, ErrTransicaoNaoPermitida):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.As(err, &guarda):
+		http.Error(w, guarda.Motivo, http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, "erro ao atualizar status", http.StatusInternalServerError)
+	}
+}
+
+func registrarHistorico(db *gorm.DB, negID uint, de, para string, ator auth.Ator, motivo string) error {
+	h := models.NegociacaoStatusHistorico{
+		NegociacaoID:   negID,
+		StatusAnterior: de,
+		StatusNovo:     para,
+		AtorTipo:       ator.Tipo,
+		Motivo:         motivo,
+	}
+	if ator.ID != 0 {
+		id := ator.ID
+		h.AtorID = &id
+	}
+	if err := db.Create(&h).Error; err != nil {
+		return err
+	}
+
+	e := evento.Evento{
+		NegociacaoID:   negID,
+		Tipo:           evento.StatusAlterado,
+		Descricao:      fmt.Sprintf("Status alterado de %s para %s", de, para),
+		ReferenciaTipo: "status_historico",
+		ReferenciaID:   h.ID,
+		Dados:          map[string]any{"de": de, "para": para, "motivo": motivo},
+	}
+	if de == "" {
+		e.Tipo = evento.NegociacaoCriada
+		e.Descricao = "Negociação criada em " + para
+	}
+	return evento.Registrar(db, e.Por(ator))
+}
+
+// statusLegados leva os status anteriores ao pipeline (em minúsculas) para
+// a etapa equivalente. As etapas atuais também entram, para corrigir
+// diferenças de caixa e espaços.
+var statusLegados = map[string]string{
+	"":            models.StatusAberta,
+	"pendente":    models.StatusAberta,
+	"ativa":       models.StatusFechada,
+	"vigente":     models.StatusFechada,
+	"em execução": models.StatusFechada,
+}
+
+func init() {
+	for _, e := range append(append([]string{}, models.EtapasPipeline...), models.StatusCancelada) {
+		statusLegados[strings.ToLower(e)] = e
+	}
+}
+
+// Migrate ajusta dados legados: status fora do pipeline vão para a etapa
+// equivalente (um status sem equivalente interrompe a migração), o CNPJ
+// normalizado é preenchido para as negociações antigas e o início da etapa
+// atual vem da última transição do histórico (ou da última alteração).
+func Migrate(db *gorm.DB) error {
+	if err := migrarStatus(db); err != nil {
+		return err
+	}
+	if err := db.Model(&models.Negociacao{}).
+		Where("cnpj_normalizado IS NULL OR cnpj_normalizado = ''").
+		Where("cnpj IS NOT NULL AND cnpj <> ''").
+		UpdateColumn("cnpj_normalizado", gorm.Expr("regexp_replace(cnpj, '[^0-9]', '', 'g')")).Error; err != nil {
+		return err
+	}
+	return db.Exec(`
+		UPDATE negociacaos n SET status_desde = COALESCE(
+			(SELECT MAX(h.created_at) FROM negociacao_status_historicos h
+				WHERE h.negociacao_id = n.id AND h.status_novo = n.status),
+			n.updated_at, n.created_at)
+		WHERE n.status_desde IS NULL`).Error
+}
+
+// migrarStatus aplica statusLegados e lista os status que ficaram sem etapa.
+func migrarStatus(db *gorm.DB) error {
+	validos := append(append([]string{}, models.EtapasPipeline...), models.StatusCancelada)
+	var foraDoPipeline []string
+	if err := db.Unscoped().Model(&models.Negociacao{}).
+		Where("status IS NULL OR status NOT IN ?", validos).
+		Distinct().Pluck("COALESCE(status, '')", &foraDoPipeline).Error; err != nil {
+		return err
+	}
+	var desconhecidos []string
+	for _, atual := range foraDoPipeline {
+		etapa, ok := statusLegados[strings.ToLower(strings.TrimSpace(atual))]
+		if !ok {
+			desconhecidos = append(desconhecidos, fmt.Sprintf("%q", atual))
+			continue
+		}
+		if err := db.Unscoped().Model(&models.Negociacao{}).
+			Where("COALESCE(status, '') = ?", atual).
+			UpdateColumn("status", etapa).Error; err != nil {
+			return err
+		}
+	}
+	if len(desconhecidos) > 0 {
+		return fmt.Errorf("status de negociação sem etapa no pipeline: %s", strings.Join(desconhecidos, ", "))
+	}
+	return nil
+}
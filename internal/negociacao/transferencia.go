@@ -0,0 +1,289 @@
+// internal/negociacao/transferencia.go
+package negociacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/utils"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// O que acontece com as parcelas em aberto na transferência
+const (
+	ComissaoMover   = "mover"   // seguem a negociação para o novo consultor
+	ComissaoDividir = "dividir" // cada parcela vira duas, na proporção informada
+)
+
+// Parcelas que ficam com o consultor anterior em qualquer modo: as pagas, as
+// canceladas e as com NF já enviada (a nota foi emitida por ele).
+var statusParcelaFixos = []string{"Pago", "NF Enviada", "Cancelada"}
+
+var errMesmoConsultor = errors.New("a negociação já é deste consultor")
+
+type transferirRequest struct {
+	ConsultorID    uint    `json:"consultorId"`
+	Motivo         string  `json:"motivo"`
+	Comissao       string  `json:"comissao"`       // "mover" (padrão) | "dividir"
+	PercentualNovo float64 `json:"percentualNovo"` // "dividir": % de cada parcela para o novo consultor
+}
+
+// ResultadoTransferencia resume a transferência e o destino das parcelas.
+type ResultadoTransferencia struct {
+	Negociacao        *models.Negociacao `json:"negociacao"`
+	De                uint               `json:"de"`
+	Para              uint               `json:"para"`
+	Comissao          string             `json:"comissao"`
+	ParcelasMovidas   int                `json:"parcelasMovidas"`
+	ParcelasDivididas int                `json:"parcelasDivididas"`
+	ParcelasMantidas  int                `json:"parcelasMantidas"` // ficaram com o consultor anterior
+	TarefasMovidas    int64              `json:"tarefasMovidas"`
+}
+
+// parcelaTransferencia é a parte da parcela usada na transferência.
+type parcelaTransferencia struct {
+	ID           uint
+	Valor        float64
+	VolumeMensal float64
+	Status       string
+}
+
+// Transferir trata POST /negociacoes/{id}/transferir (comercial/admin)
+// Body: { "consultorId": 7, "motivo": "...", "comissao": "mover" | "dividir", "percentualNovo": 50 }
+func (h *Handler) Transferir(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+	if !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID da negociação inválido", http.StatusBadRequest)
+		return
+	}
+
+	var req transferirRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Motivo = strings.TrimSpace(req.Motivo)
+	req.Comissao = strings.ToLower(strings.TrimSpace(req.Comissao))
+	if req.Comissao == "" {
+		req.Comissao = ComissaoMover
+	}
+	switch {
+	case req.ConsultorID == 0:
+		http.Error(w, "o campo 'consultorId' é obrigatório", http.StatusBadRequest)
+		return
+	case req.Motivo == "":
+		http.Error(w, "o campo 'motivo' é obrigatório", http.StatusBadRequest)
+		return
+	case req.Comissao != ComissaoMover && req.Comissao != ComissaoDividir:
+		http.Error(w, "o campo 'comissao' deve ser 'mover' ou 'dividir'", http.StatusBadRequest)
+		return
+	case req.Comissao == ComissaoDividir && (req.PercentualNovo <= 0 || req.PercentualNovo >= 100):
+		http.Error(w, "'percentualNovo' deve estar entre 0 e 100", http.StatusBadRequest)
+		return
+	}
+
+	escopo, err := EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+
+	// o consultor de destino precisa existir e, para um comercial, ser do time
+	destino := h.DB.Table("consultors").Where("id = ? AND deleted_at IS NULL", req.ConsultorID)
+	if escopo.ComercialID != 0 {
+		destino = destino.Where("comercial_id = ?", escopo.ComercialID)
+	}
+	var qtd int64
+	if err := destino.Count(&qtd).Error; err != nil {
+		http.Error(w, "Erro ao buscar consultor", http.StatusInternalServerError)
+		return
+	}
+	if qtd == 0 {
+		http.Error(w, "Consultor de destino não encontrado", http.StatusUnprocessableEntity)
+		return
+	}
+
+	var res ResultadoTransferencia
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var neg models.Negociacao
+		if err := escopo.Aplicar(tx.Clauses(clause.Locking{Strength: "UPDATE"})).First(&neg, id).Error; err != nil {
+			return err
+		}
+		if neg.ConsultorID == req.ConsultorID {
+			return errMesmoConsultor
+		}
+		res = ResultadoTransferencia{De: neg.ConsultorID, Para: req.ConsultorID, Comissao: req.Comissao}
+
+		if err := NewRepository().AtualizarCampos(tx, &neg, map[string]any{"consultor_id": req.ConsultorID}); err != nil {
+			return err
+		}
+		neg.ConsultorID = req.ConsultorID
+		res.Negociacao = &neg
+
+		if err := transferirParcelas(tx, neg.ID, res.De, req, &res); err != nil {
+			return err
+		}
+		// em venda conjunta, a fatia do anterior nos próximos cálculos passa ao novo
+		if err := transferirParticipacao(tx, neg.ID, res.De, res.Para); err != nil {
+			return err
+		}
+
+		// os contratos acompanham a negociação
+		if err := tx.Table("contratos").Where("negociacao_id = ?", neg.ID).
+			Updates(map[string]any{"consultor_id": req.ConsultorID, "updated_at": gorm.Expr("NOW()")}).Error; err != nil {
+			return err
+		}
+
+		// tarefas em aberto do consultor anterior passam para o novo
+		upd := tx.Table("tarefas").
+			Where("negociacao_id = ? AND responsavel_tipo = ? AND responsavel_id = ? AND NOT concluida AND deleted_at IS NULL",
+				neg.ID, auth.AtorConsultor, res.De).
+			Updates(map[string]any{"responsavel_id": req.ConsultorID, "updated_at": gorm.Expr("NOW()")})
+		if upd.Error != nil {
+			return upd.Error
+		}
+		res.TarefasMovidas = upd.RowsAffected
+
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID: neg.ID,
+			Tipo:         evento.NegociacaoTransferida,
+			Descricao:    fmt.Sprintf("Negociação transferida do consultor #%d para o consultor #%d", res.De, res.Para),
+			Dados: map[string]any{
+				"de": res.De, "para": res.Para, "motivo": req.Motivo,
+				"comissao": req.Comissao, "percentualNovo": req.PercentualNovo,
+				"parcelasMovidas": res.ParcelasMovidas, "parcelasDivididas": res.ParcelasDivididas,
+				"parcelasMantidas": res.ParcelasMantidas, "tarefasMovidas": res.TarefasMovidas,
+			},
+		}.Por(ator))
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	case errors.Is(err, errMesmoConsultor):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case errors.Is(err, utils.ErrVersaoConflito):
+		http.Error(w, "A negociação foi alterada ao mesmo tempo; tente novamente", http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao transferir negociação", http.StatusInternalServerError)
+		return
+	}
+
+	h.notificarTransferencia(res, req.Motivo)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// transferirParcelas decide o destino das parcelas que ainda seguiam o dono
+// da negociação (consultor_id nulo). As fixas ficam com o anterior; as em
+// aberto seguem a negociação ("mover") ou são divididas ("dividir"), e a
+// parte do anterior passa a apontar para ele. Parcelas que já tinham
+// consultor definido (transferências anteriores) não mudam.
+func transferirParcelas(tx *gorm.DB, negID, anterior uint, req transferirRequest, res *ResultadoTransferencia) error {
+	doNegocio := tx.Table("parcela_comissaos").
+		Where("consultor_id IS NULL").
+		Where("calculo_comissao_id IN (SELECT id FROM calculo_comissaos WHERE negociacao_id = ? AND deleted_at IS NULL)", negID)
+
+	fixas := doNegocio.Session(&gorm.Session{}).Where("status IN ?", statusParcelaFixos).
+		Updates(map[string]any{"consultor_id": anterior, "updated_at": gorm.Expr("NOW()")})
+	if fixas.Error != nil {
+		return fixas.Error
+	}
+	res.ParcelasMantidas = int(fixas.RowsAffected)
+
+	var abertas []parcelaTransferencia
+	if err := doNegocio.Session(&gorm.Session{}).Select("id, valor, volume_mensal, status").
+		Where("status NOT IN ?", statusParcelaFixos).Order("id").Scan(&abertas).Error; err != nil {
+		return err
+	}
+	if req.Comissao == ComissaoMover {
+		res.ParcelasMovidas = len(abertas)
+		return nil
+	}
+
+	// dividir: a parcela original fica com o anterior e uma cópia com a parte
+	// do novo consultor segue a negociação. O total do cálculo não muda.
+	fracao := req.PercentualNovo / 100
+	for _, p := range abertas {
+		valorNovo, valorAnterior := dividirValor(p.Valor, fracao)
+		volumeNovo := p.VolumeMensal * fracao
+		// a fatia de cada um sai da fatia que a parcela já tinha
+		if err := tx.Exec(`
+			INSERT INTO parcela_comissaos
+				(calculo_comissao_id, contrato_id, percentual, valor, volume_mensal, anexo, nota_fiscal, data_vencimento, status, created_at, updated_at)
+			SELECT calculo_comissao_id, contrato_id, COALESCE(percentual, 100) * ?, ?, ?, '', '', data_vencimento, status, NOW(), NOW()
+			FROM parcela_comissaos WHERE id = ?`, fracao, valorNovo, volumeNovo, p.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("parcela_comissaos").Where("id = ?", p.ID).Updates(map[string]any{
+			"percentual":    gorm.Expr("COALESCE(percentual, 100) * ?", 1-fracao),
+			"valor":         valorAnterior,
+			"volume_mensal": p.VolumeMensal - volumeNovo,
+			"consultor_id":  anterior,
+			"updated_at":    gorm.Expr("NOW()"),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	res.ParcelasDivididas = len(abertas)
+	return nil
+}
+
+// dividirValor separa a parte do novo consultor (fracao) de um valor em
+// reais. As duas partes ficam em centavos e somam o valor original.
+func dividirValor(valor, fracao float64) (novo, anterior float64) {
+	novo = math.Round(valor*fracao*100) / 100
+	return novo, math.Round((valor-novo)*100) / 100
+}
+
+func (h *Handler) notificarTransferencia(res ResultadoTransferencia, motivo string) {
+	if h.Notificador == nil {
+		return
+	}
+	dados := map[string]any{
+		"de": res.De, "para": res.Para, "comissao": res.Comissao,
+		"parcelasMovidas": res.ParcelasMovidas, "parcelasDivididas": res.ParcelasDivididas,
+		"parcelasMantidas": res.ParcelasMantidas,
+	}
+	for _, n := range []notificacao.Notificacao{
+		{
+			Tipo:           "negociacao_transferida",
+			DestinatarioID: res.De,
+			Titulo:         "Negociação transferida para outro consultor",
+			Mensagem:       fmt.Sprintf("%s: %s", res.Negociacao.Nome, motivo),
+		},
+		{
+			Tipo:           "negociacao_recebida",
+			DestinatarioID: res.Para,
+			Titulo:         "Negociação transferida para você",
+			Mensagem:       fmt.Sprintf("%s: %s", res.Negociacao.Nome, motivo),
+		},
+	} {
+		n.DestinatarioTipo = notificacao.DestinoConsultor
+		n.NegociacaoID = res.Negociacao.ID
+		n.Dados = dados
+		if err := h.Notificador.Notificar(n); err != nil {
+			log.Printf("[negociacao] erro ao notificar transferência da negociação %d: %v", res.Negociacao.ID, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package negociacao
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDividirValor(t *testing.T) {
+	casos := []struct {
+		nome           string
+		valor, fracao  float64
+		novo, anterior float64
+	}{
+		{"metade exata", 100, 0.5, 50, 50},
+		{"terço arredonda para baixo", 100, 1.0 / 3, 33.33, 66.67},
+		{"dois terços arredonda para cima", 100, 2.0 / 3, 66.67, 33.33},
+		{"centavo ímpar", 0.01, 0.5, 0.01, 0},
+		{"sem resíduo de ponto flutuante", 100.1, 0.3333, 33.36, 66.74},
+		{"tudo para o novo", 1234.56, 1, 1234.56, 0},
+		{"nada para o novo", 1234.56, 0, 0, 1234.56},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			novo, anterior := dividirValor(c.valor, c.fracao)
+			if novo != c.novo || anterior != c.anterior {
+				t.Fatalf("dividirValor(%v, %v) = (%v, %v), quer (%v, %v)", c.valor, c.fracao, novo, anterior, c.novo, c.anterior)
+			}
+			if math.Abs(novo+anterior-c.valor) > 1e-9 {
+				t.Errorf("as partes somam %v, quer %v", novo+anterior, c.valor)
+			}
+		})
+	}
+}
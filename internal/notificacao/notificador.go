@@ -0,0 +1,72 @@
+package notificacao
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// Destinatários possíveis de uma notificação
+const (
+	DestinoConsultor = "consultor"
+	DestinoComercial = "comercial"
+)
+
+// Notificacao é uma mensagem para um usuário do portal.
+type Notificacao struct {
+	Tipo             string         `json:"tipo"` // ex.: "documento_rejeitado"
+	DestinatarioTipo string         `json:"destinatarioTipo"`
+	DestinatarioID   uint           `json:"destinatarioId"`
+	Titulo           string         `json:"titulo"`
+	Mensagem         string         `json:"mensagem"`
+	NegociacaoID     uint           `json:"negociacaoId,omitempty"`
+	Dados            map[string]any `json:"dados,omitempty"`
+}
+
+// Notificador entrega notificações. Implementações não devem bloquear a
+// requisição por muito tempo.
+type Notificador interface {
+	Notificar(n Notificacao) error
+}
+
+// NewFromEnv escolhe o notificador: webhook quando NOTIFICACAO_WEBHOOK_URL
+// estiver configurada, senão só log.
+func NewFromEnv() Notificador {
+	if url := os.Getenv("NOTIFICACAO_WEBHOOK_URL"); url != "" {
+		return &Webhook{URL: url}
+	}
+	return Log{}
+}
+
+// Log só registra a notificação no log da aplicação (dev).
+type Log struct{}
+
+func (Log) Notificar(n Notificacao) error {
+	log.Printf("[notificacao] %s -> %s#%d: %s", n.Tipo, n.DestinatarioTipo, n.DestinatarioID, n.Titulo)
+	return nil
+}
+
+// Webhook envia a notificação como JSON (POST) em background.
+type Webhook struct {
+	URL string
+}
+
+func (wh *Webhook) Notificar(n Notificacao) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	go func() {
+		resp, err := clienteWebhook.Post(wh.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Erro ao enviar notificação: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Webhook de notificação respondeu %d", resp.StatusCode)
+		}
+	}()
+	return nil
+}
@@ -5,19 +5,46 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"os"
+	"time"
 )
 
-func EnviarWebhookAlerta(cnpj string) {
-	payload := map[string]string{
+var clienteWebhook = &http.Client{Timeout: 10 * time.Second}
+
+// AlertaCNPJ descreve uma negociação aberta para um CNPJ que já existe.
+type AlertaCNPJ struct {
+	CNPJ                  string `json:"cnpj"`
+	NegociacaoID          uint   `json:"negociacaoId,omitempty"` // vazio quando a criação foi bloqueada
+	ConsultorID           uint   `json:"consultorId"`
+	NegociacaoExistenteID uint   `json:"negociacaoExistenteId"`
+	ConsultorExistenteID  uint   `json:"consultorExistenteId"`
+	Acao                  string `json:"acao"` // "bloqueada" | "em_revisao" | "liberada"
+}
+
+// EnviarWebhookAlerta avisa (em background) que uma negociação foi iniciada
+// com CNPJ já existente. A URL vem de NOTIFICACAO_WEBHOOK_URL; sem ela, só loga.
+func EnviarWebhookAlerta(alerta AlertaCNPJ) {
+	url := os.Getenv("NOTIFICACAO_WEBHOOK_URL")
+	if url == "" {
+		log.Printf("[notificacao] CNPJ duplicado %s (%s); NOTIFICACAO_WEBHOOK_URL não configurada", alerta.CNPJ, alerta.Acao)
+		return
+	}
+
+	payload := map[string]any{
 		"mensagem": "Alerta: nova negociação iniciada com CNPJ já existente",
-		"cnpj":     cnpj,
+		"alerta":   alerta,
 	}
 	body, _ := json.Marshal(payload)
 
-	resp, err := http.Post("https://seu-webhook-url.com/alerta", "application/json", bytes.NewBuffer(body))
-	if err != nil {
-		log.Printf("Erro ao enviar webhook: %v", err)
-		return
-	}
-	defer resp.Body.Close()
+	go func() {
+		resp, err := clienteWebhook.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("Erro ao enviar webhook: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Webhook respondeu %d", resp.StatusCode)
+		}
+	}()
 }
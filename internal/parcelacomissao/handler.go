@@ -2,10 +2,13 @@ package parcelacomissao
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 )
@@ -56,6 +59,28 @@ func recalcTotalForCalculo(db *gorm.DB, calculoID uint) error {
 		Update("total_receber", total).Error
 }
 
+// registrarPagamento grava o evento "parcela paga" na linha do tempo da
+// negociação do cálculo. O status já foi gravado, então só registra falhas no log.
+func registrarPagamento(db *gorm.DB, p *ParcelaComissao, ator auth.Ator) {
+	var calc struct{ NegociacaoID uint }
+	if err := db.Table("calculo_comissaos").Select("negociacao_id").
+		Where("id = ?", p.CalculoComissaoID).Take(&calc).Error; err != nil {
+		log.Printf("[parcela] evento de pagamento da parcela %d não registrado: %v", p.ID, err)
+		return
+	}
+	err := evento.Registrar(db, evento.Evento{
+		NegociacaoID:   calc.NegociacaoID,
+		Tipo:           evento.ParcelaPaga,
+		Descricao:      "Parcela de comissão paga",
+		ReferenciaTipo: "parcela",
+		ReferenciaID:   p.ID,
+		Dados:          map[string]any{"valor": p.Valor, "calculoComissaoId": p.CalculoComissaoID},
+	}.Por(ator))
+	if err != nil {
+		log.Printf("[parcela] evento de pagamento da parcela %d não registrado: %v", p.ID, err)
+	}
+}
+
 /* ============================== Endpoints ============================== */
 
 // GET /calculos-comissao/{cid}/parcelas
@@ -190,6 +215,10 @@ func (h *Handler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if parcelaAtual.Status != "Pago" && parcela.Status == "Pago" {
+		registrarPagamento(h.Repo.DB, parcela, auth.AtorDaRequisicao(r))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(parcela)
 }
@@ -301,6 +330,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	statusAnterior := parcelaExistente.Status
 	parcelaExistente.Valor = payload.Valor
 	parcelaExistente.DataVencimento = payload.DataVencimento
 	parcelaExistente.Status = payload.Status
@@ -324,6 +354,10 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if statusAnterior != "Pago" && parcelaExistente.Status == "Pago" {
+		registrarPagamento(h.Repo.DB, parcelaExistente, auth.AtorDaRequisicao(r))
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(parcelaExistente)
 }
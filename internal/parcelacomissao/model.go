@@ -11,6 +11,9 @@ import (
 type ParcelaComissao struct {
 	ID                uint       `gorm:"primaryKey" json:"id"`
 	CalculoComissaoID uint       `gorm:"not null;index" json:"calculoComissaoId"`
+	ConsultorID       *uint      `gorm:"index" json:"consultorId,omitempty"` // quem recebe; nulo = consultor atual da negociação
+	ContratoID        *uint      `gorm:"index" json:"contratoId,omitempty"`  // contrato de origem, quando gerada dele
+	Percentual        *float64   `json:"percentual,omitempty"`               // fatia do cálculo (0–100) em venda conjunta ou divisão; nulo = integral
 	Valor             float64    `gorm:"not null;default:0" json:"valor"`
 	VolumeMensal      float64    `gorm:"not null;default:0" json:"volumeMensal"`
 	Anexo             string     `gorm:"size:255" json:"anexo"`
@@ -60,15 +60,16 @@ func (r *Repository) ListByCalculoID(calculoID uint) ([]ParcelaComissao, error)
 	return parcelas, err
 }
 
-// ListByConsultorID busca todas as parcelas de todas as negociações de um consultor.
+// ListByConsultorID busca as parcelas que o consultor recebe: as das suas
+// negociações e as que ficaram com ele em negociações transferidas.
 func (r *Repository) ListByConsultorID(consultorID uint) ([]ParcelaComissao, error) {
 	var parcelas []ParcelaComissao
 	err := r.DB.
 		Table("parcela_comissaos").
 		Select("parcela_comissaos.*").
 		Joins("JOIN calculo_comissaos ON calculo_comissaos.id = parcela_comissaos.calculo_comissao_id").
-		Joins("JOIN negociacoes ON negociacoes.id = calculo_comissaos.negociacao_id").
-		Where("negociacoes.consultor_id = ?", consultorID).
+		Joins("JOIN negociacaos ON negociacaos.id = calculo_comissaos.negociacao_id").
+		Where("COALESCE(parcela_comissaos.consultor_id, negociacaos.consultor_id) = ?", consultorID).
 		Order("data_vencimento ASC").
 		Find(&parcelas).Error
 
@@ -0,0 +1,235 @@
+// internal/pdf/contrato.go
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/produtos"
+	"gorm.io/gorm"
+)
+
+// ModeloContrato é uma versão de um modelo de contrato. Versões não mudam
+// depois de criadas: editar o modelo grava a próxima versão do mesmo código.
+//
+// O conteúdo é texto com variáveis no formato {{ grupo.campo }} (veja
+// VariaveisContrato). Blocos são separados por linha em branco; um bloco
+// que começa com "# " vira título de seção.
+type ModeloContrato struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	Codigo string `gorm:"size:60;not null;uniqueIndex:idx_contrato_modelo_versao,priority:1" json:"codigo"`
+	Versao int    `gorm:"not null;uniqueIndex:idx_contrato_modelo_versao,priority:2" json:"versao"`
+
+	Nome         string   `gorm:"size:200;not null" json:"nome"`         // título do documento
+	TipoContrato string   `gorm:"size:50" json:"tipoContrato,omitempty"` // "Gestão" | "Energia"; vazio = qualquer
+	Conteudo     string   `gorm:"type:text;not null" json:"conteudo"`
+	Notas        string   `gorm:"type:text" json:"notas,omitempty"` // o que mudou nesta versão
+	Variaveis    []string `gorm:"type:jsonb;serializer:json" json:"variaveis"`
+
+	CriadoPorTipo string `gorm:"size:20;not null" json:"criadoPorTipo"`
+	CriadoPorID   *uint  `json:"criadoPorId"`
+}
+
+func (ModeloContrato) TableName() string { return "contrato_modelos" }
+
+// DocumentoContrato é um PDF gerado a partir de um modelo. O hash é o
+// SHA-256 do PDF, o mesmo calculado ao enviá-lo para assinatura.
+type DocumentoContrato struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ContratoID   uint      `gorm:"not null;index" json:"contratoId"`
+	NegociacaoID uint      `gorm:"not null;index" json:"negociacaoId"`
+
+	ModeloID     uint   `gorm:"not null" json:"modeloId"`
+	ModeloCodigo string `gorm:"size:60;not null" json:"modeloCodigo"`
+	ModeloVersao int    `gorm:"not null" json:"modeloVersao"`
+
+	URL     string            `gorm:"size:500;not null" json:"url"`
+	Hash    string            `gorm:"size:64;not null;index" json:"hash"`
+	Valores map[string]string `gorm:"type:jsonb;serializer:json" json:"valores"` // valores usados na substituição
+
+	GeradoPorTipo string `gorm:"size:20;not null" json:"geradoPorTipo"`
+	GeradoPorID   *uint  `json:"geradoPorId"`
+}
+
+func (DocumentoContrato) TableName() string { return "contrato_documentos" }
+
+// VariavelContrato descreve uma variável aceita nos modelos.
+type VariavelContrato struct {
+	Chave     string `json:"chave"`
+	Descricao string `json:"descricao"`
+}
+
+// VariaveisContrato é o catálogo de variáveis dos modelos de contrato.
+// Sem cliente cadastrado, as variáveis "cliente" usam os dados da negociação.
+var VariaveisContrato = []VariavelContrato{
+	{"negociacao.razao_social", "Razão social informada na negociação"},
+	{"negociacao.cnpj", "CNPJ informado na negociação"},
+	{"negociacao.uf", "UF da negociação"},
+	{"negociacao.contato", "Nome do contato na negociação"},
+	{"negociacao.email", "E-mail do contato na negociação"},
+	{"negociacao.telefone", "Telefone do contato na negociação"},
+	{"cliente.razao_social", "Razão social do cliente"},
+	{"cliente.cnpj", "CNPJ do cliente"},
+	{"cliente.uf", "UF do cliente"},
+	{"cliente.contato", "Contato principal do cliente"},
+	{"cliente.email", "E-mail do cliente"},
+	{"cliente.telefone", "Telefone do cliente"},
+	{"consultor.nome", "Nome completo do consultor"},
+	{"consultor.email", "E-mail do consultor"},
+	{"consultor.cnpj", "CNPJ do consultor"},
+	{"produto.tipo", "Produto da negociação do mesmo tipo do contrato"},
+	{"produto.fee", "Fee do produto"},
+	{"produto.comissao", "Comissão do produto (%)"},
+	{"contrato.numero", "Número do contrato"},
+	{"contrato.tipo", "Tipo do contrato (Gestão ou Energia)"},
+	{"contrato.valor", "Valor total do contrato (R$)"},
+	{"contrato.fee_percent", "Fee do contrato (%)"},
+	{"contrato.unipay_percent", "Pagamento único sobre o total (%)"},
+	{"contrato.valor_mensal", "Pagamento mensal fixo (R$)"},
+	{"contrato.inicio_suprimento", "Início do suprimento (dd/mm/aaaa)"},
+	{"contrato.fim_suprimento", "Fim do suprimento (dd/mm/aaaa)"},
+	{"contrato.data_assinatura", "Data de assinatura (dd/mm/aaaa)"},
+	{"data.hoje", "Data da geração do documento (dd/mm/aaaa)"},
+	{"data.hoje_extenso", "Data da geração por extenso (ex.: 5 de março de 2025)"},
+}
+
+var reVariavel = regexp.MustCompile(`\{\{\s*([a-z_]+\.[a-z_]+)\s*\}\}`)
+
+// variaveisDoConteudo lista as variáveis usadas no conteúdo (sem repetição,
+// em ordem alfabética) e as que não existem no catálogo.
+func variaveisDoConteudo(conteudo string) (usadas, desconhecidas []string) {
+	conhecidas := make(map[string]bool, len(VariaveisContrato))
+	for _, v := range VariaveisContrato {
+		conhecidas[v.Chave] = true
+	}
+	vistas := map[string]bool{}
+	for _, m := range reVariavel.FindAllStringSubmatch(conteudo, -1) {
+		chave := m[1]
+		if vistas[chave] {
+			continue
+		}
+		vistas[chave] = true
+		if conhecidas[chave] {
+			usadas = append(usadas, chave)
+		} else {
+			desconhecidas = append(desconhecidas, chave)
+		}
+	}
+	sort.Strings(usadas)
+	sort.Strings(desconhecidas)
+	return usadas, desconhecidas
+}
+
+// substituir troca as variáveis pelos valores. As que ficarem sem valor são
+// mantidas no texto e devolvidas em pendentes.
+func substituir(conteudo string, valores map[string]string) (texto string, pendentes []string) {
+	vistas := map[string]bool{}
+	texto = reVariavel.ReplaceAllStringFunc(conteudo, func(m string) string {
+		chave := reVariavel.FindStringSubmatch(m)[1]
+		if v := strings.TrimSpace(valores[chave]); v != "" {
+			return v
+		}
+		if !vistas[chave] {
+			vistas[chave] = true
+			pendentes = append(pendentes, chave)
+		}
+		return m
+	})
+	return texto, pendentes
+}
+
+// valoresDoContrato carrega os dados de negociação, cliente, consultor,
+// produto e contrato e os formata para os modelos.
+func valoresDoContrato(db *gorm.DB, c contrato.Contrato, agora time.Time) (map[string]string, error) {
+	var neg models.Negociacao
+	if err := db.First(&neg, c.NegociacaoID).Error; err != nil {
+		return nil, fmt.Errorf("negociação %d: %w", c.NegociacaoID, err)
+	}
+	cliente := models.Cliente{
+		Nome: neg.Nome, CNPJ: neg.CNPJ, UF: neg.UF,
+		Contato: neg.Contato, Email: neg.Email, Telefone: neg.Telefone,
+	}
+	if neg.ClienteID != nil {
+		if err := db.First(&cliente, *neg.ClienteID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	consultor, err := buscarConsultor(db, neg.ConsultorID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("consultor %d: %w", neg.ConsultorID, err)
+	}
+	var produto produtos.Produto
+	err = db.Where("negociacao_id = ? AND LOWER(tipo) = LOWER(?)", neg.ID, c.Tipo).
+		Order("ativo DESC, id").Take(&produto).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	v := map[string]string{
+		"negociacao.razao_social":    neg.Nome,
+		"negociacao.cnpj":            neg.CNPJ,
+		"negociacao.uf":              neg.UF,
+		"negociacao.contato":         neg.Contato,
+		"negociacao.email":           neg.Email,
+		"negociacao.telefone":        neg.Telefone,
+		"cliente.razao_social":       cliente.Nome,
+		"cliente.cnpj":               cliente.CNPJ,
+		"cliente.uf":                 cliente.UF,
+		"cliente.contato":            cliente.Contato,
+		"cliente.email":              cliente.Email,
+		"cliente.telefone":           cliente.Telefone,
+		"consultor.nome":             consultor.NomeCompleto(),
+		"consultor.email":            consultor.Email,
+		"consultor.cnpj":             consultor.CNPJ,
+		"contrato.numero":            fmt.Sprint(c.ID),
+		"contrato.tipo":              c.Tipo,
+		"contrato.valor":             moeda(c.Valor),
+		"contrato.inicio_suprimento": data(c.InicioSuprimento),
+		"contrato.fim_suprimento":    data(c.FimSuprimento),
+		"data.hoje":                  data(agora),
+		"data.hoje_extenso":          fmt.Sprintf("%d de %s de %d", agora.Day(), meses[agora.Month()-1], agora.Year()),
+	}
+	if produto.ID != 0 {
+		v["produto.tipo"] = produto.Tipo
+		v["produto.fee"] = numero(produto.Fee, 2)
+		v["produto.comissao"] = percentual(produto.Comissao)
+	}
+	if c.Fee {
+		v["contrato.fee_percent"] = percentual(c.FeePercent * 100)
+	}
+	if c.UniPay {
+		v["contrato.unipay_percent"] = percentual(c.UniPayPercent * 100)
+	}
+	if c.MonPay {
+		v["contrato.valor_mensal"] = moeda(c.MonthPay)
+	}
+	if c.DataAssinatura.Year() > 1900 {
+		v["contrato.data_assinatura"] = data(c.DataAssinatura)
+	}
+	return v, nil
+}
+
+// renderizarContrato monta o PDF do texto já substituído.
+func renderizarContrato(m ModeloContrato, texto string, agora time.Time) ([]byte, error) {
+	d := novoDocumento(m.Nome, "", agora)
+	for _, bloco := range strings.Split(strings.ReplaceAll(texto, "\r\n", "\n"), "\n\n") {
+		bloco = strings.TrimSpace(bloco)
+		switch {
+		case bloco == "":
+		case strings.HasPrefix(bloco, "# "):
+			d.Secao(strings.TrimSpace(strings.TrimPrefix(bloco, "# ")))
+		default:
+			d.Paragrafo(bloco)
+		}
+	}
+	return d.Bytes()
+}
@@ -0,0 +1,365 @@
+// internal/pdf/contrato_handler.go
+package pdf
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/assinatura"
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+var reCodigoModelo = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{1,59}$`)
+
+type modeloContratoRequest struct {
+	Codigo       string `json:"codigo"`
+	Nome         string `json:"nome"`
+	TipoContrato string `json:"tipoContrato"`
+	Conteudo     string `json:"conteudo"`
+	Notas        string `json:"notas"`
+}
+
+// CriarModeloContrato trata POST /modelos-contrato (comercial)
+// Body: { "codigo": "gestao-padrao", "nome": "...", "tipoContrato": "Gestão", "conteudo": "...", "notas": "..." }
+// Grava a próxima versão do código (a primeira é a 1). Variáveis fora do
+// catálogo são recusadas.
+func (h *Handler) CriarModeloContrato(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+	if !ator.EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	var req modeloContratoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	req.Codigo = strings.ToLower(strings.TrimSpace(req.Codigo))
+	req.Nome = strings.TrimSpace(req.Nome)
+	req.TipoContrato = strings.TrimSpace(req.TipoContrato)
+	switch {
+	case !reCodigoModelo.MatchString(req.Codigo):
+		http.Error(w, "o campo 'codigo' deve ter de 2 a 60 caracteres entre a-z, 0-9, '-' e '_'", http.StatusBadRequest)
+		return
+	case req.Nome == "":
+		http.Error(w, "o campo 'nome' é obrigatório", http.StatusBadRequest)
+		return
+	case strings.TrimSpace(req.Conteudo) == "":
+		http.Error(w, "o campo 'conteudo' é obrigatório", http.StatusBadRequest)
+		return
+	}
+	usadas, desconhecidas := variaveisDoConteudo(req.Conteudo)
+	if len(desconhecidas) > 0 {
+		http.Error(w, "variáveis desconhecidas: "+strings.Join(desconhecidas, ", "), http.StatusBadRequest)
+		return
+	}
+
+	m := ModeloContrato{
+		Codigo:        req.Codigo,
+		Nome:          req.Nome,
+		TipoContrato:  req.TipoContrato,
+		Conteudo:      req.Conteudo,
+		Notas:         strings.TrimSpace(req.Notas),
+		Variaveis:     usadas,
+		CriadoPorTipo: ator.Tipo,
+	}
+	if ator.ID != 0 {
+		id := ator.ID
+		m.CriadoPorID = &id
+	}
+	if m.Variaveis == nil {
+		m.Variaveis = []string{}
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ModeloContrato{}).Where("codigo = ?", m.Codigo).
+			Select("COALESCE(MAX(versao), 0)").Scan(&m.Versao).Error; err != nil {
+			return err
+		}
+		m.Versao++
+		return tx.Create(&m).Error
+	})
+	if err != nil {
+		// duas versões do mesmo código criadas ao mesmo tempo esbarram no índice único
+		var qtd int64
+		h.DB.Model(&ModeloContrato{}).Where("codigo = ? AND versao = ?", m.Codigo, m.Versao).Count(&qtd)
+		if qtd > 0 {
+			http.Error(w, "outra versão deste modelo foi criada ao mesmo tempo; tente novamente", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Erro ao salvar modelo de contrato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+// ListarModelosContrato trata GET /modelos-contrato: a versão mais recente de cada código.
+func (h *Handler) ListarModelosContrato(w http.ResponseWriter, r *http.Request) {
+	lista := []ModeloContrato{}
+	if err := h.DB.Raw(`
+		SELECT DISTINCT ON (codigo) * FROM contrato_modelos
+		ORDER BY codigo, versao DESC`).Scan(&lista).Error; err != nil {
+		http.Error(w, "Erro ao listar modelos de contrato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lista)
+}
+
+// ListarVariaveisContrato trata GET /modelos-contrato/variaveis
+func (h *Handler) ListarVariaveisContrato(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(VariaveisContrato)
+}
+
+// ListarVersoesModeloContrato trata GET /modelos-contrato/{codigo}/versoes
+func (h *Handler) ListarVersoesModeloContrato(w http.ResponseWriter, r *http.Request) {
+	lista := []ModeloContrato{}
+	if err := h.DB.Where("codigo = ?", mux.Vars(r)["codigo"]).Order("versao DESC").Find(&lista).Error; err != nil {
+		http.Error(w, "Erro ao listar versões do modelo", http.StatusInternalServerError)
+		return
+	}
+	if len(lista) == 0 {
+		http.Error(w, "Modelo de contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lista)
+}
+
+// BuscarVersaoModeloContrato trata GET /modelos-contrato/{codigo}/versoes/{versao}
+func (h *Handler) BuscarVersaoModeloContrato(w http.ResponseWriter, r *http.Request) {
+	versao, err := strconv.Atoi(mux.Vars(r)["versao"])
+	if err != nil || versao <= 0 {
+		http.Error(w, "Versão inválida", http.StatusBadRequest)
+		return
+	}
+	m, err := buscarModeloContrato(h.DB, mux.Vars(r)["codigo"], versao)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Modelo de contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar modelo de contrato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+type gerarDocumentoRequest struct {
+	Modelo string `json:"modelo"` // código do modelo
+	Versao int    `json:"versao"` // 0 = mais recente
+}
+
+// PreviaDocumentoContrato é o texto do modelo já preenchido, sem gerar o PDF.
+type PreviaDocumentoContrato struct {
+	ModeloCodigo string            `json:"modeloCodigo"`
+	ModeloVersao int               `json:"modeloVersao"`
+	Texto        string            `json:"texto"`
+	Pendentes    []string          `json:"pendentes"` // variáveis sem valor (mantidas no texto)
+	Valores      map[string]string `json:"valores"`
+}
+
+// GerarDocumentoContrato trata POST /contratos/{id}/documento?preview=true
+// Body: { "modelo": "gestao-padrao", "versao": 2 }
+// Preenche o modelo com os dados do contrato. Com preview, devolve o texto e
+// as variáveis pendentes; sem, exige todas preenchidas, grava o PDF, guarda o
+// hash e passa a usar o PDF como documento do contrato (o que vai para
+// assinatura em POST /negociacoes/{id}/assinaturas).
+func (h *Handler) GerarDocumentoContrato(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID do contrato inválido", http.StatusBadRequest)
+		return
+	}
+	preview, _ := strconv.ParseBool(r.URL.Query().Get("preview"))
+	var req gerarDocumentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Modelo) == "" {
+		http.Error(w, "o campo 'modelo' é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	var c contrato.Contrato
+	if err := h.DB.First(&c, id).Error; err != nil {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	if _, ok := h.carregarNegociacao(w, r, strconv.Itoa(int(c.NegociacaoID))); !ok {
+		return
+	}
+	m, err := buscarModeloContrato(h.DB, strings.ToLower(strings.TrimSpace(req.Modelo)), req.Versao)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Modelo de contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar modelo de contrato", http.StatusInternalServerError)
+		return
+	}
+	if m.TipoContrato != "" && !strings.EqualFold(m.TipoContrato, c.Tipo) {
+		http.Error(w, fmt.Sprintf("o modelo é para contratos de %s", m.TipoContrato), http.StatusUnprocessableEntity)
+		return
+	}
+
+	agora := time.Now()
+	valores, err := valoresDoContrato(h.DB, c, agora)
+	if err != nil {
+		http.Error(w, "Erro ao carregar dados do contrato", http.StatusInternalServerError)
+		return
+	}
+	texto, pendentes := substituir(m.Conteudo, valores)
+
+	w.Header().Set("Content-Type", "application/json")
+	if preview {
+		if pendentes == nil {
+			pendentes = []string{}
+		}
+		_ = json.NewEncoder(w).Encode(PreviaDocumentoContrato{
+			ModeloCodigo: m.Codigo, ModeloVersao: m.Versao, Texto: texto, Pendentes: pendentes, Valores: valores,
+		})
+		return
+	}
+	if len(pendentes) > 0 {
+		http.Error(w, "preencha os dados pendentes: "+strings.Join(pendentes, ", "), http.StatusUnprocessableEntity)
+		return
+	}
+	if c.DataAssinatura.Year() > 1900 {
+		http.Error(w, "contrato já assinado; o documento não pode ser substituído", http.StatusConflict)
+		return
+	}
+	if c.Status == contrato.StatusCancelado {
+		http.Error(w, "contrato cancelado; o documento não pode ser gerado", http.StatusConflict)
+		return
+	}
+	// o PDF em assinatura não pode ser trocado por baixo do envelope
+	var emAssinatura int64
+	if err := h.DB.Model(&assinatura.Envelope{}).
+		Where("contrato_id = ? AND status = ?", c.ID, assinatura.StatusEnviado).
+		Count(&emAssinatura).Error; err != nil {
+		http.Error(w, "Erro ao verificar envelopes de assinatura", http.StatusInternalServerError)
+		return
+	}
+	if emAssinatura > 0 {
+		http.Error(w, "contrato aguardando assinaturas; cancele o envelope antes de gerar outro documento", http.StatusConflict)
+		return
+	}
+	if h.Processador == nil || h.Processador.Storage == nil {
+		http.Error(w, "Storage não configurado", http.StatusInternalServerError)
+		return
+	}
+
+	conteudo, err := renderizarContrato(m, texto, agora)
+	if err != nil {
+		http.Error(w, "Erro ao gerar PDF do contrato", http.StatusInternalServerError)
+		return
+	}
+	soma := sha256.Sum256(conteudo)
+	sufixo, err := storage.SufixoAleatorio()
+	if err != nil {
+		http.Error(w, "Erro ao salvar PDF do contrato", http.StatusInternalServerError)
+		return
+	}
+	chave := fmt.Sprintf("pdf/contratos/%d/%s-v%d-%s.pdf", c.ID, m.Codigo, m.Versao, sufixo)
+	url, err := h.Processador.Storage.Put(r.Context(), chave, bytes.NewReader(conteudo), int64(len(conteudo)), "application/pdf")
+	if err != nil {
+		http.Error(w, "Erro ao salvar PDF do contrato", http.StatusInternalServerError)
+		return
+	}
+
+	ator := auth.AtorDaRequisicao(r)
+	doc := DocumentoContrato{
+		ContratoID:    c.ID,
+		NegociacaoID:  c.NegociacaoID,
+		ModeloID:      m.ID,
+		ModeloCodigo:  m.Codigo,
+		ModeloVersao:  m.Versao,
+		URL:           url,
+		Hash:          hex.EncodeToString(soma[:]),
+		Valores:       valores,
+		GeradoPorTipo: ator.Tipo,
+	}
+	if ator.ID != 0 {
+		id := ator.ID
+		doc.GeradoPorID = &id
+	}
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&doc).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&contrato.Contrato{}).Where("id = ?", c.ID).Updates(map[string]any{
+			"url":    url,
+			"versao": gorm.Expr("versao + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   c.NegociacaoID,
+			Tipo:           evento.ContratoRenderizado,
+			Descricao:      fmt.Sprintf("Documento do contrato #%d gerado do modelo %s v%d", c.ID, m.Codigo, m.Versao),
+			ReferenciaTipo: "contrato",
+			ReferenciaID:   c.ID,
+			Dados:          map[string]any{"documentoId": doc.ID, "url": url, "hash": doc.Hash},
+		}.Por(ator))
+	})
+	if err != nil {
+		http.Error(w, "Erro ao registrar documento do contrato", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(doc)
+}
+
+// ListarDocumentosContrato trata GET /contratos/{id}/documentos
+func (h *Handler) ListarDocumentosContrato(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID do contrato inválido", http.StatusBadRequest)
+		return
+	}
+	var c contrato.Contrato
+	if err := h.DB.Select("id", "negociacao_id").First(&c, id).Error; err != nil {
+		http.Error(w, "Contrato não encontrado", http.StatusNotFound)
+		return
+	}
+	if _, ok := h.carregarNegociacao(w, r, strconv.Itoa(int(c.NegociacaoID))); !ok {
+		return
+	}
+	lista := []DocumentoContrato{}
+	if err := h.DB.Where("contrato_id = ?", c.ID).Order("id DESC").Find(&lista).Error; err != nil {
+		http.Error(w, "Erro ao listar documentos do contrato", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(lista)
+}
+
+// buscarModeloContrato busca uma versão do modelo; versão 0 é a mais recente.
+func buscarModeloContrato(db *gorm.DB, codigo string, versao int) (ModeloContrato, error) {
+	var m ModeloContrato
+	q := db.Where("codigo = ?", codigo)
+	if versao > 0 {
+		q = q.Where("versao = ?", versao)
+	}
+	err := q.Order("versao DESC").Take(&m).Error
+	return m, err
+}
@@ -0,0 +1,201 @@
+// internal/pdf/handler.go
+package pdf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler expõe a geração assíncrona de PDFs
+type Handler struct {
+	DB          *gorm.DB
+	Repository  Repository
+	Processador *Processador
+}
+
+// NewHandler cria o handler de PDFs; os jobs criados acordam o processador.
+func NewHandler(db *gorm.DB, proc *Processador) *Handler {
+	return &Handler{DB: db, Repository: NewRepository(), Processador: proc}
+}
+
+// GerarProposta trata POST /negociacoes/{id}/pdfs/proposta
+func (h *Handler) GerarProposta(w http.ResponseWriter, r *http.Request) {
+	negID, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	h.enfileirar(w, r, &Job{Tipo: TipoProposta, NegociacaoID: &negID})
+}
+
+// GerarEstudo trata POST /negociacoes/{id}/estudos/{versao}/pdf
+func (h *Handler) GerarEstudo(w http.ResponseWriter, r *http.Request) {
+	negID, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	versao, err := strconv.Atoi(mux.Vars(r)["versao"])
+	if err != nil || versao <= 0 {
+		http.Error(w, "Versão inválida", http.StatusBadRequest)
+		return
+	}
+	var qtd int64
+	if err := h.DB.Table("estudos").Where("negociacao_id = ? AND versao = ?", negID, versao).Count(&qtd).Error; err != nil {
+		http.Error(w, "Erro ao buscar estudo", http.StatusInternalServerError)
+		return
+	}
+	if qtd == 0 {
+		http.Error(w, "Estudo não encontrado", http.StatusNotFound)
+		return
+	}
+	h.enfileirar(w, r, &Job{Tipo: TipoEstudo, NegociacaoID: &negID, EstudoVersao: uint(versao)})
+}
+
+// GerarExtrato trata POST /consultores/{id}/extratos/{competencia}/pdf (competencia = AAAA-MM)
+func (h *Handler) GerarExtrato(w http.ResponseWriter, r *http.Request) {
+	consultorID, ok := h.carregarConsultor(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	competencia := mux.Vars(r)["competencia"]
+	inicio, err := time.Parse("2006-01", competencia)
+	if err != nil {
+		http.Error(w, "Competência inválida (use AAAA-MM)", http.StatusBadRequest)
+		return
+	}
+	if inicio.After(time.Now()) {
+		http.Error(w, "Competência no futuro", http.StatusBadRequest)
+		return
+	}
+	h.enfileirar(w, r, &Job{Tipo: TipoExtratoComissao, ConsultorID: &consultorID, Competencia: competencia})
+}
+
+// ListarPorNegociacao trata GET /negociacoes/{id}/pdfs
+func (h *Handler) ListarPorNegociacao(w http.ResponseWriter, r *http.Request) {
+	negID, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	list, err := h.Repository.ListarPorNegociacao(h.DB, negID)
+	if err != nil {
+		http.Error(w, "Erro ao listar PDFs", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// BuscarPorID trata GET /pdfs/{id}: status do job e, quando concluído, a URL.
+func (h *Handler) BuscarPorID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	j, err := h.Repository.BuscarPorID(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "PDF não encontrado", http.StatusNotFound)
+		return
+	}
+	switch {
+	case j.NegociacaoID != nil:
+		if _, ok := h.carregarNegociacao(w, r, strconv.Itoa(int(*j.NegociacaoID))); !ok {
+			return
+		}
+	case j.ConsultorID != nil:
+		if _, ok := h.carregarConsultor(w, r, strconv.Itoa(int(*j.ConsultorID))); !ok {
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(j)
+}
+
+/* ================== Helpers ================== */
+
+func (h *Handler) enfileirar(w http.ResponseWriter, r *http.Request, j *Job) {
+	ator := auth.AtorDaRequisicao(r)
+	j.SolicitadoPorTipo = ator.Tipo
+	if ator.ID != 0 {
+		id := ator.ID
+		j.SolicitadoPorID = &id
+	}
+	if err := h.Repository.Criar(h.DB, j); err != nil {
+		http.Error(w, "Erro ao criar job de PDF", http.StatusInternalServerError)
+		return
+	}
+	if h.Processador != nil {
+		h.Processador.Acordar()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/pdfs/%d", j.ID))
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(j)
+}
+
+// carregarNegociacao valida o ID e o escopo do usuário sobre a negociação.
+func (h *Handler) carregarNegociacao(w http.ResponseWriter, r *http.Request, idStr string) (uint, bool) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return 0, false
+	}
+	cond, args := escopo.SQL("n")
+	var qtd int64
+	if err := h.DB.Table("negociacaos AS n").
+		Where("n.id = ? AND n.deleted_at IS NULL", id).
+		Where(cond, args...).
+		Count(&qtd).Error; err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return 0, false
+	}
+	if qtd == 0 {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// carregarConsultor: o consultor só vê o próprio extrato; o comercial, os
+// dos consultores do seu time (admin, todos).
+func (h *Handler) carregarConsultor(w http.ResponseWriter, r *http.Request, idStr string) (uint, bool) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return 0, false
+	}
+	if escopo.ConsultorID != 0 && escopo.ConsultorID != uint(id) {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return 0, false
+	}
+
+	var c struct{ ComercialID uint }
+	if err := h.DB.Table("consultors").Select("comercial_id").
+		Where("id = ? AND deleted_at IS NULL", id).Take(&c).Error; err != nil {
+		http.Error(w, "Consultor não encontrado", http.StatusNotFound)
+		return 0, false
+	}
+	if escopo.ComercialID != 0 && escopo.ComercialID != c.ComercialID {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return 0, false
+	}
+	return uint(id), true
+}
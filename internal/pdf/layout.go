@@ -0,0 +1,230 @@
+// internal/pdf/layout.go
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Empresa que assina os documentos (cabeçalho/rodapé).
+const nomeEmpresa = "Kroma Energia"
+
+const (
+	alturaLinha = 6.0
+	margem      = 15.0
+)
+
+// Coluna de uma tabela: título, largura (mm) e alinhamento ("L", "C", "R").
+type Coluna struct {
+	Titulo  string
+	Largura float64
+	Alinhar string
+}
+
+// documentoPDF é um A4 retrato com cabeçalho, rodapé numerado e os blocos
+// usados pelos modelos (seções, pares rótulo/valor, tabelas e parágrafos).
+// As fontes padrão do PDF só têm cp1252; tr converte o texto UTF-8.
+type documentoPDF struct {
+	f  *fpdf.Fpdf
+	tr func(string) string
+}
+
+func novoDocumento(titulo, subtitulo string, geradoEm time.Time) *documentoPDF {
+	f := fpdf.New("P", "mm", "A4", "")
+	d := &documentoPDF{f: f, tr: f.UnicodeTranslatorFromDescriptor("")}
+	f.SetMargins(margem, margem, margem)
+	f.SetAutoPageBreak(true, 20)
+	f.SetTitle(titulo, true)
+	f.SetAuthor(nomeEmpresa, true)
+	f.SetCreationDate(geradoEm)
+	f.AliasNbPages("")
+
+	f.SetHeaderFunc(func() {
+		f.SetFont("Helvetica", "B", 9)
+		f.SetTextColor(90, 90, 90)
+		f.CellFormat(60, 5, d.tr(nomeEmpresa), "", 0, "L", false, 0, "")
+		f.CellFormat(0, 5, d.tr(titulo), "", 1, "R", false, 0, "")
+		f.SetDrawColor(200, 200, 200)
+		f.Line(margem, f.GetY()+1, 210-margem, f.GetY()+1)
+		f.Ln(6)
+		f.SetTextColor(0, 0, 0)
+	})
+	f.SetFooterFunc(func() {
+		f.SetY(-15)
+		f.SetFont("Helvetica", "", 8)
+		f.SetTextColor(120, 120, 120)
+		f.CellFormat(90, 5, d.tr("Gerado em "+dataHora(geradoEm)), "", 0, "L", false, 0, "")
+		f.CellFormat(0, 5, d.tr(fmt.Sprintf("Página %d/{nb}", f.PageNo())), "", 0, "R", false, 0, "")
+	})
+
+	f.AddPage()
+	f.SetFont("Helvetica", "B", 18)
+	f.MultiCell(0, 9, d.tr(titulo), "", "L", false)
+	if subtitulo != "" {
+		f.SetFont("Helvetica", "", 11)
+		f.SetTextColor(80, 80, 80)
+		f.MultiCell(0, 6, d.tr(subtitulo), "", "L", false)
+		f.SetTextColor(0, 0, 0)
+	}
+	f.Ln(4)
+	return d
+}
+
+// Secao abre um bloco com título.
+func (d *documentoPDF) Secao(titulo string) {
+	f := d.f
+	if f.GetY() > 250 {
+		f.AddPage()
+	}
+	f.Ln(3)
+	f.SetFont("Helvetica", "B", 12)
+	f.SetFillColor(235, 240, 245)
+	f.CellFormat(0, 8, d.tr(titulo), "", 1, "L", true, 0, "")
+	f.Ln(2)
+}
+
+// Campos imprime pares rótulo/valor, um por linha; valores vazios viram "—".
+func (d *documentoPDF) Campos(pares ...[2]string) {
+	f := d.f
+	for _, p := range pares {
+		valor := strings.TrimSpace(p[1])
+		if valor == "" {
+			valor = "—"
+		}
+		f.SetFont("Helvetica", "B", 10)
+		f.CellFormat(50, alturaLinha, d.tr(p[0]), "", 0, "L", false, 0, "")
+		f.SetFont("Helvetica", "", 10)
+		f.MultiCell(0, alturaLinha, d.tr(valor), "", "L", false)
+	}
+}
+
+// Paragrafo imprime texto corrido.
+func (d *documentoPDF) Paragrafo(texto string) {
+	d.f.SetFont("Helvetica", "", 10)
+	d.f.MultiCell(0, 5, d.tr(texto), "", "J", false)
+	d.f.Ln(2)
+}
+
+// Tabela imprime cabeçalho e linhas, repetindo o cabeçalho a cada página.
+// Se total não for nil, ele sai em negrito como última linha.
+func (d *documentoPDF) Tabela(colunas []Coluna, linhas [][]string, total []string) {
+	f := d.f
+	cabecalho := func() {
+		f.SetFont("Helvetica", "B", 9)
+		f.SetFillColor(50, 70, 90)
+		f.SetTextColor(255, 255, 255)
+		for _, c := range colunas {
+			f.CellFormat(c.Largura, 7, d.tr(c.Titulo), "", 0, c.Alinhar, true, 0, "")
+		}
+		f.Ln(-1)
+		f.SetTextColor(0, 0, 0)
+	}
+	linha := func(valores []string, negrito, zebra bool) {
+		if f.GetY()+alturaLinha > 277 {
+			f.AddPage()
+			cabecalho()
+		}
+		estilo := ""
+		if negrito {
+			estilo = "B"
+		}
+		f.SetFont("Helvetica", estilo, 9)
+		f.SetFillColor(245, 245, 245)
+		for i, c := range colunas {
+			v := ""
+			if i < len(valores) {
+				v = valores[i]
+			}
+			f.CellFormat(c.Largura, alturaLinha, d.tr(truncar(v, c.Largura)), "", 0, c.Alinhar, zebra, 0, "")
+		}
+		f.Ln(-1)
+	}
+
+	cabecalho()
+	for i, l := range linhas {
+		linha(l, false, i%2 == 1)
+	}
+	if len(linhas) == 0 {
+		f.SetFont("Helvetica", "I", 9)
+		f.CellFormat(0, alturaLinha, d.tr("Nenhum registro."), "", 1, "L", false, 0, "")
+	}
+	if total != nil {
+		f.SetDrawColor(50, 70, 90)
+		f.Line(margem, f.GetY(), 210-margem, f.GetY())
+		linha(total, true, false)
+	}
+	f.Ln(3)
+}
+
+// Bytes finaliza o documento.
+func (d *documentoPDF) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.f.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// truncar corta o texto que não cabe na coluna (estimativa de ~1,9 mm por caractere em 9pt).
+func truncar(s string, largura float64) string {
+	max := int(largura / 1.9)
+	r := []rune(s)
+	if max < 4 || len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
+
+/* ================== Formatação pt-BR ================== */
+
+// moeda formata "R$ 1.234,56".
+func moeda(v float64) string {
+	return "R$ " + numero(v, 2)
+}
+
+// numero formata com separador de milhar "." e decimal ",".
+func numero(v float64, casas int) string {
+	negativo := v < 0
+	s := fmt.Sprintf("%.*f", casas, math.Abs(v))
+	inteiro, decimal, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, c := range inteiro {
+		if i > 0 && (len(inteiro)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+	if decimal != "" {
+		b.WriteByte(',')
+		b.WriteString(decimal)
+	}
+	if negativo && strings.Trim(s, "0.") != "" {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+func percentual(v float64) string { return numero(v, 2) + "%" }
+
+func data(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02/01/2006")
+}
+
+func dataHora(t time.Time) string { return t.Format("02/01/2006 15:04") }
+
+var meses = []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho",
+	"julho", "agosto", "setembro", "outubro", "novembro", "dezembro"}
+
+// mesAno formata "março/2025".
+func mesAno(t time.Time) string {
+	return fmt.Sprintf("%s/%d", meses[t.Month()-1], t.Year())
+}
@@ -0,0 +1,51 @@
+// internal/pdf/model.go
+package pdf
+
+import "time"
+
+// Tipos de documento gerado
+const (
+	TipoProposta        = "proposta"         // proposta comercial (negociação + produtos)
+	TipoEstudo          = "estudo"           // estudo de viabilidade (versão do estudo de economia)
+	TipoExtratoComissao = "extrato_comissao" // extrato mensal de comissão do consultor
+)
+
+// Status do job
+const (
+	StatusPendente    = "pendente"
+	StatusProcessando = "processando"
+	StatusConcluido   = "concluido"
+	StatusErro        = "erro"
+)
+
+// maxTentativas antes de o job ficar em erro.
+const maxTentativas = 3
+
+// Job é um pedido de geração de PDF. A renderização roda em background
+// (Processador); o cliente acompanha pelo status e recebe a URL no fim.
+type Job struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Tipo   string `gorm:"size:30;not null" json:"tipo"`
+	Status string `gorm:"size:20;not null;index" json:"status"`
+
+	// Origem dos dados, conforme o tipo
+	NegociacaoID *uint  `gorm:"index" json:"negociacaoId,omitempty"`
+	EstudoVersao uint   `json:"estudoVersao,omitempty"`
+	ConsultorID  *uint  `gorm:"index" json:"consultorId,omitempty"`
+	Competencia  string `gorm:"size:7" json:"competencia,omitempty"` // "AAAA-MM" (extrato)
+
+	// Resultado
+	URL         string     `gorm:"size:500" json:"url,omitempty"`
+	Erro        string     `gorm:"type:text" json:"erro,omitempty"`
+	Tentativas  int        `gorm:"not null;default:0" json:"tentativas"`
+	IniciadoEm  *time.Time `json:"iniciadoEm,omitempty"`
+	ConcluidoEm *time.Time `json:"concluidoEm,omitempty"`
+
+	SolicitadoPorTipo string `gorm:"size:20;not null" json:"solicitadoPorTipo"`
+	SolicitadoPorID   *uint  `json:"solicitadoPorId"`
+}
+
+func (Job) TableName() string { return "pdf_jobs" }
@@ -0,0 +1,284 @@
+// internal/pdf/modelos.go
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/simulacao"
+	"gorm.io/gorm"
+)
+
+var ErrTipoDesconhecido = errors.New("tipo de PDF desconhecido")
+
+// pessoa é o mínimo de consultor/comercial que sai nos documentos.
+type pessoa struct {
+	Nome      string
+	Sobrenome string
+	Email     string
+	CNPJ      string
+}
+
+func (p pessoa) NomeCompleto() string {
+	return strings.TrimSpace(p.Nome + " " + p.Sobrenome)
+}
+
+func buscarConsultor(db *gorm.DB, id uint) (pessoa, error) {
+	var p pessoa
+	err := db.Table("consultors").Select("nome, sobrenome, email, cnpj").Where("id = ?", id).Take(&p).Error
+	return p, err
+}
+
+// renderizar monta o PDF do job e devolve o conteúdo e a chave no storage.
+func renderizar(db *gorm.DB, j *Job, agora time.Time) ([]byte, string, error) {
+	switch j.Tipo {
+	case TipoProposta:
+		return renderizarProposta(db, j, agora)
+	case TipoEstudo:
+		return renderizarEstudo(db, j, agora)
+	case TipoExtratoComissao:
+		return renderizarExtrato(db, j, agora)
+	default:
+		return nil, "", ErrTipoDesconhecido
+	}
+}
+
+/* ================== Proposta comercial ================== */
+
+func renderizarProposta(db *gorm.DB, j *Job, agora time.Time) ([]byte, string, error) {
+	var neg models.Negociacao
+	if err := db.Preload("Produtos").First(&neg, *j.NegociacaoID).Error; err != nil {
+		return nil, "", fmt.Errorf("negociação %d: %w", *j.NegociacaoID, err)
+	}
+	consultor, err := buscarConsultor(db, neg.ConsultorID)
+	if err != nil {
+		return nil, "", fmt.Errorf("consultor %d: %w", neg.ConsultorID, err)
+	}
+	var estudo *simulacao.Estudo
+	var e simulacao.Estudo
+	err = db.Omit("resultado").Where("negociacao_id = ?", neg.ID).Order("versao DESC").Take(&e).Error
+	switch {
+	case err == nil:
+		estudo = &e
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", err
+	}
+
+	d := novoDocumento("Proposta comercial", neg.Nome, agora)
+	d.Secao("Cliente")
+	d.Campos(
+		[2]string{"Razão social", neg.Nome},
+		[2]string{"CNPJ", neg.CNPJ},
+		[2]string{"Contato", neg.Contato},
+		[2]string{"E-mail", neg.Email},
+		[2]string{"Telefone", neg.Telefone},
+		[2]string{"UF", neg.UF},
+	)
+
+	d.Secao("Consultor responsável")
+	d.Campos(
+		[2]string{"Nome", consultor.NomeCompleto()},
+		[2]string{"E-mail", consultor.Email},
+	)
+
+	d.Secao("Produtos")
+	linhas := make([][]string, 0, len(neg.Produtos))
+	for _, p := range neg.Produtos {
+		if !p.Ativo {
+			continue
+		}
+		linhas = append(linhas, []string{p.Tipo, numero(p.Fee, 2), percentual(p.Comissao)})
+	}
+	d.Tabela([]Coluna{
+		{Titulo: "Produto", Largura: 100, Alinhar: "L"},
+		{Titulo: "Fee", Largura: 40, Alinhar: "R"},
+		{Titulo: "Comissão", Largura: 40, Alinhar: "R"},
+	}, linhas, nil)
+
+	if estudo != nil {
+		d.Secao("Economia estimada")
+		d.Campos(
+			[2]string{"Preço da energia", moeda(estudo.Parametros.PrecoEnergiaMWh) + "/MWh"},
+			[2]string{"Custo no mercado cativo", moeda(estudo.CustoCativo)},
+			[2]string{"Custo no mercado livre", moeda(estudo.CustoLivre)},
+			[2]string{"Economia", fmt.Sprintf("%s (%s)", moeda(estudo.Economia), percentual(estudo.EconomiaPercentual))},
+		)
+		d.Paragrafo(fmt.Sprintf("Valores do estudo de economia versão %d, com %d meses de histórico de consumo "+
+			"e tarifas vigentes em %s.", estudo.Versao, estudo.Parametros.Meses, estudo.Parametros.DataBase))
+	}
+
+	d.Secao("Condições")
+	d.Paragrafo("Esta proposta tem caráter indicativo. Preços e condições estão sujeitos à análise de crédito " +
+		"e à confirmação na data de assinatura do contrato.")
+
+	conteudo, err := d.Bytes()
+	return conteudo, fmt.Sprintf("pdf/propostas/%d/proposta-%d.pdf", neg.ID, j.ID), err
+}
+
+/* ================== Estudo de viabilidade ================== */
+
+func renderizarEstudo(db *gorm.DB, j *Job, agora time.Time) ([]byte, string, error) {
+	var e simulacao.Estudo
+	if err := db.Where("negociacao_id = ? AND versao = ?", *j.NegociacaoID, j.EstudoVersao).Take(&e).Error; err != nil {
+		return nil, "", fmt.Errorf("estudo v%d: %w", j.EstudoVersao, err)
+	}
+	var neg struct{ Nome, CNPJ string }
+	if err := db.Table("negociacaos").Select("nome, cnpj").Where("id = ?", e.NegociacaoID).Take(&neg).Error; err != nil {
+		return nil, "", err
+	}
+
+	p, res := e.Parametros, e.Resultado
+	d := novoDocumento("Estudo de viabilidade", fmt.Sprintf("%s — versão %d", neg.Nome, e.Versao), agora)
+
+	d.Secao("Resumo")
+	d.Campos(
+		[2]string{"Cliente", neg.Nome},
+		[2]string{"CNPJ", neg.CNPJ},
+		[2]string{"Consumo no período", numero(res.ConsumoKWh, 0) + " kWh"},
+		[2]string{"Custo no mercado cativo", moeda(res.CustoCativo)},
+		[2]string{"Custo no mercado livre", moeda(res.CustoLivre)},
+		[2]string{"Economia", fmt.Sprintf("%s (%s)", moeda(res.Economia), percentual(res.EconomiaPercentual))},
+	)
+
+	d.Secao("Premissas")
+	d.Campos(
+		[2]string{"Preço da energia", moeda(p.PrecoEnergiaMWh) + "/MWh"},
+		[2]string{"Desconto na TUSD", percentual(p.DescontoTUSD)},
+		[2]string{"Tributos", percentual(p.Tributos)},
+		[2]string{"Perdas", percentual(p.PercentualPerdas())},
+		[2]string{"Encargos", moeda(p.EncargosMWh) + "/MWh"},
+		[2]string{"Tarifas vigentes em", p.DataBase},
+	)
+
+	colunasValores := []Coluna{
+		{Titulo: "Mês", Largura: 36, Alinhar: "L"},
+		{Titulo: "Consumo (kWh)", Largura: 36, Alinhar: "R"},
+		{Titulo: "Cativo", Largura: 36, Alinhar: "R"},
+		{Titulo: "Livre", Largura: 36, Alinhar: "R"},
+		{Titulo: "Economia", Largura: 36, Alinhar: "R"},
+	}
+	d.Secao("Mês a mês")
+	linhas := make([][]string, 0, len(res.Meses))
+	for _, m := range res.Meses {
+		linhas = append(linhas, []string{mesAno(m.Referencia), numero(m.ConsumoKWh, 0),
+			moeda(m.CustoCativo), moeda(m.CustoLivre), moeda(m.Economia)})
+	}
+	d.Tabela(colunasValores, linhas, []string{"Total", numero(res.ConsumoKWh, 0),
+		moeda(res.CustoCativo), moeda(res.CustoLivre), moeda(res.Economia)})
+
+	d.Secao("Por unidade consumidora")
+	linhas = make([][]string, 0, len(res.Unidades))
+	for _, u := range res.Unidades {
+		linhas = append(linhas, []string{u.CodigoInstalacao, u.Distribuidora,
+			strings.TrimSpace(u.Subgrupo + " " + u.Modalidade), moeda(u.CustoCativo), moeda(u.CustoLivre), moeda(u.Economia)})
+	}
+	d.Tabela([]Coluna{
+		{Titulo: "Instalação", Largura: 30, Alinhar: "L"},
+		{Titulo: "Distribuidora", Largura: 36, Alinhar: "L"},
+		{Titulo: "Tarifa", Largura: 24, Alinhar: "L"},
+		{Titulo: "Cativo", Largura: 30, Alinhar: "R"},
+		{Titulo: "Livre", Largura: 30, Alinhar: "R"},
+		{Titulo: "Economia", Largura: 30, Alinhar: "R"},
+	}, linhas, nil)
+
+	if len(res.Avisos) > 0 {
+		d.Secao("Observações")
+		for _, a := range res.Avisos {
+			d.Paragrafo("• " + a)
+		}
+	}
+
+	conteudo, err := d.Bytes()
+	return conteudo, fmt.Sprintf("pdf/estudos/%d/estudo-v%d-%d.pdf", e.NegociacaoID, e.Versao, j.ID), err
+}
+
+/* ================== Extrato mensal de comissão ================== */
+
+// linhaExtrato é uma parcela do consultor no mês.
+type linhaExtrato struct {
+	ID             uint
+	Valor          float64
+	Status         string
+	DataVencimento time.Time
+	DataPagamento  *time.Time
+	NegociacaoID   uint
+	NegociacaoNome string
+}
+
+// parcelasDoMes lista as parcelas do consultor com vencimento na competência.
+func parcelasDoMes(db *gorm.DB, consultorID uint, inicio time.Time) ([]linhaExtrato, error) {
+	var linhas []linhaExtrato
+	err := db.Table("parcela_comissaos AS p").
+		Select("p.id, p.valor, p.status, p.data_vencimento, p.data_pagamento, n.id AS negociacao_id, n.nome AS negociacao_nome").
+		Joins("JOIN calculo_comissaos c ON c.id = p.calculo_comissao_id AND c.deleted_at IS NULL").
+		Joins("JOIN negociacaos n ON n.id = c.negociacao_id AND n.deleted_at IS NULL").
+		Where("COALESCE(p.consultor_id, n.consultor_id) = ?", consultorID).
+		Where("p.data_vencimento >= ? AND p.data_vencimento < ?", inicio, inicio.AddDate(0, 1, 0)).
+		Order("p.data_vencimento ASC, n.nome ASC, p.id ASC").
+		Scan(&linhas).Error
+	return linhas, err
+}
+
+func renderizarExtrato(db *gorm.DB, j *Job, agora time.Time) ([]byte, string, error) {
+	inicio, err := time.Parse("2006-01", j.Competencia)
+	if err != nil {
+		return nil, "", fmt.Errorf("competência inválida %q", j.Competencia)
+	}
+	consultor, err := buscarConsultor(db, *j.ConsultorID)
+	if err != nil {
+		return nil, "", fmt.Errorf("consultor %d: %w", *j.ConsultorID, err)
+	}
+	parcelas, err := parcelasDoMes(db, *j.ConsultorID, inicio)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var pago, pendente, cancelado float64
+	linhas := make([][]string, 0, len(parcelas))
+	for _, p := range parcelas {
+		pagamento := ""
+		if p.DataPagamento != nil {
+			pagamento = data(*p.DataPagamento)
+		}
+		linhas = append(linhas, []string{p.NegociacaoNome, data(p.DataVencimento), p.Status, pagamento, moeda(p.Valor)})
+		switch p.Status {
+		case "Pago":
+			pago += p.Valor
+		case "Cancelada":
+			cancelado += p.Valor
+		default:
+			pendente += p.Valor
+		}
+	}
+
+	d := novoDocumento("Extrato de comissão", fmt.Sprintf("%s — %s", consultor.NomeCompleto(), mesAno(inicio)), agora)
+	d.Secao("Consultor")
+	d.Campos(
+		[2]string{"Nome", consultor.NomeCompleto()},
+		[2]string{"CNPJ", consultor.CNPJ},
+		[2]string{"E-mail", consultor.Email},
+		[2]string{"Competência", mesAno(inicio)},
+	)
+
+	d.Secao("Resumo")
+	d.Campos(
+		[2]string{"Pago", moeda(pago)},
+		[2]string{"A receber", moeda(pendente)},
+		[2]string{"Cancelado", moeda(cancelado)},
+	)
+
+	d.Secao("Parcelas")
+	d.Tabela([]Coluna{
+		{Titulo: "Negociação", Largura: 62, Alinhar: "L"},
+		{Titulo: "Vencimento", Largura: 28, Alinhar: "C"},
+		{Titulo: "Status", Largura: 28, Alinhar: "L"},
+		{Titulo: "Pagamento", Largura: 28, Alinhar: "C"},
+		{Titulo: "Valor", Largura: 34, Alinhar: "R"},
+	}, linhas, []string{"Total (exceto canceladas)", "", "", "", moeda(pago + pendente)})
+
+	conteudo, err := d.Bytes()
+	return conteudo, fmt.Sprintf("pdf/extratos/%d/%s-%d.pdf", *j.ConsultorID, j.Competencia, j.ID), err
+}
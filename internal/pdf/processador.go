@@ -0,0 +1,208 @@
+// internal/pdf/processador.go
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+	"gorm.io/gorm"
+)
+
+// tempoMaxProcessando: job "processando" há mais tempo volta para a fila.
+const tempoMaxProcessando = 10 * time.Minute
+
+// Processador consome a fila de jobs de PDF em background: renderiza,
+// grava no storage e vincula o arquivo ao anexo correspondente.
+type Processador struct {
+	DB          *gorm.DB
+	Storage     storage.Storage
+	Notificador notificacao.Notificador
+	Repository  Repository
+	Intervalo   time.Duration // varredura da fila quando ninguém acorda o processador
+
+	acordar chan struct{}
+}
+
+// NewProcessadorFromEnv lê PDF_PROCESSADOR_INTERVALO (padrão 15s), no formato de time.ParseDuration.
+func NewProcessadorFromEnv(db *gorm.DB, store storage.Storage, notif notificacao.Notificador) *Processador {
+	intervalo := 15 * time.Second
+	if v := os.Getenv("PDF_PROCESSADOR_INTERVALO"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			intervalo = d
+		} else {
+			log.Printf("[pdf] PDF_PROCESSADOR_INTERVALO inválido (%q); usando %s", v, intervalo)
+		}
+	}
+	return &Processador{
+		DB:          db,
+		Storage:     store,
+		Notificador: notif,
+		Repository:  NewRepository(),
+		Intervalo:   intervalo,
+		acordar:     make(chan struct{}, 1),
+	}
+}
+
+// Acordar avisa que há job novo, sem esperar a próxima varredura.
+func (p *Processador) Acordar() {
+	select {
+	case p.acordar <- struct{}{}:
+	default:
+	}
+}
+
+// Iniciar roda o processador em background até o contexto ser cancelado.
+func (p *Processador) Iniciar(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(p.Intervalo)
+		defer ticker.Stop()
+		for {
+			p.Executar(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-p.acordar:
+			}
+		}
+	}()
+}
+
+// Executar processa a fila até ela esvaziar.
+func (p *Processador) Executar(ctx context.Context) {
+	if n, err := p.Repository.LiberarTravados(p.DB, time.Now().Add(-tempoMaxProcessando)); err != nil {
+		log.Printf("[pdf] erro ao liberar jobs travados: %v", err)
+	} else if n > 0 {
+		log.Printf("[pdf] %d job(s) travado(s) devolvido(s) à fila", n)
+	}
+
+	for ctx.Err() == nil {
+		j, err := p.Repository.Reivindicar(p.DB, time.Now())
+		if err != nil {
+			log.Printf("[pdf] erro ao buscar job: %v", err)
+			return
+		}
+		if j == nil {
+			return
+		}
+		p.processar(ctx, j)
+	}
+}
+
+func (p *Processador) processar(ctx context.Context, j *Job) {
+	url, err := p.gerar(ctx, j)
+	agora := time.Now()
+	if err != nil {
+		log.Printf("[pdf] job %d (%s), tentativa %d: %v", j.ID, j.Tipo, j.Tentativas, err)
+		if err := p.Repository.Falhar(p.DB, j, err, agora); err != nil {
+			log.Printf("[pdf] erro ao registrar falha do job %d: %v", j.ID, err)
+		}
+		if j.Status == StatusErro {
+			p.notificar(j, "pdf_erro", "Não foi possível gerar o PDF", j.Erro)
+		}
+		return
+	}
+	if err := p.Repository.Concluir(p.DB, j, url, agora); err != nil {
+		log.Printf("[pdf] erro ao concluir job %d: %v", j.ID, err)
+		return
+	}
+	p.notificar(j, "pdf_gerado", "PDF pronto", url)
+}
+
+// gerar renderiza, grava e vincula o PDF; devolve a URL pública.
+func (p *Processador) gerar(ctx context.Context, j *Job) (string, error) {
+	conteudo, chave, err := renderizar(p.DB, j, time.Now())
+	if err != nil {
+		return "", err
+	}
+	// a chave dos modelos usa IDs sequenciais: o sufixo impede enumerar os PDFs
+	sufixo, err := storage.SufixoAleatorio()
+	if err != nil {
+		return "", err
+	}
+	chave = strings.TrimSuffix(chave, ".pdf") + "-" + sufixo + ".pdf"
+	url, err := p.Storage.Put(ctx, chave, bytes.NewReader(conteudo), int64(len(conteudo)), "application/pdf")
+	if err != nil {
+		return "", fmt.Errorf("storage: %w", err)
+	}
+	if err := p.vincular(j, url); err != nil {
+		return "", fmt.Errorf("vincular anexo: %w", err)
+	}
+	return url, nil
+}
+
+// vincular leva a URL para o anexo correspondente: proposta e estudo entram
+// no checklist da negociação (como enviados por quem pediu o PDF); o extrato
+// vai para o anexo das parcelas do mês que ainda não têm outro arquivo.
+func (p *Processador) vincular(j *Job, url string) error {
+	switch j.Tipo {
+	case TipoProposta, TipoEstudo:
+		codigo := models.DocProposta
+		if j.Tipo == TipoEstudo {
+			codigo = models.DocEstudoViabilidade
+		}
+		_, err := documento.Definir(p.DB, *j.NegociacaoID, codigo, url, "", models.StatusEnviado, solicitante(j))
+		if errors.Is(err, documento.ErrTipoDesconhecido) {
+			log.Printf("[pdf] job %d: tipo de documento %q fora do catálogo; PDF não vinculado", j.ID, codigo)
+			return nil
+		}
+		return err
+
+	case TipoExtratoComissao:
+		inicio, err := time.Parse("2006-01", j.Competencia)
+		if err != nil {
+			return err
+		}
+		return p.DB.Exec(`
+			UPDATE parcela_comissaos SET anexo = ?, updated_at = NOW()
+			WHERE id IN (
+				SELECT p.id FROM parcela_comissaos p
+				JOIN calculo_comissaos c ON c.id = p.calculo_comissao_id AND c.deleted_at IS NULL
+				JOIN negociacaos n ON n.id = c.negociacao_id AND n.deleted_at IS NULL
+				WHERE COALESCE(p.consultor_id, n.consultor_id) = ? AND p.data_vencimento >= ? AND p.data_vencimento < ?
+			)
+			AND (COALESCE(anexo, '') = '' OR anexo LIKE ?)`,
+			url, *j.ConsultorID, inicio, inicio.AddDate(0, 1, 0), p.Storage.URL("pdf/extratos/")+"%").Error
+	}
+	return nil
+}
+
+func (p *Processador) notificar(j *Job, tipo, titulo, mensagem string) {
+	if p.Notificador == nil || j.SolicitadoPorID == nil {
+		return
+	}
+	n := notificacao.Notificacao{
+		Tipo:             tipo,
+		DestinatarioTipo: j.SolicitadoPorTipo,
+		DestinatarioID:   *j.SolicitadoPorID,
+		Titulo:           titulo,
+		Mensagem:         mensagem,
+		Dados:            map[string]any{"jobId": j.ID, "tipo": j.Tipo, "url": j.URL},
+	}
+	if j.NegociacaoID != nil {
+		n.NegociacaoID = *j.NegociacaoID
+	}
+	if err := p.Notificador.Notificar(n); err != nil {
+		log.Printf("[pdf] erro ao notificar job %d: %v", j.ID, err)
+	}
+}
+
+// solicitante é o ator que pediu o PDF.
+func solicitante(j *Job) auth.Ator {
+	a := auth.Ator{Tipo: j.SolicitadoPorTipo}
+	if j.SolicitadoPorID != nil {
+		a.ID = *j.SolicitadoPorID
+	}
+	return a
+}
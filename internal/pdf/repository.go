@@ -0,0 +1,98 @@
+// internal/pdf/repository.go
+package pdf
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository define operações de persistência dos jobs de PDF
+type Repository interface {
+	Criar(db *gorm.DB, j *Job) error
+	BuscarPorID(db *gorm.DB, id uint) (*Job, error)
+	ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]Job, error)
+
+	Reivindicar(db *gorm.DB, agora time.Time) (*Job, error)
+	Concluir(db *gorm.DB, j *Job, url string, agora time.Time) error
+	Falhar(db *gorm.DB, j *Job, causa error, agora time.Time) error
+	LiberarTravados(db *gorm.DB, antesDe time.Time) (int64, error)
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) Criar(db *gorm.DB, j *Job) error {
+	j.Status = StatusPendente
+	return db.Create(j).Error
+}
+
+func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*Job, error) {
+	var j Job
+	if err := db.First(&j, id).Error; err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]Job, error) {
+	list := []Job{}
+	err := db.Where("negociacao_id = ?", negociacaoID).Order("created_at DESC").Limit(100).Find(&list).Error
+	return list, err
+}
+
+// Reivindicar marca o próximo job pendente como "processando" e o devolve
+// (nil se não houver). SKIP LOCKED deixa várias instâncias da API
+// processarem a fila sem pegar o mesmo job.
+func (r *repositoryImpl) Reivindicar(db *gorm.DB, agora time.Time) (*Job, error) {
+	var j Job
+	err := db.Raw(`
+		UPDATE pdf_jobs SET status = ?, tentativas = tentativas + 1, iniciado_em = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM pdf_jobs WHERE status = ?
+			ORDER BY id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		StatusProcessando, agora, agora, StatusPendente).Scan(&j).Error
+	if err != nil || j.ID == 0 {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *repositoryImpl) Concluir(db *gorm.DB, j *Job, url string, agora time.Time) error {
+	j.Status, j.URL, j.Erro, j.ConcluidoEm = StatusConcluido, url, "", &agora
+	return db.Model(j).Updates(map[string]any{
+		"status": j.Status, "url": url, "erro": "", "concluido_em": agora,
+	}).Error
+}
+
+// Falhar devolve o job para a fila ou, esgotadas as tentativas, o deixa em erro.
+func (r *repositoryImpl) Falhar(db *gorm.DB, j *Job, causa error, agora time.Time) error {
+	j.Status, j.Erro = StatusPendente, causa.Error()
+	if j.Tentativas >= maxTentativas {
+		j.Status, j.ConcluidoEm = StatusErro, &agora
+	}
+	return db.Model(j).Updates(map[string]any{
+		"status": j.Status, "erro": j.Erro, "concluido_em": j.ConcluidoEm,
+	}).Error
+}
+
+// LiberarTravados devolve à fila os jobs que ficaram "processando" (ex.:
+// instância reiniciada no meio da renderização).
+func (r *repositoryImpl) LiberarTravados(db *gorm.DB, antesDe time.Time) (int64, error) {
+	res := db.Exec(`
+		UPDATE pdf_jobs
+		SET status = CASE WHEN tentativas < ? THEN ? ELSE ? END,
+		    erro = 'processamento interrompido',
+		    updated_at = NOW()
+		WHERE status = ? AND iniciado_em < ?`,
+		maxTentativas, StatusPendente, StatusErro, StatusProcessando, antesDe)
+	return res.RowsAffected, res.Error
+}
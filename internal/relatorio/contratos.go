@@ -0,0 +1,65 @@
+// internal/relatorio/contratos.go
+package relatorio
+
+import (
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/contrato"
+	"gorm.io/gorm"
+)
+
+// ContratoVencendo é um contrato cujo suprimento termina no período.
+type ContratoVencendo struct {
+	ContratoID       uint      `json:"contratoId"`
+	NegociacaoID     uint      `json:"negociacaoId"`
+	Cliente          string    `json:"cliente"`
+	CNPJ             string    `json:"cnpj"`
+	UF               string    `json:"uf"`
+	Tipo             string    `json:"tipo"`
+	Status           string    `json:"status"`
+	Valor            float64   `json:"valor"`
+	FimSuprimento    time.Time `json:"fimSuprimento"`
+	DiasRestantes    int       `json:"diasRestantes"`
+	ConsultorID      uint      `json:"consultorId"`
+	Consultor        string    `json:"consultor"`
+	ComercialID      *uint     `json:"comercialId"`
+	Renovado         bool      `json:"renovado"`
+	RenovadoPorID    *uint     `json:"renovadoPorId,omitempty"` // contrato sucessor
+	AvisoEnviadoDias int       `json:"avisoEnviadoDias"`        // menor antecedência já avisada; 0 = nenhuma
+}
+
+// ContratosVencendo lista os contratos não cancelados cujo fim do suprimento
+// cai no período (padrão: hoje até 180 dias). O período vale para
+// fim_suprimento, não para a criação da negociação.
+func ContratosVencendo(db *gorm.DB, f Filtros) ([]ContratoVencendo, error) {
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	de, ate := hoje, hoje.AddDate(0, 0, 180)
+	if f.De != nil {
+		de = *f.De
+	}
+	if f.Ate != nil {
+		ate = *f.Ate
+	}
+	f.De, f.Ate = nil, nil
+	where, args := f.where()
+
+	sql := `SELECT ct.id AS contrato_id, ct.negociacao_id, n.nome AS cliente, n.cnpj, n.uf,
+			ct.tipo, ct.status, ct.valor, ct.fim_suprimento,
+			(ct.fim_suprimento::date - CURRENT_DATE) AS dias_restantes,
+			n.consultor_id, TRIM(c.nome || ' ' || COALESCE(c.sobrenome, '')) AS consultor, c.comercial_id,
+			ct.renovado_por_id IS NOT NULL AS renovado, ct.renovado_por_id,
+			ct.alerta_vencimento_dias AS aviso_enviado_dias
+		FROM contratos ct
+		JOIN negociacaos n ON n.id = ct.negociacao_id
+		JOIN consultors c ON c.id = n.consultor_id
+		WHERE ct.deleted_at IS NULL AND ct.status <> ?
+			AND ct.fim_suprimento >= ? AND ct.fim_suprimento < ?
+			AND ` + where + `
+		ORDER BY ct.fim_suprimento ASC, ct.id`
+
+	all := append([]any{contrato.StatusCancelado, de, ate.AddDate(0, 0, 1)}, args...)
+	out := []ContratoVencendo{}
+	err := db.Raw(sql, all...).Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,110 @@
+// internal/relatorio/filtros.go
+package relatorio
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+)
+
+const layoutData = "2006-01-02"
+
+// Filtros recortam as negociações consideradas nos relatórios.
+// O período é aplicado sobre a data de criação da negociação.
+type Filtros struct {
+	De          *time.Time `json:"de,omitempty"`
+	Ate         *time.Time `json:"ate,omitempty"` // inclusivo (dia inteiro)
+	ComercialID uint       `json:"comercialId,omitempty"`
+	ConsultorID uint       `json:"consultorId,omitempty"`
+	UF          string     `json:"uf,omitempty"`
+	TipoProduto string     `json:"tipoProduto,omitempty"`
+	Origem      string     `json:"origem,omitempty"` // cadastro | indicacao | renovacao
+}
+
+// FiltrosDaQuery lê ?de=AAAA-MM-DD&ate=AAAA-MM-DD&comercialId=&consultorId=&uf=&tipoProduto=&origem=
+func FiltrosDaQuery(q url.Values) (Filtros, error) {
+	var f Filtros
+	for _, c := range []struct {
+		nome string
+		dst  **time.Time
+	}{{"de", &f.De}, {"ate", &f.Ate}} {
+		if v := strings.TrimSpace(q.Get(c.nome)); v != "" {
+			t, err := time.ParseInLocation(layoutData, v, time.Local)
+			if err != nil {
+				return f, errors.New("datas devem estar no formato AAAA-MM-DD")
+			}
+			*c.dst = &t
+		}
+	}
+	for _, c := range []struct {
+		nome string
+		dst  *uint
+	}{{"comercialId", &f.ComercialID}, {"consultorId", &f.ConsultorID}} {
+		if v := strings.TrimSpace(q.Get(c.nome)); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return f, errors.New(c.nome + " inválido")
+			}
+			*c.dst = uint(n)
+		}
+	}
+	f.UF = strings.ToUpper(strings.TrimSpace(q.Get("uf")))
+	f.TipoProduto = strings.TrimSpace(q.Get("tipoProduto"))
+	f.Origem = strings.ToLower(strings.TrimSpace(q.Get("origem")))
+	switch f.Origem {
+	case "", models.OrigemCadastro, models.OrigemIndicacao, models.OrigemRenovacao:
+	default:
+		return f, errors.New("origem inválida")
+	}
+	return f, nil
+}
+
+// where monta as condições sobre `n` (negociacaos) e `c` (consultors).
+func (f Filtros) where() (string, []any) {
+	conds := []string{"n.deleted_at IS NULL"}
+	var args []any
+	if f.De != nil {
+		conds = append(conds, "n.created_at >= ?")
+		args = append(args, *f.De)
+	}
+	if f.Ate != nil {
+		conds = append(conds, "n.created_at < ?")
+		args = append(args, f.Ate.AddDate(0, 0, 1))
+	}
+	if f.ComercialID != 0 {
+		conds = append(conds, "c.comercial_id = ?")
+		args = append(args, f.ComercialID)
+	}
+	if f.ConsultorID != 0 {
+		conds = append(conds, "n.consultor_id = ?")
+		args = append(args, f.ConsultorID)
+	}
+	if f.UF != "" {
+		conds = append(conds, "UPPER(n.uf) = ?")
+		args = append(args, f.UF)
+	}
+	if f.TipoProduto != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM produtos p WHERE p.negociacao_id = n.id AND LOWER(p.tipo) = LOWER(?))")
+		args = append(args, f.TipoProduto)
+	}
+	if f.Origem != "" {
+		conds = append(conds, "n.origem = ?")
+		args = append(args, f.Origem)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// cteNegociacoes é o CTE "negs" com as negociações filtradas.
+func (f Filtros) cteNegociacoes() (string, []any) {
+	where, args := f.where()
+	return `negs AS (
+		SELECT n.id, n.status, n.created_at
+		FROM negociacaos n
+		JOIN consultors c ON c.id = n.consultor_id
+		WHERE ` + where + `
+	)`, args
+}
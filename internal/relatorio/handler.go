@@ -0,0 +1,84 @@
+// internal/relatorio/handler.go
+package relatorio
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"gorm.io/gorm"
+)
+
+// Handler expõe os relatórios do pipeline (comercial, recortado ao seu time, ou admin).
+type Handler struct {
+	DB *gorm.DB
+}
+
+// NewHandler cria o handler de relatórios
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db}
+}
+
+// GET /relatorios/pipeline
+func (h *Handler) Pipeline(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return GerarPipeline(h.DB, f) })
+}
+
+// GET /relatorios/pipeline/status
+func (h *Handler) PorStatus(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return PorStatus(h.DB, f) })
+}
+
+// GET /relatorios/pipeline/conversao
+func (h *Handler) Conversao(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return ConversaoEtapas(h.DB, f) })
+}
+
+// GET /relatorios/pipeline/tempo
+func (h *Handler) Tempo(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return TempoPorEtapa(h.DB, f) })
+}
+
+// GET /relatorios/indicacoes
+func (h *Handler) Indicacoes(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return Indicacoes(h.DB, f) })
+}
+
+// GET /relatorios/contratos/vencendo
+func (h *Handler) ContratosVencendo(w http.ResponseWriter, r *http.Request) {
+	h.responder(w, r, func(f Filtros) (any, error) { return ContratosVencendo(h.DB, f) })
+}
+
+func (h *Handler) responder(w http.ResponseWriter, r *http.Request, gerar func(Filtros) (any, error)) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	f, err := FiltrosDaQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Comercial que não é admin só vê o próprio time.
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if !e.Todos {
+		if f.ComercialID != 0 && f.ComercialID != e.ComercialID {
+			http.Error(w, "acesso negado", http.StatusForbidden)
+			return
+		}
+		f.ComercialID = e.ComercialID
+	}
+
+	res, err := gerar(f)
+	if err != nil {
+		http.Error(w, "Erro ao gerar relatório", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,91 @@
+// internal/relatorio/indicacoes.go
+package relatorio
+
+import (
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+)
+
+// IndicacaoResumo é o resultado do link de indicação de um consultor.
+type IndicacaoResumo struct {
+	ConsultorID  uint    `json:"consultorId"`
+	Consultor    string  `json:"consultor"`
+	Leads        int64   `json:"leads"`       // envios válidos do formulário
+	Negociacoes  int64   `json:"negociacoes"` // negociações com origem "indicacao"
+	Abertas      int64   `json:"abertas"`     // ainda em andamento
+	Fechadas     int64   `json:"fechadas"`
+	Canceladas   int64   `json:"canceladas"`
+	Conversao    float64 `json:"conversao"`    // fechadas / negociações (0–1)
+	ValorFechado float64 `json:"valorFechado"` // contratos das fechadas
+}
+
+// Indicacoes agrupa por consultor indicador os leads recebidos e o destino
+// das negociações que eles geraram. O período vale para a data do lead e
+// para a criação da negociação; o filtro de origem é ignorado.
+func Indicacoes(db *gorm.DB, f Filtros) ([]IndicacaoResumo, error) {
+	f.Origem = models.OrigemIndicacao
+	where, args := f.where()
+
+	leadConds, leadArgs := "c.deleted_at IS NULL", []any{}
+	if f.De != nil {
+		leadConds += " AND l.created_at >= ?"
+		leadArgs = append(leadArgs, *f.De)
+	}
+	if f.Ate != nil {
+		leadConds += " AND l.created_at < ?"
+		leadArgs = append(leadArgs, f.Ate.AddDate(0, 0, 1))
+	}
+	if f.ComercialID != 0 {
+		leadConds += " AND c.comercial_id = ?"
+		leadArgs = append(leadArgs, f.ComercialID)
+	}
+	if f.ConsultorID != 0 {
+		leadConds += " AND l.consultor_id = ?"
+		leadArgs = append(leadArgs, f.ConsultorID)
+	}
+
+	sql := `WITH leads AS (
+		SELECT l.consultor_id, COUNT(*) AS total
+		FROM leads_indicacao l
+		JOIN consultors c ON c.id = l.consultor_id
+		WHERE ` + leadConds + `
+		GROUP BY l.consultor_id
+	),
+	negs AS (
+		SELECT COALESCE(n.indicado_por_id, n.consultor_id) AS consultor_id,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE n.status NOT IN (?, ?)) AS abertas,
+			COUNT(*) FILTER (WHERE n.status = ?) AS fechadas,
+			COUNT(*) FILTER (WHERE n.status = ?) AS canceladas,
+			COALESCE(SUM((SELECT SUM(ct.valor) FROM contratos ct
+				WHERE ct.negociacao_id = n.id AND ct.deleted_at IS NULL))
+				FILTER (WHERE n.status = ?), 0) AS valor_fechado
+		FROM negociacaos n
+		JOIN consultors c ON c.id = n.consultor_id
+		WHERE ` + where + `
+		GROUP BY 1
+	)
+	SELECT c.id AS consultor_id,
+		TRIM(c.nome || ' ' || COALESCE(c.sobrenome, '')) AS consultor,
+		COALESCE(l.total, 0) AS leads,
+		COALESCE(n.total, 0) AS negociacoes,
+		COALESCE(n.abertas, 0) AS abertas,
+		COALESCE(n.fechadas, 0) AS fechadas,
+		COALESCE(n.canceladas, 0) AS canceladas,
+		COALESCE(ROUND(n.fechadas::numeric / NULLIF(n.total, 0), 4), 0)::float8 AS conversao,
+		COALESCE(n.valor_fechado, 0) AS valor_fechado
+	FROM consultors c
+	LEFT JOIN leads l ON l.consultor_id = c.id
+	LEFT JOIN negs n ON n.consultor_id = c.id
+	WHERE l.consultor_id IS NOT NULL OR n.consultor_id IS NOT NULL
+	ORDER BY fechadas DESC, negociacoes DESC, leads DESC, c.id`
+
+	all := append(leadArgs,
+		models.StatusFechada, models.StatusCancelada,
+		models.StatusFechada, models.StatusCancelada, models.StatusFechada)
+	all = append(all, args...)
+
+	out := []IndicacaoResumo{}
+	err := db.Raw(sql, all...).Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,165 @@
+// internal/relatorio/pipeline.go
+package relatorio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+)
+
+// StatusResumo é a contagem e o valor das negociações numa etapa.
+type StatusResumo struct {
+	Status           string  `json:"status"`
+	Quantidade       int64   `json:"quantidade"`
+	ValorEstimado    float64 `json:"valorEstimado"`    // soma dos contratos cadastrados
+	ComissaoEstimada float64 `json:"comissaoEstimada"` // soma dos cálculos de comissão
+	TarefasAtrasadas int64   `json:"tarefasAtrasadas"` // tarefas abertas já vencidas
+}
+
+// Conversao é a passagem de uma etapa para a seguinte.
+type Conversao struct {
+	De         string  `json:"de"`
+	Para       string  `json:"para"`
+	Alcancaram int64   `json:"alcancaram"` // chegaram em "de"
+	Avancaram  int64   `json:"avancaram"`  // chegaram em "para"
+	Taxa       float64 `json:"taxa"`       // avancaram / alcancaram (0–1)
+}
+
+// TempoEtapa é o tempo médio de permanência numa etapa, pelo histórico.
+type TempoEtapa struct {
+	Status      string  `json:"status"`
+	MediaDias   float64 `json:"mediaDias"`   // só passagens concluídas
+	Passagens   int64   `json:"passagens"`   // passagens concluídas
+	EmAndamento int64   `json:"emAndamento"` // negociações ainda nessa etapa
+}
+
+// Pipeline reúne os três relatórios.
+type Pipeline struct {
+	Filtros       Filtros        `json:"filtros"`
+	PorStatus     []StatusResumo `json:"porStatus"`
+	Conversao     []Conversao    `json:"conversao"`
+	TempoPorEtapa []TempoEtapa   `json:"tempoPorEtapa"`
+}
+
+// cteEtapas devolve o CTE "etapas(nome, ordem)" com o pipeline em ordem.
+func cteEtapas() string {
+	vals := make([]string, len(models.EtapasPipeline))
+	for i, e := range models.EtapasPipeline {
+		vals[i] = fmt.Sprintf("('%s', %d)", strings.ReplaceAll(e, "'", "''"), i)
+	}
+	return "etapas(nome, ordem) AS (VALUES " + strings.Join(vals, ", ") + ")"
+}
+
+// PorStatus conta negociações e soma valores por status atual.
+func PorStatus(db *gorm.DB, f Filtros) ([]StatusResumo, error) {
+	cte, args := f.cteNegociacoes()
+	sql := `WITH ` + cte + `,
+	valores AS (
+		SELECT negs.id,
+			COALESCE((SELECT SUM(ct.valor) FROM contratos ct
+				WHERE ct.negociacao_id = negs.id AND ct.deleted_at IS NULL), 0) AS valor,
+			COALESCE((SELECT SUM(cc.total_receber) FROM calculo_comissaos cc
+				WHERE cc.negociacao_id = negs.id AND cc.deleted_at IS NULL), 0) AS comissao,
+			(SELECT COUNT(*) FROM tarefas t WHERE t.negociacao_id = negs.id AND t.deleted_at IS NULL
+				AND NOT t.concluida AND t.vencimento < NOW()) AS atrasadas
+		FROM negs
+	)
+	SELECT negs.status AS status,
+		COUNT(*) AS quantidade,
+		SUM(v.valor) AS valor_estimado,
+		SUM(v.comissao) AS comissao_estimada,
+		SUM(v.atrasadas) AS tarefas_atrasadas
+	FROM negs
+	JOIN valores v ON v.id = negs.id
+	GROUP BY negs.status
+	ORDER BY negs.status`
+
+	out := []StatusResumo{}
+	err := db.Raw(sql, args...).Scan(&out).Error
+	return out, err
+}
+
+// ConversaoEtapas calcula, para cada par de etapas consecutivas, quantas
+// negociações chegaram na primeira e quantas chegaram na seguinte. "Chegou"
+// considera o histórico de status e, para dados antigos sem histórico, a
+// posição do status atual.
+func ConversaoEtapas(db *gorm.DB, f Filtros) ([]Conversao, error) {
+	cte, args := f.cteNegociacoes()
+	sql := `WITH ` + cte + `, ` + cteEtapas() + `,
+	alcance AS (
+		SELECT negs.id, MAX(e.ordem) AS max_ordem
+		FROM negs
+		LEFT JOIN negociacao_status_historicos h ON h.negociacao_id = negs.id
+		JOIN etapas e ON e.nome = h.status_novo OR e.nome = negs.status
+		GROUP BY negs.id
+	),
+	por_etapa AS (
+		SELECT e.nome, e.ordem,
+			COUNT(a.id) FILTER (WHERE a.max_ordem >= e.ordem) AS alcancaram
+		FROM etapas e
+		CROSS JOIN alcance a
+		GROUP BY e.nome, e.ordem
+	),
+	com_proxima AS (
+		SELECT nome AS de,
+			LEAD(nome) OVER (ORDER BY ordem) AS para,
+			alcancaram,
+			LEAD(alcancaram) OVER (ORDER BY ordem) AS avancaram,
+			ordem
+		FROM por_etapa
+	)
+	SELECT de, para, alcancaram, avancaram,
+		COALESCE(ROUND(avancaram::numeric / NULLIF(alcancaram, 0), 4), 0)::float8 AS taxa
+	FROM com_proxima
+	WHERE para IS NOT NULL
+	ORDER BY ordem`
+
+	out := []Conversao{}
+	err := db.Raw(sql, args...).Scan(&out).Error
+	return out, err
+}
+
+// TempoPorEtapa mede quanto tempo as negociações ficam em cada etapa, usando
+// o intervalo entre uma transição e a seguinte no histórico.
+func TempoPorEtapa(db *gorm.DB, f Filtros) ([]TempoEtapa, error) {
+	cte, args := f.cteNegociacoes()
+	sql := `WITH ` + cte + `, ` + cteEtapas() + `,
+	estadias AS (
+		SELECT h.status_novo AS status,
+			h.created_at AS entrada,
+			LEAD(h.created_at) OVER (PARTITION BY h.negociacao_id ORDER BY h.created_at, h.id) AS saida
+		FROM negociacao_status_historicos h
+		JOIN negs ON negs.id = h.negociacao_id
+	)
+	SELECT e.nome AS status,
+		COALESCE(ROUND((AVG(EXTRACT(EPOCH FROM (s.saida - s.entrada))) FILTER (WHERE s.saida IS NOT NULL) / 86400)::numeric, 2), 0)::float8 AS media_dias,
+		COUNT(s.saida) AS passagens,
+		COUNT(*) FILTER (WHERE s.entrada IS NOT NULL AND s.saida IS NULL
+			AND e.ordem < (SELECT MAX(ordem) FROM etapas)) AS em_andamento
+	FROM etapas e
+	LEFT JOIN estadias s ON s.status = e.nome
+	GROUP BY e.nome, e.ordem
+	ORDER BY e.ordem`
+
+	out := []TempoEtapa{}
+	err := db.Raw(sql, args...).Scan(&out).Error
+	return out, err
+}
+
+// GerarPipeline roda os três relatórios com os mesmos filtros.
+func GerarPipeline(db *gorm.DB, f Filtros) (*Pipeline, error) {
+	p := &Pipeline{Filtros: f}
+	var err error
+	if p.PorStatus, err = PorStatus(db, f); err != nil {
+		return nil, err
+	}
+	if p.Conversao, err = ConversaoEtapas(db, f); err != nil {
+		return nil, err
+	}
+	if p.TempoPorEtapa, err = TempoPorEtapa(db, f); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,248 @@
+// internal/simulacao/calculo.go
+package simulacao
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/unidade"
+)
+
+// Valores padrão dos parâmetros
+const (
+	perdasPadrao = 3.0 // % de perdas na rede básica sobre a energia comprada
+	mesesPadrao  = 12
+	mesesMax     = 60
+)
+
+var (
+	ErrPrecoObrigatorio = errors.New("informe o preço da energia (precoEnergiaMWh)")
+	ErrPercentual       = errors.New("percentuais devem estar entre 0 e 100 (tributos abaixo de 100)")
+	ErrSemUnidades      = errors.New("nenhuma unidade consumidora para simular")
+	ErrSemConsumo       = errors.New("as unidades não têm histórico de consumo")
+)
+
+// Parametros são as premissas comerciais do estudo.
+type Parametros struct {
+	UnidadeIDs      []uint   `json:"unidadeIds"`      // vazio: unidades da negociação (ou do cliente)
+	PrecoEnergiaMWh float64  `json:"precoEnergiaMWh"` // preço proposto no mercado livre (R$/MWh)
+	DescontoTUSD    float64  `json:"descontoTUSD"`    // % de desconto na TUSD demanda (energia incentivada: 50, 80, 100)
+	Tributos        float64  `json:"tributos"`        // % de ICMS+PIS/COFINS, aplicado "por dentro" nos dois cenários
+	Perdas          *float64 `json:"perdas"`          // % de perdas sobre a energia comprada (ausente: 3; 0 é aceito)
+	EncargosMWh     float64  `json:"encargosMWh"`     // encargos setoriais/CCEE/gestão no mercado livre (R$/MWh)
+	Meses           int      `json:"meses"`           // janela do histórico, em meses até a data base (padrão 12)
+	DataBase        string   `json:"dataBase"`        // AAAA-MM-DD; define a versão da tarifa e o último mês do histórico (padrão hoje)
+}
+
+// PercentualPerdas devolve as perdas informadas ou o padrão.
+func (p Parametros) PercentualPerdas() float64 {
+	if p.Perdas == nil {
+		return perdasPadrao
+	}
+	return *p.Perdas
+}
+
+// normalizar aplica os padrões e valida os parâmetros.
+func (p *Parametros) normalizar(agora time.Time) (time.Time, error) {
+	if p.PrecoEnergiaMWh <= 0 {
+		return time.Time{}, ErrPrecoObrigatorio
+	}
+	if p.Perdas == nil {
+		padrao := perdasPadrao
+		p.Perdas = &padrao
+	}
+	for _, v := range []float64{p.DescontoTUSD, *p.Perdas} {
+		if v < 0 || v > 100 {
+			return time.Time{}, ErrPercentual
+		}
+	}
+	if p.Tributos < 0 || p.Tributos >= 100 || p.EncargosMWh < 0 {
+		return time.Time{}, ErrPercentual
+	}
+	if p.Meses <= 0 {
+		p.Meses = mesesPadrao
+	}
+	p.Meses = min(p.Meses, mesesMax)
+
+	base := inicioDoDia(agora)
+	if p.DataBase != "" {
+		t, err := time.Parse("2006-01-02", p.DataBase)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("dataBase inválida %q (use AAAA-MM-DD)", p.DataBase)
+		}
+		base = t
+	}
+	p.DataBase = base.Format("2006-01-02")
+	return base, nil
+}
+
+// Entrada é uma unidade com o histórico e a tarifa vigente na data base.
+type Entrada struct {
+	Unidade  unidade.UnidadeConsumidora
+	Consumos []unidade.ConsumoMensal
+	Tarifa   *Tarifa // nil: sem tarifa cadastrada
+}
+
+// MesSimulado compara os dois cenários em um mês.
+type MesSimulado struct {
+	Referencia  time.Time `json:"referencia"`
+	ConsumoKWh  float64   `json:"consumoKWh"`
+	CustoCativo float64   `json:"custoCativo"`
+	CustoLivre  float64   `json:"custoLivre"`
+	Economia    float64   `json:"economia"`
+}
+
+// UnidadeSimulada é o resultado de uma unidade.
+type UnidadeSimulada struct {
+	UnidadeID        uint          `json:"unidadeId"`
+	CodigoInstalacao string        `json:"codigoInstalacao"`
+	Distribuidora    string        `json:"distribuidora"`
+	Subgrupo         string        `json:"subgrupo"`
+	Modalidade       string        `json:"modalidade"`
+	TarifaID         uint          `json:"tarifaId"`
+	Meses            []MesSimulado `json:"meses"`
+	CustoCativo      float64       `json:"custoCativo"`
+	CustoLivre       float64       `json:"custoLivre"`
+	Economia         float64       `json:"economia"`
+}
+
+// Resultado consolida o estudo: mês a mês (somando as unidades) e por unidade.
+type Resultado struct {
+	Meses              []MesSimulado     `json:"meses"`
+	Unidades           []UnidadeSimulada `json:"unidades"`
+	ConsumoKWh         float64           `json:"consumoKWh"`
+	CustoCativo        float64           `json:"custoCativo"`
+	CustoLivre         float64           `json:"custoLivre"`
+	Economia           float64           `json:"economia"`
+	EconomiaPercentual float64           `json:"economiaPercentual"`
+	Avisos             []string          `json:"avisos"`
+}
+
+// Simular compara, mês a mês, o custo no mercado cativo com o custo no
+// mercado livre para o preço e o desconto propostos.
+//
+//	cativo = consumo × (TUSD + TE) + demanda faturada × TUSD demanda
+//	livre  = consumo × TUSD + demanda faturada × TUSD demanda × (1 - desconto)
+//	         + MWh × (1 + perdas) × preço + MWh × encargos
+//
+// Os tributos são aplicados por dentro (valor / (1 - tributos)) nos dois
+// cenários. A demanda faturada é a maior entre a contratada e a medida.
+// Unidades sem tarifa ou sem histórico ficam de fora e geram aviso.
+func Simular(entradas []Entrada, p Parametros) (Resultado, error) {
+	res := Resultado{Meses: []MesSimulado{}, Unidades: []UnidadeSimulada{}, Avisos: []string{}}
+	if len(entradas) == 0 {
+		return res, ErrSemUnidades
+	}
+	fatorTributos := 1 / (1 - p.Tributos/100)
+	desconto := p.DescontoTUSD / 100
+	perdas := p.PercentualPerdas() / 100
+
+	porMes := map[time.Time]*MesSimulado{}
+	for _, e := range entradas {
+		u := e.Unidade
+		if e.Tarifa == nil {
+			res.Avisos = append(res.Avisos, fmt.Sprintf(
+				"unidade %s: sem tarifa cadastrada para %s %s/%s na data base",
+				u.CodigoInstalacao, u.Distribuidora, u.Subgrupo, chaveDe(u.Distribuidora, u.Subgrupo, u.Modalidade).Modalidade))
+			continue
+		}
+		if len(e.Consumos) == 0 {
+			res.Avisos = append(res.Avisos, fmt.Sprintf("unidade %s: sem histórico de consumo", u.CodigoInstalacao))
+			continue
+		}
+		if u.Grupo == unidade.GrupoB {
+			res.Avisos = append(res.Avisos, fmt.Sprintf(
+				"unidade %s: grupo B só acessa o mercado livre via comercializador varejista", u.CodigoInstalacao))
+		}
+		if len(e.Consumos) < p.Meses {
+			res.Avisos = append(res.Avisos, fmt.Sprintf(
+				"unidade %s: histórico com %d de %d meses", u.CodigoInstalacao, len(e.Consumos), p.Meses))
+		}
+
+		t := e.Tarifa
+		us := UnidadeSimulada{
+			UnidadeID:        u.ID,
+			CodigoInstalacao: u.CodigoInstalacao,
+			Distribuidora:    u.Distribuidora,
+			Subgrupo:         u.Subgrupo,
+			Modalidade:       t.Modalidade,
+			TarifaID:         t.ID,
+			Meses:            make([]MesSimulado, 0, len(e.Consumos)),
+		}
+		for _, c := range e.Consumos {
+			ponta, fora := c.ConsumoPontaKWh, c.ConsumoForaPontaKWh
+			tusdPonta, tePonta := t.TUSDPontaKWh, t.TEPontaKWh
+			if t.Modalidade == ModalidadePadrao {
+				// tarifa monômia/convencional: sem posto ponta
+				tusdPonta, tePonta = t.TUSDForaPontaKWh, t.TEForaPontaKWh
+			}
+			fio := ponta*tusdPonta + fora*t.TUSDForaPontaKWh
+			energiaCativa := ponta*tePonta + fora*t.TEForaPontaKWh
+
+			var demanda float64
+			if u.Grupo != unidade.GrupoB {
+				demanda = max(u.DemandaContratadaForaPonta, c.DemandaKW) * t.TUSDDemandaForaPontaKW
+				if t.Modalidade == "Azul" {
+					demanda += u.DemandaContratadaPonta * t.TUSDDemandaPontaKW
+				}
+			}
+
+			mwh := c.TotalKWh() / 1000
+			energiaLivre := mwh*(1+perdas)*p.PrecoEnergiaMWh + mwh*p.EncargosMWh
+
+			m := MesSimulado{
+				Referencia:  c.Referencia,
+				ConsumoKWh:  c.TotalKWh(),
+				CustoCativo: arredondar((fio + energiaCativa + demanda) * fatorTributos),
+				CustoLivre:  arredondar((fio + demanda*(1-desconto) + energiaLivre) * fatorTributos),
+			}
+			m.Economia = arredondar(m.CustoCativo - m.CustoLivre)
+			us.Meses = append(us.Meses, m)
+			us.CustoCativo += m.CustoCativo
+			us.CustoLivre += m.CustoLivre
+
+			total, ok := porMes[c.Referencia]
+			if !ok {
+				total = &MesSimulado{Referencia: c.Referencia}
+				porMes[c.Referencia] = total
+			}
+			total.ConsumoKWh += m.ConsumoKWh
+			total.CustoCativo += m.CustoCativo
+			total.CustoLivre += m.CustoLivre
+		}
+		us.CustoCativo = arredondar(us.CustoCativo)
+		us.CustoLivre = arredondar(us.CustoLivre)
+		us.Economia = arredondar(us.CustoCativo - us.CustoLivre)
+		res.Unidades = append(res.Unidades, us)
+	}
+	if len(res.Unidades) == 0 {
+		return res, ErrSemConsumo
+	}
+
+	for _, m := range porMes {
+		m.CustoCativo = arredondar(m.CustoCativo)
+		m.CustoLivre = arredondar(m.CustoLivre)
+		m.Economia = arredondar(m.CustoCativo - m.CustoLivre)
+		res.Meses = append(res.Meses, *m)
+		res.ConsumoKWh += m.ConsumoKWh
+		res.CustoCativo += m.CustoCativo
+		res.CustoLivre += m.CustoLivre
+	}
+	sort.Slice(res.Meses, func(i, j int) bool { return res.Meses[i].Referencia.Before(res.Meses[j].Referencia) })
+
+	res.CustoCativo = arredondar(res.CustoCativo)
+	res.CustoLivre = arredondar(res.CustoLivre)
+	res.Economia = arredondar(res.CustoCativo - res.CustoLivre)
+	if res.CustoCativo > 0 {
+		res.EconomiaPercentual = arredondar(res.Economia / res.CustoCativo * 100)
+	}
+	return res, nil
+}
+
+// arredondar para centavos.
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
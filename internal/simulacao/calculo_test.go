@@ -0,0 +1,82 @@
+package simulacao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/unidade"
+)
+
+func perdas(v float64) *float64 { return &v }
+
+// entradaSimples: 10 MWh fora ponta, R$ 0,10/kWh de TUSD e R$ 0,30/kWh de
+// TE, sem demanda, para o custo livre depender só do preço e das perdas.
+func entradaSimples() []Entrada {
+	return []Entrada{{
+		Unidade: unidade.UnidadeConsumidora{ID: 1, CodigoInstalacao: "UC-1", Grupo: "A", Subgrupo: "A4", Modalidade: "Verde"},
+		Consumos: []unidade.ConsumoMensal{{
+			Referencia:          time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+			ConsumoForaPontaKWh: 10000,
+		}},
+		Tarifa: &Tarifa{ID: 1, Modalidade: "Verde", TUSDForaPontaKWh: 0.10, TEForaPontaKWh: 0.30},
+	}}
+}
+
+func TestSimularPerdas(t *testing.T) {
+	casos := []struct {
+		nome       string
+		perdas     *float64
+		custoLivre float64
+	}{
+		// fio 1.000 + 10 MWh × (1 + perdas) × R$ 200
+		{"omitidas usam o padrão de 3%", nil, 3060},
+		{"zero é aceito", perdas(0), 3000},
+		{"informadas", perdas(5), 3100},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p := Parametros{PrecoEnergiaMWh: 200, Perdas: c.perdas}
+			if _, err := p.normalizar(time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			res, err := Simular(entradaSimples(), p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.CustoCativo != 4000 {
+				t.Errorf("custo cativo = %.2f, quer 4000.00", res.CustoCativo)
+			}
+			if res.CustoLivre != c.custoLivre {
+				t.Errorf("custo livre = %.2f, quer %.2f", res.CustoLivre, c.custoLivre)
+			}
+		})
+	}
+}
+
+func TestNormalizarPerdas(t *testing.T) {
+	casos := []struct {
+		nome   string
+		perdas *float64
+		quer   float64
+		err    error
+	}{
+		{"omitidas", nil, perdasPadrao, nil},
+		{"zero", perdas(0), 0, nil},
+		{"cem", perdas(100), 100, nil},
+		{"negativas", perdas(-1), 0, ErrPercentual},
+		{"acima de cem", perdas(101), 0, ErrPercentual},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			p := Parametros{PrecoEnergiaMWh: 200, Perdas: c.perdas}
+			_, err := p.normalizar(time.Now())
+			if !errors.Is(err, c.err) {
+				t.Fatalf("erro = %v, quer %v", err, c.err)
+			}
+			if err == nil && p.PercentualPerdas() != c.quer {
+				t.Errorf("perdas = %v, quer %v", p.PercentualPerdas(), c.quer)
+			}
+		})
+	}
+}
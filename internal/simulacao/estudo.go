@@ -0,0 +1,27 @@
+// internal/simulacao/estudo.go
+package simulacao
+
+import "time"
+
+// Estudo é uma simulação de economia salva na negociação. Cada nova
+// simulação salva vira a próxima versão; as anteriores não mudam.
+type Estudo struct {
+	ID           uint `gorm:"primaryKey" json:"id"`
+	NegociacaoID uint `gorm:"not null;uniqueIndex:idx_estudo_versao" json:"negociacaoId"`
+	Versao       uint `gorm:"not null;uniqueIndex:idx_estudo_versao" json:"versao"`
+
+	Parametros Parametros `gorm:"type:jsonb;serializer:json" json:"parametros"`
+	Resultado  Resultado  `gorm:"type:jsonb;serializer:json" json:"resultado,omitempty"`
+
+	// Totais copiados do resultado para listagens e relatórios
+	CustoCativo        float64 `gorm:"not null;default:0" json:"custoCativo"`
+	CustoLivre         float64 `gorm:"not null;default:0" json:"custoLivre"`
+	Economia           float64 `gorm:"not null;default:0" json:"economia"`
+	EconomiaPercentual float64 `gorm:"not null;default:0" json:"economiaPercentual"`
+
+	CriadoPorTipo string    `gorm:"size:20;not null" json:"criadoPorTipo"` // "consultor" | "comercial"
+	CriadoPorID   *uint     `json:"criadoPorId"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+func (Estudo) TableName() string { return "estudos" }
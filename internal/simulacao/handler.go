@@ -0,0 +1,341 @@
+// internal/simulacao/handler.go
+package simulacao
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/unidade"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+var errUnidadeDeOutroCliente = errors.New("unidade não encontrada entre as do cliente da negociação")
+
+// Handler encapsula DB e repositories de tarifas e estudos
+type Handler struct {
+	DB         *gorm.DB
+	Repository Repository
+	Unidades   unidade.Repository
+}
+
+// NewHandler cria o handler de simulação/estudos
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db, Repository: NewRepository(), Unidades: unidade.NewRepository()}
+}
+
+type tarifaRequest struct {
+	Distribuidora          string  `json:"distribuidora"`
+	Subgrupo               string  `json:"subgrupo"`
+	Modalidade             string  `json:"modalidade"`
+	VigenciaInicio         string  `json:"vigenciaInicio"` // AAAA-MM-DD
+	Fonte                  string  `json:"fonte"`
+	TUSDPontaKWh           float64 `json:"tusdPontaKWh"`
+	TUSDForaPontaKWh       float64 `json:"tusdForaPontaKWh"`
+	TEPontaKWh             float64 `json:"tePontaKWh"`
+	TEForaPontaKWh         float64 `json:"teForaPontaKWh"`
+	TUSDDemandaPontaKW     float64 `json:"tusdDemandaPontaKW"`
+	TUSDDemandaForaPontaKW float64 `json:"tusdDemandaForaPontaKW"`
+}
+
+/* ================== Tarifas ================== */
+
+// ListarTarifas trata GET /tarifas?distribuidora=&subgrupo=&modalidade=&vigentes=true
+func (h *Handler) ListarTarifas(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	list, err := h.Repository.ListarTarifas(h.DB, FiltroTarifas{
+		Distribuidora: strings.TrimSpace(q.Get("distribuidora")),
+		Subgrupo:      strings.TrimSpace(q.Get("subgrupo")),
+		Modalidade:    strings.TrimSpace(q.Get("modalidade")),
+		SoVigentes:    q.Get("vigentes") == "true",
+	})
+	if err != nil {
+		http.Error(w, "Erro ao listar tarifas", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// CriarTarifa trata POST /tarifas (comercial): cadastra uma nova versão e
+// encerra a versão anterior na véspera da nova vigência.
+func (h *Handler) CriarTarifa(w http.ResponseWriter, r *http.Request) {
+	if admin, _ := r.Context().Value(auth.CtxIsAdmin).(bool); !admin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	var req tarifaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	k := chaveDe(req.Distribuidora, req.Subgrupo, req.Modalidade)
+	if k.Distribuidora == "" || k.Subgrupo == "" {
+		http.Error(w, "distribuidora e subgrupo são obrigatórios", http.StatusBadRequest)
+		return
+	}
+	inicio, err := time.Parse("2006-01-02", req.VigenciaInicio)
+	if err != nil {
+		http.Error(w, "vigenciaInicio inválida (use AAAA-MM-DD)", http.StatusBadRequest)
+		return
+	}
+	t := Tarifa{
+		Distribuidora:          k.Distribuidora,
+		Subgrupo:               k.Subgrupo,
+		Modalidade:             k.Modalidade,
+		VigenciaInicio:         inicio,
+		Fonte:                  strings.TrimSpace(req.Fonte),
+		TUSDPontaKWh:           req.TUSDPontaKWh,
+		TUSDForaPontaKWh:       req.TUSDForaPontaKWh,
+		TEPontaKWh:             req.TEPontaKWh,
+		TEForaPontaKWh:         req.TEForaPontaKWh,
+		TUSDDemandaPontaKW:     req.TUSDDemandaPontaKW,
+		TUSDDemandaForaPontaKW: req.TUSDDemandaForaPontaKW,
+	}
+	for _, v := range []float64{t.TUSDPontaKWh, t.TUSDForaPontaKWh, t.TEPontaKWh, t.TEForaPontaKWh, t.TUSDDemandaPontaKW, t.TUSDDemandaForaPontaKW} {
+		if v < 0 {
+			http.Error(w, "valores da tarifa não podem ser negativos", http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		return h.Repository.CriarVersaoTarifa(tx, &t)
+	})
+	if errors.Is(err, ErrVigenciaDuplicada) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao salvar tarifa", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+/* ================== Estudos ================== */
+
+// Simular trata POST /negociacoes/{id}/estudos/simular: calcula sem salvar.
+func (h *Handler) Simular(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	p, res, ok := h.simular(w, r, neg)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"parametros": p, "resultado": res})
+}
+
+// Criar trata POST /negociacoes/{id}/estudos: simula e salva como nova versão.
+func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	p, res, ok := h.simular(w, r, neg)
+	if !ok {
+		return
+	}
+
+	ator := auth.AtorDaRequisicao(r)
+	e := Estudo{
+		NegociacaoID:       neg.ID,
+		Parametros:         p,
+		Resultado:          res,
+		CustoCativo:        res.CustoCativo,
+		CustoLivre:         res.CustoLivre,
+		Economia:           res.Economia,
+		EconomiaPercentual: res.EconomiaPercentual,
+		CriadoPorTipo:      ator.Tipo,
+	}
+	if ator.ID != 0 {
+		id := ator.ID
+		e.CriadoPorID = &id
+	}
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		// serializa as versões da mesma negociação
+		if err := tx.Exec("SELECT id FROM negociacaos WHERE id = ? FOR UPDATE", neg.ID).Error; err != nil {
+			return err
+		}
+		if err := h.Repository.CriarEstudo(tx, &e); err != nil {
+			return err
+		}
+		return evento.Registrar(tx, evento.Evento{
+			NegociacaoID:   neg.ID,
+			Tipo:           evento.EstudoGerado,
+			Descricao:      fmt.Sprintf("Estudo de economia v%d gerado", e.Versao),
+			ReferenciaTipo: "estudo",
+			ReferenciaID:   e.ID,
+			Dados: map[string]any{
+				"versao":             e.Versao,
+				"economia":           e.Economia,
+				"economiaPercentual": e.EconomiaPercentual,
+				"precoEnergiaMWh":    p.PrecoEnergiaMWh,
+			},
+		}.Por(ator))
+	})
+	if err != nil {
+		http.Error(w, "Erro ao salvar estudo", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+// Listar trata GET /negociacoes/{id}/estudos (versões, sem o detalhamento)
+func (h *Handler) Listar(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	list, err := h.Repository.ListarEstudos(h.DB, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao listar estudos", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// BuscarPorVersao trata GET /negociacoes/{id}/estudos/{versao}
+func (h *Handler) BuscarPorVersao(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r)
+	if !ok {
+		return
+	}
+	versao, err := strconv.Atoi(mux.Vars(r)["versao"])
+	if err != nil || versao <= 0 {
+		http.Error(w, "Versão inválida", http.StatusBadRequest)
+		return
+	}
+	e, err := h.Repository.BuscarEstudo(h.DB, neg.ID, uint(versao))
+	if err != nil {
+		http.Error(w, "Estudo não encontrado", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+/* ================== Helpers ================== */
+
+type negociacaoSimulada struct {
+	ID        uint
+	ClienteID *uint
+}
+
+// carregarNegociacao valida o ID e o escopo do usuário sobre a negociação.
+func (h *Handler) carregarNegociacao(w http.ResponseWriter, r *http.Request) (negociacaoSimulada, bool) {
+	var neg negociacaoSimulada
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return neg, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return neg, false
+	}
+	cond, args := escopo.SQL("n")
+	res := h.DB.Table("negociacaos AS n").
+		Select("n.id, n.cliente_id").
+		Where("n.id = ? AND n.deleted_at IS NULL", id).
+		Where(cond, args...).
+		Take(&neg)
+	if res.Error != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return neg, false
+	}
+	return neg, true
+}
+
+// simular lê os parâmetros, monta as entradas e calcula o resultado.
+func (h *Handler) simular(w http.ResponseWriter, r *http.Request, neg negociacaoSimulada) (Parametros, Resultado, bool) {
+	var p Parametros
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return p, Resultado{}, false
+	}
+	base, err := p.normalizar(time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return p, Resultado{}, false
+	}
+
+	entradas, err := h.montarEntradas(neg, &p, base)
+	if errors.Is(err, errUnidadeDeOutroCliente) {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return p, Resultado{}, false
+	}
+	if err != nil {
+		http.Error(w, "Erro ao carregar unidades e tarifas", http.StatusInternalServerError)
+		return p, Resultado{}, false
+	}
+
+	res, err := Simular(entradas, p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return p, Resultado{}, false
+	}
+	return p, res, true
+}
+
+// montarEntradas carrega as unidades (as informadas, as da negociação ou,
+// na falta delas, as do cliente), o histórico e a tarifa vigente na data
+// base. Preenche p.UnidadeIDs com as unidades usadas.
+func (h *Handler) montarEntradas(neg negociacaoSimulada, p *Parametros, base time.Time) ([]Entrada, error) {
+	var unidades []unidade.UnidadeConsumidora
+	var err error
+	switch {
+	case len(p.UnidadeIDs) > 0:
+		if neg.ClienteID == nil {
+			return nil, errUnidadeDeOutroCliente
+		}
+		err = h.DB.Where("id IN ? AND cliente_id = ?", p.UnidadeIDs, *neg.ClienteID).
+			Order("distribuidora ASC, codigo_instalacao ASC").
+			Find(&unidades).Error
+		if err == nil && len(unidades) != len(p.UnidadeIDs) {
+			return nil, errUnidadeDeOutroCliente
+		}
+	default:
+		unidades, err = h.Unidades.ListarPorNegociacao(h.DB, neg.ID)
+		if err == nil && len(unidades) == 0 && neg.ClienteID != nil {
+			unidades, err = h.Unidades.ListarPorCliente(h.DB, *neg.ClienteID)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.UnidadeIDs = make([]uint, 0, len(unidades))
+	entradas := make([]Entrada, 0, len(unidades))
+	for _, u := range unidades {
+		hist, err := h.Unidades.Historico(h.DB, u.ID, p.Meses, base)
+		if err != nil {
+			return nil, err
+		}
+		t, err := h.Repository.TarifaVigente(h.DB, u.Distribuidora, u.Subgrupo, u.Modalidade, base)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		p.UnidadeIDs = append(p.UnidadeIDs, u.ID)
+		entradas = append(entradas, Entrada{Unidade: u, Consumos: hist, Tarifa: t})
+	}
+	return entradas, nil
+}
@@ -0,0 +1,144 @@
+// internal/simulacao/repository.go
+package simulacao
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrVigenciaDuplicada: já há uma versão da tarifa começando na mesma data.
+var ErrVigenciaDuplicada = errors.New("já existe uma versão desta tarifa com essa vigência")
+
+// FiltroTarifas filtra a listagem da tabela de tarifas
+type FiltroTarifas struct {
+	Distribuidora string
+	Subgrupo      string
+	Modalidade    string
+	SoVigentes    bool
+}
+
+// Repository define operações de persistência de tarifas e estudos
+type Repository interface {
+	ListarTarifas(db *gorm.DB, f FiltroTarifas) ([]Tarifa, error)
+	TarifaVigente(db *gorm.DB, distribuidora, subgrupo, modalidade string, data time.Time) (*Tarifa, error)
+	CriarVersaoTarifa(db *gorm.DB, t *Tarifa) error
+
+	ListarEstudos(db *gorm.DB, negociacaoID uint) ([]Estudo, error)
+	BuscarEstudo(db *gorm.DB, negociacaoID, versao uint) (*Estudo, error)
+	CriarEstudo(db *gorm.DB, e *Estudo) error
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) ListarTarifas(db *gorm.DB, f FiltroTarifas) ([]Tarifa, error) {
+	q := db.Model(&Tarifa{})
+	if f.Distribuidora != "" {
+		q = q.Where("LOWER(distribuidora) = LOWER(?)", f.Distribuidora)
+	}
+	if f.Subgrupo != "" {
+		q = q.Where("subgrupo = ?", f.Subgrupo)
+	}
+	if f.Modalidade != "" {
+		q = q.Where("modalidade = ?", f.Modalidade)
+	}
+	if f.SoVigentes {
+		q = q.Where("vigencia_fim IS NULL")
+	}
+	list := []Tarifa{}
+	err := q.Order("distribuidora ASC, subgrupo ASC, modalidade ASC, vigencia_inicio DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) TarifaVigente(db *gorm.DB, distribuidora, subgrupo, modalidade string, data time.Time) (*Tarifa, error) {
+	k := chaveDe(distribuidora, subgrupo, modalidade)
+	dia := inicioDoDia(data)
+	var t Tarifa
+	err := db.Where("LOWER(distribuidora) = LOWER(?) AND subgrupo = ? AND modalidade = ?", k.Distribuidora, k.Subgrupo, k.Modalidade).
+		Where("vigencia_inicio <= ? AND (vigencia_fim IS NULL OR vigencia_fim >= ?)", dia, dia).
+		Order("vigencia_inicio DESC").
+		Take(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CriarVersaoTarifa grava a nova versão e encerra, na véspera do início, a
+// versão que estava em vigor nessa data. Se já existir uma versão posterior,
+// a nova termina na véspera dela. Use dentro de uma transação.
+func (r *repositoryImpl) CriarVersaoTarifa(db *gorm.DB, t *Tarifa) error {
+	inicio := inicioDoDia(t.VigenciaInicio)
+	t.VigenciaInicio = inicio
+	chave := "LOWER(distribuidora) = LOWER(?) AND subgrupo = ? AND modalidade = ?"
+
+	var qtd int64
+	if err := db.Model(&Tarifa{}).
+		Where(chave, t.Distribuidora, t.Subgrupo, t.Modalidade).
+		Where("vigencia_inicio = ?", inicio).
+		Count(&qtd).Error; err != nil {
+		return err
+	}
+	if qtd > 0 {
+		return ErrVigenciaDuplicada
+	}
+
+	var proxima Tarifa
+	err := db.Where(chave, t.Distribuidora, t.Subgrupo, t.Modalidade).
+		Where("vigencia_inicio > ?", inicio).
+		Order("vigencia_inicio ASC").
+		Take(&proxima).Error
+	switch {
+	case err == nil:
+		fim := proxima.VigenciaInicio.AddDate(0, 0, -1)
+		t.VigenciaFim = &fim
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	if err := db.Model(&Tarifa{}).
+		Where(chave, t.Distribuidora, t.Subgrupo, t.Modalidade).
+		Where("vigencia_inicio < ? AND (vigencia_fim IS NULL OR vigencia_fim >= ?)", inicio, inicio).
+		Update("vigencia_fim", inicio.AddDate(0, 0, -1)).Error; err != nil {
+		return err
+	}
+	return db.Create(t).Error
+}
+
+// ListarEstudos devolve as versões (sem o resultado detalhado), da mais recente para a mais antiga.
+func (r *repositoryImpl) ListarEstudos(db *gorm.DB, negociacaoID uint) ([]Estudo, error) {
+	list := []Estudo{}
+	err := db.Omit("resultado").
+		Where("negociacao_id = ?", negociacaoID).
+		Order("versao DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) BuscarEstudo(db *gorm.DB, negociacaoID, versao uint) (*Estudo, error) {
+	var e Estudo
+	if err := db.Where("negociacao_id = ? AND versao = ?", negociacaoID, versao).Take(&e).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CriarEstudo atribui a próxima versão da negociação e grava o estudo.
+// Use dentro de uma transação com a negociação travada (FOR UPDATE).
+func (r *repositoryImpl) CriarEstudo(db *gorm.DB, e *Estudo) error {
+	var ultima uint
+	if err := db.Model(&Estudo{}).
+		Where("negociacao_id = ?", e.NegociacaoID).
+		Select("COALESCE(MAX(versao), 0)").
+		Scan(&ultima).Error; err != nil {
+		return err
+	}
+	e.Versao = ultima + 1
+	return db.Create(e).Error
+}
@@ -0,0 +1,67 @@
+// internal/simulacao/tarifa.go
+package simulacao
+
+import (
+	"strings"
+	"time"
+)
+
+// ModalidadePadrao é usada quando a unidade não informa a modalidade (grupo B).
+const ModalidadePadrao = "Convencional"
+
+// Tarifa é uma versão da tarifa homologada de uma distribuidora para um
+// subgrupo/modalidade. Cada reajuste vira uma nova linha; a anterior é
+// encerrada em VigenciaFim e continua disponível para estudos antigos.
+// Valores sem tributos (ICMS/PIS/COFINS entram nos parâmetros do estudo).
+type Tarifa struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	Distribuidora  string     `gorm:"size:100;not null;uniqueIndex:idx_tarifa_vigencia" json:"distribuidora"`
+	Subgrupo       string     `gorm:"size:5;not null;uniqueIndex:idx_tarifa_vigencia" json:"subgrupo"`
+	Modalidade     string     `gorm:"size:20;not null;uniqueIndex:idx_tarifa_vigencia" json:"modalidade"`
+	VigenciaInicio time.Time  `gorm:"type:date;not null;uniqueIndex:idx_tarifa_vigencia" json:"vigenciaInicio"`
+	VigenciaFim    *time.Time `gorm:"type:date" json:"vigenciaFim"` // nulo: vigente
+	Fonte          string     `gorm:"size:255" json:"fonte"`        // ex.: "REH ANEEL 3.245/2024"
+
+	// Energia (R$/kWh); na modalidade Convencional só vale a fora ponta
+	TUSDPontaKWh     float64 `gorm:"column:tusd_ponta_kwh;not null;default:0" json:"tusdPontaKWh"`
+	TUSDForaPontaKWh float64 `gorm:"column:tusd_fora_ponta_kwh;not null;default:0" json:"tusdForaPontaKWh"`
+	TEPontaKWh       float64 `gorm:"column:te_ponta_kwh;not null;default:0" json:"tePontaKWh"`
+	TEForaPontaKWh   float64 `gorm:"column:te_fora_ponta_kwh;not null;default:0" json:"teForaPontaKWh"`
+
+	// Demanda (R$/kW); na modalidade Verde só vale a fora ponta
+	TUSDDemandaPontaKW     float64 `gorm:"column:tusd_demanda_ponta_kw;not null;default:0" json:"tusdDemandaPontaKW"`
+	TUSDDemandaForaPontaKW float64 `gorm:"column:tusd_demanda_fora_ponta_kw;not null;default:0" json:"tusdDemandaForaPontaKW"`
+}
+
+func (Tarifa) TableName() string { return "tarifas" }
+
+// Vigente indica se a tarifa vale na data informada.
+func (t Tarifa) Vigente(data time.Time) bool {
+	dia := inicioDoDia(data)
+	return !t.VigenciaInicio.After(dia) && (t.VigenciaFim == nil || !t.VigenciaFim.Before(dia))
+}
+
+// chaveTarifa identifica a série de versões de uma tarifa.
+type chaveTarifa struct {
+	Distribuidora string
+	Subgrupo      string
+	Modalidade    string
+}
+
+func chaveDe(distribuidora, subgrupo, modalidade string) chaveTarifa {
+	if strings.TrimSpace(modalidade) == "" {
+		modalidade = ModalidadePadrao
+	}
+	return chaveTarifa{
+		Distribuidora: strings.TrimSpace(distribuidora),
+		Subgrupo:      strings.TrimSpace(subgrupo),
+		Modalidade:    strings.TrimSpace(modalidade),
+	}
+}
+
+func inicioDoDia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,158 @@
+// internal/sla/handler.go
+package sla
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler expõe os prazos por etapa e a lista de negociações em risco
+type Handler struct {
+	DB *gorm.DB
+}
+
+// NewHandler cria o handler de SLA
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db}
+}
+
+type prazoRequest struct {
+	DiasAlerta       int   `json:"diasAlerta"`
+	DiasCancelamento int   `json:"diasCancelamento"`
+	Ativo            *bool `json:"ativo"`
+}
+
+// EmRisco é uma negociação que passou do prazo da etapa atual.
+type EmRisco struct {
+	ID                   uint       `json:"negociacaoId"`
+	Nome                 string     `json:"nome"`
+	Status               string     `json:"status"`
+	ConsultorID          uint       `json:"consultorId"`
+	Consultor            string     `json:"consultor"`
+	ComercialID          *uint      `json:"comercialId"`
+	StatusDesde          time.Time  `json:"statusDesde"`
+	EmRiscoDesde         *time.Time `json:"emRiscoDesde"` // quando o aviso saiu (nulo até a próxima varredura)
+	UltimaAtividade      time.Time  `json:"ultimaAtividade"`
+	DiasNaEtapa          int        `json:"diasNaEtapa"`
+	DiasAlerta           int        `json:"diasAlerta"`
+	DiasCancelamento     int        `json:"diasCancelamento"`
+	CancelamentoPrevisto *time.Time `json:"cancelamentoPrevisto,omitempty"` // nulo quando a etapa não cancela sozinha
+}
+
+// ListarPrazos trata GET /sla/prazos
+func (h *Handler) ListarPrazos(w http.ResponseWriter, r *http.Request) {
+	var prazos []Prazo
+	if err := h.DB.Order("id ASC").Find(&prazos).Error; err != nil {
+		http.Error(w, "Erro ao listar prazos", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(prazos)
+}
+
+// AtualizarPrazo trata PUT /sla/prazos/{status} (somente admin)
+// Body: { "diasAlerta": 10, "diasCancelamento": 90, "ativo": true } — 0 desliga
+func (h *Handler) AtualizarPrazo(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := r.Context().Value(auth.CtxIsAdmin).(bool); !isAdmin {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	var req prazoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if req.DiasAlerta < 0 || req.DiasCancelamento < 0 {
+		http.Error(w, "os prazos não podem ser negativos", http.StatusBadRequest)
+		return
+	}
+	if req.DiasCancelamento > 0 && req.DiasCancelamento <= req.DiasAlerta {
+		http.Error(w, "'diasCancelamento' deve ser maior que 'diasAlerta'", http.StatusBadRequest)
+		return
+	}
+
+	var p Prazo
+	if err := h.DB.Where("status = ?", mux.Vars(r)["status"]).First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Etapa sem prazo configurável", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao buscar prazo", http.StatusInternalServerError)
+		return
+	}
+	p.DiasAlerta = req.DiasAlerta
+	p.DiasCancelamento = req.DiasCancelamento
+	if req.Ativo != nil {
+		p.Ativo = *req.Ativo
+	}
+	if err := h.DB.Save(&p).Error; err != nil {
+		http.Error(w, "Erro ao salvar prazo", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// ListarEmRisco trata GET /sla/em-risco?comercialId= (comercial/admin)
+// O comercial vê o próprio time; o admin vê todos ou filtra por comercial.
+func (h *Handler) ListarEmRisco(w http.ResponseWriter, r *http.Request) {
+	if !auth.AtorDaRequisicao(r).EhComercial() {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if v := r.URL.Query().Get("comercialId"); v != "" && escopo.Todos {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			http.Error(w, "comercialId inválido", http.StatusBadRequest)
+			return
+		}
+		escopo = negociacao.Escopo{ComercialID: uint(id)}
+	}
+	cond, args := escopo.SQL("n")
+
+	var out []EmRisco
+	err = h.DB.Raw(`
+		SELECT n.id, n.nome, n.status, n.consultor_id, TRIM(c.nome || ' ' || COALESCE(c.sobrenome, '')) AS consultor,
+			c.comercial_id, n.status_desde, n.em_risco_desde, `+ultimaAtividade+` AS ultima_atividade,
+			s.dias_alerta, s.dias_cancelamento
+		FROM negociacaos n
+		JOIN consultors c ON c.id = n.consultor_id
+		JOIN sla_prazos s ON s.status = n.status AND s.ativo AND s.dias_alerta > 0
+		WHERE n.deleted_at IS NULL
+			AND n.status_desde < NOW() - make_interval(days => s.dias_alerta)
+			AND `+cond+`
+		ORDER BY n.status_desde ASC`, args...).Scan(&out).Error
+	if err != nil {
+		http.Error(w, "Erro ao listar negociações em risco", http.StatusInternalServerError)
+		return
+	}
+
+	agora := time.Now()
+	for i := range out {
+		e := &out[i]
+		e.DiasNaEtapa = int(math.Floor(agora.Sub(e.StatusDesde).Hours() / 24))
+		if e.DiasCancelamento > 0 {
+			previsto := e.UltimaAtividade.AddDate(0, 0, e.DiasCancelamento)
+			e.CancelamentoPrevisto = &previsto
+		}
+	}
+	if out == nil {
+		out = []EmRisco{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
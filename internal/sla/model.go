@@ -0,0 +1,45 @@
+// internal/sla/model.go
+package sla
+
+import (
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Prazo é o SLA de uma etapa do pipeline. Passados DiasAlerta dias na etapa,
+// a negociação é marcada "em risco" e os responsáveis são avisados; passados
+// DiasCancelamento dias sem nenhuma atividade, é cancelada pelo sistema.
+// Zero desliga o respectivo controle.
+type Prazo struct {
+	ID               uint      `gorm:"primaryKey" json:"id"`
+	Status           string    `gorm:"size:50;not null;uniqueIndex" json:"status"`
+	DiasAlerta       int       `gorm:"not null;default:0" json:"diasAlerta"`
+	DiasCancelamento int       `gorm:"not null;default:0" json:"diasCancelamento"`
+	Ativo            bool      `gorm:"not null" json:"ativo"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+func (Prazo) TableName() string { return "sla_prazos" }
+
+// prazosPadrao só são inseridos se a etapa ainda não tiver prazo, então os
+// ajustes feitos pelos admins são preservados. Contrato assinado nunca é
+// cancelado automaticamente.
+var prazosPadrao = []Prazo{
+	{Status: models.StatusAberta, DiasAlerta: 7, DiasCancelamento: 60, Ativo: true},
+	{Status: models.StatusEmEstudo, DiasAlerta: 10, DiasCancelamento: 90, Ativo: true},
+	{Status: models.StatusEstudoFeito, DiasAlerta: 10, DiasCancelamento: 90, Ativo: true},
+	{Status: models.StatusContratoEnviado, DiasAlerta: 15, DiasCancelamento: 120, Ativo: true},
+	{Status: models.StatusContratoAssinado, DiasAlerta: 30, Ativo: true},
+}
+
+// Migrate popula os prazos padrão. Roda depois do AutoMigrate.
+func Migrate(db *gorm.DB) error {
+	prazos := append([]Prazo(nil), prazosPadrao...)
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "status"}},
+		DoNothing: true,
+	}).Create(&prazos).Error
+}
@@ -0,0 +1,165 @@
+// internal/sla/monitor.go
+package sla
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/evento"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"gorm.io/gorm"
+)
+
+// ultimaAtividade é a expressão SQL do último sinal de vida da negociação `n`:
+// a entrada na etapa ou o último evento da linha do tempo que não seja o
+// próprio aviso de SLA.
+const ultimaAtividade = `GREATEST(n.status_desde, (SELECT MAX(e.created_at) FROM eventos e
+	WHERE e.negociacao_id = n.id AND e.tipo <> '` + evento.SLAExcedido + `'))`
+
+// Monitor varre as negociações abertas, marca as que estouraram o prazo da
+// etapa e cancela as abandonadas.
+type Monitor struct {
+	DB          *gorm.DB
+	Notificador notificacao.Notificador
+	Intervalo   time.Duration
+}
+
+// NewMonitorFromEnv lê SLA_MONITOR_INTERVALO (padrão 1h).
+func NewMonitorFromEnv(db *gorm.DB, notif notificacao.Notificador) *Monitor {
+	intervalo := time.Hour
+	if v := os.Getenv("SLA_MONITOR_INTERVALO"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			intervalo = d
+		} else {
+			log.Printf("[sla] SLA_MONITOR_INTERVALO inválido (%q); usando %s", v, intervalo)
+		}
+	}
+	return &Monitor{DB: db, Notificador: notif, Intervalo: intervalo}
+}
+
+// Iniciar roda o monitor em background até o contexto ser cancelado.
+func (m *Monitor) Iniciar(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.Intervalo)
+		defer ticker.Stop()
+		for {
+			m.Executar(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Executar faz uma varredura.
+func (m *Monitor) Executar(agora time.Time) {
+	m.sinalizar(agora)
+	m.cancelarAbandonadas(agora)
+}
+
+type negociacaoParada struct {
+	ID          uint
+	Nome        string
+	Status      string
+	ConsultorID uint
+	ComercialID *uint
+	Dias        int
+}
+
+// sinalizar marca em_risco_desde nas negociações que passaram do prazo da
+// etapa. O UPDATE condicional reserva cada uma, então várias instâncias da
+// API não avisam duas vezes.
+func (m *Monitor) sinalizar(agora time.Time) {
+	var paradas []negociacaoParada
+	err := m.DB.Raw(`
+		UPDATE negociacaos n SET em_risco_desde = ?
+		FROM sla_prazos s
+		WHERE s.status = n.status AND s.ativo AND s.dias_alerta > 0
+			AND n.deleted_at IS NULL AND n.em_risco_desde IS NULL
+			AND n.status_desde < ?::timestamptz - make_interval(days => s.dias_alerta)
+		RETURNING n.id, n.nome, n.status, n.consultor_id, s.dias_alerta AS dias,
+			(SELECT c.comercial_id FROM consultors c WHERE c.id = n.consultor_id) AS comercial_id`,
+		agora, agora).Scan(&paradas).Error
+	if err != nil {
+		log.Printf("[sla] erro ao sinalizar negociações paradas: %v", err)
+		return
+	}
+
+	for _, n := range paradas {
+		if err := evento.Registrar(m.DB, evento.Evento{
+			NegociacaoID: n.ID,
+			Tipo:         evento.SLAExcedido,
+			Descricao:    fmt.Sprintf("Negociação há mais de %d dias em %s", n.Dias, n.Status),
+			Dados:        map[string]any{"status": n.Status, "diasAlerta": n.Dias},
+		}); err != nil {
+			log.Printf("[sla] erro ao registrar evento da negociação %d: %v", n.ID, err)
+		}
+		m.notificar(n, "negociacao_em_risco", "Negociação parada além do prazo",
+			fmt.Sprintf("%s está há mais de %d dias em %s", n.Nome, n.Dias, n.Status))
+	}
+}
+
+// cancelarAbandonadas cancela, pela máquina de estados e com comentário do
+// sistema, as negociações sem atividade há mais de dias_cancelamento.
+func (m *Monitor) cancelarAbandonadas(agora time.Time) {
+	var abandonadas []negociacaoParada
+	err := m.DB.Raw(`
+		SELECT n.id, n.nome, n.status, n.consultor_id, c.comercial_id, s.dias_cancelamento AS dias
+		FROM negociacaos n
+		JOIN sla_prazos s ON s.status = n.status AND s.ativo AND s.dias_cancelamento > 0
+		JOIN consultors c ON c.id = n.consultor_id
+		WHERE n.deleted_at IS NULL
+			AND `+ultimaAtividade+` < ?::timestamptz - make_interval(days => s.dias_cancelamento)
+		ORDER BY n.id
+		LIMIT 200`, agora).Scan(&abandonadas).Error
+	if err != nil {
+		log.Printf("[sla] erro ao buscar negociações abandonadas: %v", err)
+		return
+	}
+
+	for _, n := range abandonadas {
+		motivo := fmt.Sprintf("Cancelada automaticamente: %d dias sem atividade em %s", n.Dias, n.Status)
+		_, err := negociacao.TransicionarStatus(m.DB, n.ID, models.StatusCancelada, auth.AtorSistemaPadrao(), motivo)
+		if errors.Is(err, negociacao.ErrTransicaoNaoPermitida) {
+			// o status mudou desde a consulta (outra instância ou o usuário)
+			// ou a etapa não pode ir para cancelada; o erro diz qual.
+			log.Printf("[sla] negociação %d não cancelada: %v", n.ID, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("[sla] erro ao cancelar negociação %d: %v", n.ID, err)
+			continue
+		}
+		if err := m.DB.Create(&models.Comentario{NegociacaoID: n.ID, Texto: motivo, IsSystem: true}).Error; err != nil {
+			log.Printf("[sla] erro ao comentar cancelamento da negociação %d: %v", n.ID, err)
+		}
+		m.notificar(n, "negociacao_cancelada_sla", "Negociação cancelada por inatividade", fmt.Sprintf("%s: %s", n.Nome, motivo))
+	}
+}
+
+// notificar avisa o consultor e o comercial do time.
+func (m *Monitor) notificar(n negociacaoParada, tipo, titulo, mensagem string) {
+	if m.Notificador == nil {
+		return
+	}
+	destinos := []notificacao.Notificacao{{DestinatarioTipo: notificacao.DestinoConsultor, DestinatarioID: n.ConsultorID}}
+	if n.ComercialID != nil && *n.ComercialID != 0 {
+		destinos = append(destinos, notificacao.Notificacao{DestinatarioTipo: notificacao.DestinoComercial, DestinatarioID: *n.ComercialID})
+	}
+	for _, d := range destinos {
+		d.Tipo, d.Titulo, d.Mensagem, d.NegociacaoID = tipo, titulo, mensagem, n.ID
+		d.Dados = map[string]any{"status": n.Status, "dias": n.Dias}
+		if err := m.Notificador.Notificar(d); err != nil {
+			log.Printf("[sla] erro ao notificar negociação %d: %v", n.ID, err)
+		}
+	}
+}
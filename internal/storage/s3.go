@@ -0,0 +1,120 @@
+// internal/storage/s3.go
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3 grava em qualquer bucket compatível com a API do S3 (AWS, MinIO, R2...).
+type S3 struct {
+	Client    *s3.Client
+	Bucket    string
+	PublicURL string // ex.: https://cdn.kroma.com.br ou https://bucket.s3.amazonaws.com
+}
+
+// NewS3FromEnv monta o cliente a partir de:
+//   - S3_BUCKET (obrigatório)
+//   - S3_REGION, S3_ENDPOINT (opcional, para provedores compatíveis)
+//   - S3_FORCE_PATH_STYLE=true (MinIO e afins)
+//   - S3_PUBLIC_URL (base das URLs devolvidas; default = endpoint/bucket)
+//
+// As credenciais seguem a cadeia padrão da AWS (env, profile, role).
+func NewS3FromEnv(ctx context.Context) (*S3, error) {
+	bucket := os.Getenv("S3_BUCKET")
+	if bucket == "" {
+		return nil, errors.New("S3_BUCKET não configurado")
+	}
+
+	opts := []func(*config.LoadOptions) error{}
+	if region := os.Getenv("S3_REGION"); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("config aws: %w", err)
+	}
+
+	endpoint := os.Getenv("S3_ENDPOINT")
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = os.Getenv("S3_FORCE_PATH_STYLE") == "true"
+	})
+
+	publicURL := os.Getenv("S3_PUBLIC_URL")
+	if publicURL == "" {
+		if endpoint != "" {
+			publicURL = strings.TrimSuffix(endpoint, "/") + "/" + bucket
+		} else {
+			publicURL = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, cfg.Region)
+		}
+	}
+
+	return &S3{Client: client, Bucket: bucket, PublicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error) {
+	key, err := normalizarKey(key)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.Bucket),
+		Key:           aws.String(key),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(contentType),
+		// chaves são endereçadas por conteúdo, então o objeto nunca muda
+		CacheControl: aws.String("public, max-age=31536000, immutable"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("s3 put %s: %w", key, err)
+	}
+	return s.URL(key), nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := normalizarKey(key)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, ErrNaoEncontrado
+		}
+		return nil, fmt.Errorf("s3 get %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := normalizarKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (s *S3) URL(key string) string {
+	return s.PublicURL + "/" + strings.TrimLeft(key, "/")
+}
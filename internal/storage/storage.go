@@ -0,0 +1,82 @@
+// internal/storage/storage.go
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrNaoEncontrado é retornado quando a chave não existe no backend.
+var ErrNaoEncontrado = errors.New("arquivo não encontrado")
+
+// Storage abstrai onde os arquivos enviados ficam guardados.
+// As chaves são caminhos relativos (ex.: "consultores/12/foto/abc.jpg") e
+// a URL retornada por Put é estável: a mesma chave sempre gera a mesma URL.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) (string, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// NewFromEnv escolhe o backend pela variável STORAGE_DRIVER ("local" | "s3").
+// Sem configuração, usa o disco local (modo dev/testes).
+func NewFromEnv(ctx context.Context) (Storage, error) {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_DRIVER"))) {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./uploads"
+		}
+		baseURL := os.Getenv("STORAGE_PUBLIC_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080/arquivos"
+		}
+		return NewLocal(dir, baseURL)
+	case "s3":
+		return NewS3FromEnv(ctx)
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER desconhecido: %q", os.Getenv("STORAGE_DRIVER"))
+	}
+}
+
+// KeyFromURL devolve a chave de um arquivo a partir da URL pública, se ela
+// pertencer a este storage.
+func KeyFromURL(s Storage, url string) (string, bool) {
+	base := strings.TrimSuffix(s.URL(""), "/")
+	if base == "" || !strings.HasPrefix(url, base+"/") {
+		return "", false
+	}
+	return strings.TrimPrefix(url, base+"/"), true
+}
+
+// SufixoAleatorio devolve 32 caracteres hexadecimais aleatórios para compor
+// chaves de arquivos gerados pelo sistema. Os arquivos são servidos sem
+// autenticação pela URL, então a chave não pode ser deduzível a partir de IDs.
+func SufixoAleatorio() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// limpa a chave para evitar path traversal e barras duplicadas
+func normalizarKey(key string) (string, error) {
+	key = strings.TrimLeft(strings.ReplaceAll(key, "\\", "/"), "/")
+	if key == "" {
+		return "", errors.New("chave vazia")
+	}
+	for _, parte := range strings.Split(key, "/") {
+		if parte == "" || parte == "." || parte == ".." {
+			return "", fmt.Errorf("chave inválida: %q", key)
+		}
+	}
+	return key, nil
+}
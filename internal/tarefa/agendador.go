@@ -0,0 +1,104 @@
+package tarefa
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/notificacao"
+	"gorm.io/gorm"
+)
+
+// Agendador varre as tarefas abertas e envia, uma única vez cada, o lembrete
+// (vencimento próximo) e o aviso de atraso pelo notificador configurado.
+type Agendador struct {
+	DB           *gorm.DB
+	Notificador  notificacao.Notificador
+	Intervalo    time.Duration // de quanto em quanto tempo varre
+	Antecedencia time.Duration // quanto antes do vencimento sai o lembrete
+}
+
+// NewAgendadorFromEnv lê TAREFA_AGENDADOR_INTERVALO (padrão 1m) e
+// TAREFA_LEMBRETE_ANTECEDENCIA (padrão 1h), no formato de time.ParseDuration.
+func NewAgendadorFromEnv(db *gorm.DB, notif notificacao.Notificador) *Agendador {
+	return &Agendador{
+		DB:           db,
+		Notificador:  notif,
+		Intervalo:    duracaoEnv("TAREFA_AGENDADOR_INTERVALO", time.Minute),
+		Antecedencia: duracaoEnv("TAREFA_LEMBRETE_ANTECEDENCIA", time.Hour),
+	}
+}
+
+func duracaoEnv(nome string, padrao time.Duration) time.Duration {
+	if v := os.Getenv(nome); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("[tarefa] %s inválido (%q); usando %s", nome, v, padrao)
+	}
+	return padrao
+}
+
+// Iniciar roda o agendador em background até o contexto ser cancelado.
+func (a *Agendador) Iniciar(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(a.Intervalo)
+		defer ticker.Stop()
+		for {
+			a.Executar(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Executar faz uma varredura. Cada aviso é "reservado" com um UPDATE
+// condicional antes do envio, então várias instâncias da API não duplicam.
+func (a *Agendador) Executar(agora time.Time) {
+	a.avisar(agora, "lembrete_enviado_em", "vencimento <= ? AND vencimento >= ?",
+		[]any{agora.Add(a.Antecedencia), agora}, "tarefa_lembrete", "Tarefa vence em breve")
+	a.avisar(agora, "atraso_avisado_em", "vencimento < ?",
+		[]any{agora}, "tarefa_atrasada", "Tarefa atrasada")
+}
+
+func (a *Agendador) avisar(agora time.Time, coluna, cond string, args []any, tipo, titulo string) {
+	var pendentes []Tarefa
+	err := a.DB.Where("concluida = false AND "+coluna+" IS NULL").
+		Where(cond, args...).
+		Order("vencimento ASC").
+		Limit(500).
+		Find(&pendentes).Error
+	if err != nil {
+		log.Printf("[tarefa] erro ao buscar %s: %v", tipo, err)
+		return
+	}
+
+	for _, t := range pendentes {
+		res := a.DB.Model(&Tarefa{}).
+			Where("id = ? AND "+coluna+" IS NULL", t.ID).
+			Update(coluna, agora)
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue // outra instância já avisou
+		}
+		if a.Notificador == nil {
+			continue
+		}
+		err := a.Notificador.Notificar(notificacao.Notificacao{
+			Tipo:             tipo,
+			DestinatarioTipo: t.ResponsavelTipo,
+			DestinatarioID:   t.ResponsavelID,
+			Titulo:           fmt.Sprintf("%s: %s", titulo, t.Titulo),
+			Mensagem:         fmt.Sprintf("Vencimento em %s", t.Vencimento.Format("02/01/2006 15:04")),
+			NegociacaoID:     t.NegociacaoID,
+			Dados:            map[string]any{"tarefaId": t.ID},
+		})
+		if err != nil {
+			log.Printf("[tarefa] erro ao notificar tarefa %d: %v", t.ID, err)
+		}
+	}
+}
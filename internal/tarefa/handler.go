@@ -0,0 +1,342 @@
+package tarefa
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler encapsula o DB e o Repository de tarefas
+type Handler struct {
+	DB         *gorm.DB
+	Repository Repository
+}
+
+// NewHandler cria um novo handler de tarefas
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db, Repository: NewRepository()}
+}
+
+// tarefaRequest é o corpo de criação/atualização.
+type tarefaRequest struct {
+	Titulo          string    `json:"titulo"`
+	Descricao       string    `json:"descricao"`
+	Vencimento      time.Time `json:"vencimento"`
+	ResponsavelTipo string    `json:"responsavelTipo"` // "consultor" | "comercial"; padrão: quem cria
+	ResponsavelID   uint      `json:"responsavelId"`   // opcional; ver definirResponsavel
+}
+
+type concluirRequest struct {
+	Concluida *bool `json:"concluida"` // padrão true
+}
+
+// POST /negociacoes/{id}/tarefas
+func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	var req tarefaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	ator := auth.AtorDaRequisicao(r)
+	t := Tarefa{
+		NegociacaoID:  neg.ID,
+		CriadoPorTipo: ator.Tipo,
+		CriadoPorID:   ator.ID,
+	}
+	if !h.aplicar(w, &t, req, neg, ator) {
+		return
+	}
+	if err := h.Repository.Salvar(h.DB, &t); err != nil {
+		http.Error(w, "Erro ao salvar tarefa", http.StatusInternalServerError)
+		return
+	}
+
+	t.MarcarAtraso(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// GET /negociacoes/{id}/tarefas
+func (h *Handler) ListarPorNegociacao(w http.ResponseWriter, r *http.Request) {
+	neg, ok := h.carregarNegociacao(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	list, err := h.Repository.ListarPorNegociacao(h.DB, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao listar tarefas", http.StatusInternalServerError)
+		return
+	}
+	responderLista(w, list)
+}
+
+// GET /tarefas/minhas?filtro=hoje|atrasadas|abertas
+// "hoje" (padrão) traz o que vence até o fim do dia, incluindo as atrasadas.
+func (h *Handler) Minhas(w http.ResponseWriter, r *http.Request) {
+	ator := auth.AtorDaRequisicao(r)
+
+	var (
+		ate       *time.Time
+		atrasadas bool
+	)
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("filtro"))) {
+	case "", "hoje":
+		agora := time.Now()
+		fimDoDia := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location()).AddDate(0, 0, 1)
+		ate = &fimDoDia
+	case "atrasadas":
+		atrasadas = true
+	case "abertas":
+	default:
+		http.Error(w, "filtro deve ser 'hoje', 'atrasadas' ou 'abertas'", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.Repository.ListarDoResponsavel(h.DB, ator.Tipo, ator.ID, ate, atrasadas)
+	if err != nil {
+		http.Error(w, "Erro ao listar tarefas", http.StatusInternalServerError)
+		return
+	}
+	responderLista(w, list)
+}
+
+// PUT /tarefas/{id}
+func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
+	t, neg, ok := h.carregarTarefa(w, r)
+	if !ok {
+		return
+	}
+
+	var req tarefaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	vencimentoAnterior := t.Vencimento
+	if !h.aplicar(w, t, req, neg, auth.AtorDaRequisicao(r)) {
+		return
+	}
+	// novo vencimento: os avisos voltam a valer
+	if !t.Vencimento.Equal(vencimentoAnterior) {
+		t.LembreteEnviadoEm = nil
+		t.AtrasoAvisadoEm = nil
+	}
+
+	if err := h.Repository.Salvar(h.DB, t); err != nil {
+		http.Error(w, "Erro ao salvar tarefa", http.StatusInternalServerError)
+		return
+	}
+	t.MarcarAtraso(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// PATCH /tarefas/{id}/concluir — body opcional: { "concluida": false } reabre
+func (h *Handler) Concluir(w http.ResponseWriter, r *http.Request) {
+	t, _, ok := h.carregarTarefa(w, r)
+	if !ok {
+		return
+	}
+
+	req := concluirRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "JSON inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	t.Concluida = req.Concluida == nil || *req.Concluida
+	t.ConcluidaEm = nil
+	if t.Concluida {
+		agora := time.Now()
+		t.ConcluidaEm = &agora
+	}
+
+	if err := h.Repository.Salvar(h.DB, t); err != nil {
+		http.Error(w, "Erro ao salvar tarefa", http.StatusInternalServerError)
+		return
+	}
+	t.MarcarAtraso(time.Now())
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(t)
+}
+
+// DELETE /tarefas/{id}
+func (h *Handler) Deletar(w http.ResponseWriter, r *http.Request) {
+	t, _, ok := h.carregarTarefa(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Repository.Deletar(h.DB, t.ID); err != nil {
+		http.Error(w, "Erro ao excluir tarefa", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// aplicar valida o payload e copia para a tarefa; em caso de erro já responde.
+func (h *Handler) aplicar(w http.ResponseWriter, t *Tarefa, req tarefaRequest, neg *models.Negociacao, ator auth.Ator) bool {
+	titulo := strings.TrimSpace(req.Titulo)
+	if titulo == "" {
+		http.Error(w, "O campo 'titulo' é obrigatório", http.StatusBadRequest)
+		return false
+	}
+	if req.Vencimento.IsZero() {
+		http.Error(w, "O campo 'vencimento' é obrigatório", http.StatusBadRequest)
+		return false
+	}
+
+	tipo, id, msg, err := h.definirResponsavel(req, neg, ator)
+	if err != nil {
+		http.Error(w, "Erro ao verificar responsável", http.StatusInternalServerError)
+		return false
+	}
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return false
+	}
+
+	t.Titulo = titulo
+	t.Descricao = strings.TrimSpace(req.Descricao)
+	t.Vencimento = req.Vencimento
+	t.ResponsavelTipo = tipo
+	t.ResponsavelID = id
+	return true
+}
+
+// definirResponsavel resolve quem responde pela tarefa. O consultor é sempre o
+// dono da negociação; o comercial informado tem de ser o do time do dono, e,
+// sem ID informado, vale quem está criando (se for comercial) ou o comercial
+// do consultor.
+func (h *Handler) definirResponsavel(req tarefaRequest, neg *models.Negociacao, ator auth.Ator) (string, uint, string, error) {
+	tipo := strings.ToLower(strings.TrimSpace(req.ResponsavelTipo))
+	if tipo == "" {
+		tipo = ator.Tipo
+	}
+
+	switch tipo {
+	case auth.AtorConsultor:
+		if req.ResponsavelID != 0 && req.ResponsavelID != neg.ConsultorID {
+			return "", 0, "o consultor responsável deve ser o dono da negociação", nil
+		}
+		return tipo, neg.ConsultorID, "", nil
+	case auth.AtorComercial:
+		if req.ResponsavelID == 0 && ator.Tipo == auth.AtorComercial {
+			return tipo, ator.ID, "", nil
+		}
+		var c struct{ ComercialID *uint }
+		err := h.DB.Table("consultors c").
+			Select("c.comercial_id").
+			Joins("JOIN comercials m ON m.id = c.comercial_id").
+			Where("c.id = ?", neg.ConsultorID).
+			Take(&c).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", 0, "", err
+		}
+		if req.ResponsavelID != 0 {
+			if c.ComercialID == nil || *c.ComercialID != req.ResponsavelID {
+				return "", 0, "o comercial responsável deve ser o do time da negociação", nil
+			}
+			return tipo, req.ResponsavelID, "", nil
+		}
+		if c.ComercialID == nil || *c.ComercialID == 0 {
+			return "", 0, "o consultor não tem comercial vinculado", nil
+		}
+		return tipo, *c.ComercialID, "", nil
+	}
+	return "", 0, "o campo 'responsavelTipo' deve ser 'consultor' ou 'comercial'", nil
+}
+
+// carregarNegociacao confere se a negociação está no escopo do usuário; em
+// caso de erro já responde.
+func (h *Handler) carregarNegociacao(w http.ResponseWriter, r *http.Request, idStr string) (*models.Negociacao, bool) {
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id", "status").First(&neg, id).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return nil, false
+	}
+	pode, err := h.noEscopo(r, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !pode {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, false
+	}
+	return &neg, true
+}
+
+// carregarTarefa lê {id}; pode mexer o responsável ou quem tem a negociação
+// no escopo (dono, comercial do time ou admin).
+func (h *Handler) carregarTarefa(w http.ResponseWriter, r *http.Request) (*Tarefa, *models.Negociacao, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, nil, false
+	}
+	t, err := h.Repository.BuscarPorID(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "Tarefa não encontrada", http.StatusNotFound)
+		return nil, nil, false
+	}
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id", "status").First(&neg, t.NegociacaoID).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	ator := auth.AtorDaRequisicao(r)
+	if t.ResponsavelTipo == ator.Tipo && t.ResponsavelID == ator.ID {
+		return t, &neg, true
+	}
+	pode, err := h.noEscopo(r, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	if !pode {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, nil, false
+	}
+	return t, &neg, true
+}
+
+// noEscopo diz se a negociação está no escopo do usuário da requisição.
+func (h *Handler) noEscopo(r *http.Request, negociacaoID uint) (bool, error) {
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		return false, err
+	}
+	return e.AlcancaNegociacao(h.DB, negociacaoID)
+}
+
+func responderLista(w http.ResponseWriter, list []Tarefa) {
+	agora := time.Now()
+	for i := range list {
+		list[i].MarcarAtraso(agora)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
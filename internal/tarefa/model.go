@@ -0,0 +1,39 @@
+package tarefa
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tarefa é um follow-up ligado a uma negociação ("ligar para o cliente quinta").
+// O responsável pode ser o consultor ou o comercial (mesma convenção de auth.Ator).
+type Tarefa struct {
+	gorm.Model
+
+	NegociacaoID uint      `gorm:"not null;index" json:"negociacaoId"`
+	Titulo       string    `gorm:"size:200;not null" json:"titulo"`
+	Descricao    string    `json:"descricao"`
+	Vencimento   time.Time `gorm:"not null;index" json:"vencimento"`
+
+	ResponsavelTipo string `gorm:"size:20;not null;index:idx_tarefa_responsavel" json:"responsavelTipo"` // "consultor" | "comercial"
+	ResponsavelID   uint   `gorm:"not null;index:idx_tarefa_responsavel" json:"responsavelId"`
+
+	CriadoPorTipo string `gorm:"size:20" json:"criadoPorTipo"`
+	CriadoPorID   uint   `json:"criadoPorId"`
+
+	Concluida   bool       `gorm:"not null;default:false;index" json:"concluida"`
+	ConcluidaEm *time.Time `json:"concluidaEm,omitempty"`
+
+	// Controle do agendador: cada aviso é enviado uma única vez
+	LembreteEnviadoEm *time.Time `json:"-"`
+	AtrasoAvisadoEm   *time.Time `json:"-"`
+
+	// Calculado na leitura (não persiste)
+	Atrasada bool `gorm:"-" json:"atrasada"`
+}
+
+// MarcarAtraso preenche o campo calculado Atrasada.
+func (t *Tarefa) MarcarAtraso(agora time.Time) {
+	t.Atrasada = !t.Concluida && t.Vencimento.Before(agora)
+}
@@ -0,0 +1,62 @@
+package tarefa
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository define operações de persistência para Tarefa
+type Repository interface {
+	Salvar(db *gorm.DB, t *Tarefa) error
+	BuscarPorID(db *gorm.DB, id uint) (*Tarefa, error)
+	ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]Tarefa, error)
+	ListarDoResponsavel(db *gorm.DB, tipo string, id uint, ate *time.Time, somenteAtrasadas bool) ([]Tarefa, error)
+	Deletar(db *gorm.DB, id uint) error
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) Salvar(db *gorm.DB, t *Tarefa) error {
+	return db.Save(t).Error
+}
+
+func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*Tarefa, error) {
+	var t Tarefa
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListarPorNegociacao retorna as tarefas da negociação: abertas primeiro, por vencimento.
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]Tarefa, error) {
+	var list []Tarefa
+	err := db.Where("negociacao_id = ?", negociacaoID).
+		Order("concluida ASC, vencimento ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// ListarDoResponsavel retorna as tarefas abertas do responsável, vencendo até
+// `ate` (nil = todas) ou só as já vencidas.
+func (r *repositoryImpl) ListarDoResponsavel(db *gorm.DB, tipo string, id uint, ate *time.Time, somenteAtrasadas bool) ([]Tarefa, error) {
+	q := db.Where("responsavel_tipo = ? AND responsavel_id = ? AND concluida = false", tipo, id)
+	if somenteAtrasadas {
+		q = q.Where("vencimento < ?", time.Now())
+	} else if ate != nil {
+		q = q.Where("vencimento < ?", *ate)
+	}
+	var list []Tarefa
+	err := q.Order("vencimento ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) Deletar(db *gorm.DB, id uint) error {
+	return db.Delete(&Tarefa{}, id).Error
+}
@@ -0,0 +1,192 @@
+// internal/unidade/csv.go
+package unidade
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tamanho máximo do CSV de histórico (alguns anos de faturas cabem com folga).
+const maxBytesCSV = 1 << 20
+
+var (
+	ErrCSVVazio         = errors.New("arquivo CSV vazio")
+	ErrCSVSemReferencia = errors.New("o CSV precisa da coluna 'referencia'")
+)
+
+// colunasCSV mapeia os nomes aceitos no cabeçalho para o campo.
+var colunasCSV = map[string]string{
+	"referencia":             "referencia",
+	"mes":                    "referencia",
+	"competencia":            "referencia",
+	"consumo_ponta_kwh":      "ponta",
+	"ponta_kwh":              "ponta",
+	"ponta":                  "ponta",
+	"consumo_fora_ponta_kwh": "fora_ponta",
+	"fora_ponta_kwh":         "fora_ponta",
+	"fora_ponta":             "fora_ponta",
+	"demanda_kw":             "demanda",
+	"demanda":                "demanda",
+	"valor":                  "valor",
+	"valor_total":            "valor",
+}
+
+// layoutsReferencia são os formatos aceitos para o mês de referência.
+var layoutsReferencia = []string{"2006-01", "01/2006", "2006-01-02", "02/01/2006"}
+
+// ErroLinha aponta uma linha inválida do CSV (a linha 1 é o cabeçalho).
+type ErroLinha struct {
+	Linha int    `json:"linha"`
+	Erro  string `json:"erro"`
+}
+
+// LerCSV interpreta o histórico de consumo. O cabeçalho é obrigatório e as
+// colunas podem vir em qualquer ordem: referencia, consumo_ponta_kwh,
+// consumo_fora_ponta_kwh, demanda_kw, valor. Aceita "," ou ";" como
+// separador e números no formato brasileiro ("1.234,56").
+// Devolve os meses válidos e os erros por linha; err só para falhas de leitura.
+func LerCSV(r io.Reader, unidadeID uint) ([]ConsumoMensal, []ErroLinha, error) {
+	dados, err := io.ReadAll(io.LimitReader(r, maxBytesCSV+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(dados) > maxBytesCSV {
+		return nil, nil, fmt.Errorf("arquivo CSV maior que %d bytes", maxBytesCSV)
+	}
+	dados = bytes.TrimPrefix(dados, []byte("\xef\xbb\xbf")) // BOM do Excel
+	if len(bytes.TrimSpace(dados)) == 0 {
+		return nil, nil, ErrCSVVazio
+	}
+
+	leitor := csv.NewReader(bytes.NewReader(dados))
+	leitor.Comma = separador(dados)
+	leitor.FieldsPerRecord = -1
+	leitor.TrimLeadingSpace = true
+	linhas, err := leitor.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("CSV inválido: %w", err)
+	}
+
+	indice := map[string]int{}
+	for i, nome := range linhas[0] {
+		nome = strings.ToLower(strings.TrimSpace(nome))
+		nome = strings.NewReplacer(" ", "_", "ê", "e").Replace(nome)
+		if campo, ok := colunasCSV[nome]; ok {
+			indice[campo] = i
+		}
+	}
+	if _, ok := indice["referencia"]; !ok {
+		return nil, nil, ErrCSVSemReferencia
+	}
+
+	var consumos []ConsumoMensal
+	var erros []ErroLinha
+	vistos := map[time.Time]int{}
+	for n, linha := range linhas[1:] {
+		numero := n + 2
+		if vazia(linha) {
+			continue
+		}
+		c, err := lerLinha(linha, indice)
+		if err != nil {
+			erros = append(erros, ErroLinha{Linha: numero, Erro: err.Error()})
+			continue
+		}
+		if anterior, ok := vistos[c.Referencia]; ok {
+			erros = append(erros, ErroLinha{Linha: numero, Erro: fmt.Sprintf("mês repetido (já está na linha %d)", anterior)})
+			continue
+		}
+		vistos[c.Referencia] = numero
+		c.UnidadeID = unidadeID
+		c.Origem = OrigemCSV
+		consumos = append(consumos, c)
+	}
+	return consumos, erros, nil
+}
+
+func lerLinha(linha []string, indice map[string]int) (ConsumoMensal, error) {
+	campo := func(nome string) string {
+		if i, ok := indice[nome]; ok && i < len(linha) {
+			return strings.TrimSpace(linha[i])
+		}
+		return ""
+	}
+
+	var c ConsumoMensal
+	ref, err := LerReferencia(campo("referencia"))
+	if err != nil {
+		return c, err
+	}
+	c.Referencia = ref
+
+	for _, v := range []struct {
+		nome string
+		dst  *float64
+	}{
+		{"ponta", &c.ConsumoPontaKWh},
+		{"fora_ponta", &c.ConsumoForaPontaKWh},
+		{"demanda", &c.DemandaKW},
+		{"valor", &c.Valor},
+	} {
+		if *v.dst, err = lerNumero(campo(v.nome)); err != nil {
+			return c, fmt.Errorf("%s: %w", v.nome, err)
+		}
+	}
+	return c, validarConsumo(c)
+}
+
+// LerReferencia aceita "AAAA-MM", "MM/AAAA" ou uma data completa e devolve o dia 1 do mês.
+func LerReferencia(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range layoutsReferencia {
+		if t, err := time.Parse(layout, s); err == nil {
+			return inicioDoMes(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("referência inválida %q (use AAAA-MM ou MM/AAAA)", s)
+}
+
+// milharSemDecimal reconhece "10.000" / "1.234.567" (ponto como milhar).
+var milharSemDecimal = regexp.MustCompile(`^\d{1,3}(\.\d{3})+$`)
+
+// lerNumero aceita "1234.5", "1.234,5", "10.000" e "R$ 1.234,50"; vazio vale 0.
+func lerNumero(s string) (float64, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "R$"))
+	if s == "" {
+		return 0, nil
+	}
+	if strings.Contains(s, ",") || milharSemDecimal.MatchString(s) {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("número inválido %q", s)
+	}
+	return v, nil
+}
+
+// separador escolhe ";" quando o cabeçalho tem mais ";" do que ",".
+func separador(dados []byte) rune {
+	cabecalho, _, _ := bytes.Cut(dados, []byte("\n"))
+	if bytes.Count(cabecalho, []byte(";")) > bytes.Count(cabecalho, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func vazia(linha []string) bool {
+	for _, v := range linha {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,488 @@
+// internal/unidade/handler.go
+package unidade
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KromaEnergia/api-consultor/internal/cliente"
+	"github.com/KromaEnergia/api-consultor/internal/negociacao"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler encapsula DB e repository das unidades consumidoras
+type Handler struct {
+	DB         *gorm.DB
+	Repository Repository
+	Clientes   cliente.Repository
+}
+
+// NewHandler cria o handler de unidades consumidoras
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db, Repository: NewRepository(), Clientes: cliente.NewRepository()}
+}
+
+type unidadeRequest struct {
+	NegociacaoID               *uint   `json:"negociacaoId"`
+	CodigoInstalacao           string  `json:"codigoInstalacao"`
+	Distribuidora              string  `json:"distribuidora"`
+	Grupo                      string  `json:"grupo"`
+	Subgrupo                   string  `json:"subgrupo"`
+	Modalidade                 string  `json:"modalidade"`
+	Descricao                  string  `json:"descricao"`
+	DemandaContratadaPonta     float64 `json:"demandaContratadaPonta"`
+	DemandaContratadaForaPonta float64 `json:"demandaContratadaForaPonta"`
+}
+
+type consumoRequest struct {
+	Referencia          string  `json:"referencia"` // "AAAA-MM" ou "MM/AAAA"
+	ConsumoPontaKWh     float64 `json:"consumoPontaKWh"`
+	ConsumoForaPontaKWh float64 `json:"consumoForaPontaKWh"`
+	DemandaKW           float64 `json:"demandaKW"`
+	Valor               float64 `json:"valor"`
+}
+
+/* ================== Unidades ================== */
+
+// ListarPorCliente trata GET /clientes/{id}/unidades (com o resumo dos últimos 12 meses)
+func (h *Handler) ListarPorCliente(w http.ResponseWriter, r *http.Request) {
+	clienteID, ok := h.carregarCliente(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	list, err := h.Repository.ListarPorCliente(h.DB, clienteID)
+	if err != nil {
+		http.Error(w, "Erro ao listar unidades", http.StatusInternalServerError)
+		return
+	}
+	h.responderComResumo(w, list)
+}
+
+// ListarPorNegociacao trata GET /negociacoes/{id}/unidades
+func (h *Handler) ListarPorNegociacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	cond, args := escopo.SQL("n")
+	var qtd int64
+	if err := h.DB.Table("negociacaos AS n").
+		Where("n.id = ? AND n.deleted_at IS NULL", id).
+		Where(cond, args...).
+		Count(&qtd).Error; err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if qtd == 0 {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+
+	list, err := h.Repository.ListarPorNegociacao(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "Erro ao listar unidades", http.StatusInternalServerError)
+		return
+	}
+	h.responderComResumo(w, list)
+}
+
+func (h *Handler) responderComResumo(w http.ResponseWriter, list []UnidadeConsumidora) {
+	for i := range list {
+		hist, err := h.Repository.Historico(h.DB, list[i].ID, mesesHistorico, time.Now())
+		if err != nil {
+			http.Error(w, "Erro ao carregar consumo", http.StatusInternalServerError)
+			return
+		}
+		resumo := Resumir(hist)
+		list[i].Resumo = &resumo
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+// Criar trata POST /clientes/{id}/unidades
+func (h *Handler) Criar(w http.ResponseWriter, r *http.Request) {
+	clienteID, ok := h.carregarCliente(w, r, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+	var req unidadeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+
+	u := UnidadeConsumidora{ClienteID: clienteID}
+	if !h.aplicar(w, &u, req) {
+		return
+	}
+	if err := h.Repository.Salvar(h.DB, &u); err != nil {
+		http.Error(w, "Erro ao salvar unidade", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(u)
+}
+
+// BuscarPorID trata GET /unidades/{id} (com os últimos 12 meses de consumo)
+func (h *Handler) BuscarPorID(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	hist, err := h.Repository.Historico(h.DB, u.ID, mesesHistorico, time.Now())
+	if err != nil {
+		http.Error(w, "Erro ao carregar consumo", http.StatusInternalServerError)
+		return
+	}
+	resumo := Resumir(hist)
+	u.Consumos, u.Resumo = hist, &resumo
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(u)
+}
+
+// Atualizar trata PUT /unidades/{id}
+func (h *Handler) Atualizar(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	var req unidadeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido", http.StatusBadRequest)
+		return
+	}
+	if !h.aplicar(w, u, req) {
+		return
+	}
+	if err := h.Repository.Salvar(h.DB, u); err != nil {
+		http.Error(w, "Erro ao salvar unidade", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(u)
+}
+
+// Deletar trata DELETE /unidades/{id}
+func (h *Handler) Deletar(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Repository.Deletar(h.DB, u); err != nil {
+		http.Error(w, "Erro ao remover unidade", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// aplicar valida o payload e copia para a unidade; em caso de erro já responde.
+func (h *Handler) aplicar(w http.ResponseWriter, u *UnidadeConsumidora, req unidadeRequest) bool {
+	codigo := strings.TrimSpace(req.CodigoInstalacao)
+	distribuidora := strings.TrimSpace(req.Distribuidora)
+	if codigo == "" || distribuidora == "" {
+		http.Error(w, "Os campos 'codigoInstalacao' e 'distribuidora' são obrigatórios", http.StatusBadRequest)
+		return false
+	}
+
+	subgrupo := strings.TrimSpace(req.Subgrupo)
+	grupo := strings.ToUpper(strings.TrimSpace(req.Grupo))
+	if grupo == "" && subgrupo != "" {
+		grupo = strings.ToUpper(subgrupo[:1])
+	}
+	if grupo != GrupoA && grupo != GrupoB {
+		http.Error(w, "O campo 'grupo' deve ser 'A' ou 'B'", http.StatusBadRequest)
+		return false
+	}
+	if subgrupo != "" && !strings.EqualFold(subgrupo[:1], grupo) {
+		http.Error(w, "O subgrupo não pertence ao grupo informado", http.StatusBadRequest)
+		return false
+	}
+
+	modalidade := ""
+	if m := strings.TrimSpace(req.Modalidade); m != "" {
+		for _, v := range modalidades {
+			if strings.EqualFold(v, m) {
+				modalidade = v
+			}
+		}
+		if modalidade == "" {
+			http.Error(w, "O campo 'modalidade' deve ser "+strings.Join(modalidades, ", "), http.StatusBadRequest)
+			return false
+		}
+	}
+	if req.DemandaContratadaPonta < 0 || req.DemandaContratadaForaPonta < 0 {
+		http.Error(w, "A demanda contratada não pode ser negativa", http.StatusBadRequest)
+		return false
+	}
+
+	if req.NegociacaoID != nil {
+		var neg struct{ ClienteID *uint }
+		err := h.DB.Table("negociacaos").Select("cliente_id").
+			Where("id = ? AND deleted_at IS NULL", *req.NegociacaoID).Take(&neg).Error
+		if err != nil {
+			http.Error(w, "Negociação não encontrada", http.StatusBadRequest)
+			return false
+		}
+		if neg.ClienteID == nil || *neg.ClienteID != u.ClienteID {
+			http.Error(w, "A negociação não é deste cliente", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	var outra UnidadeConsumidora
+	err := h.DB.Select("id").
+		Where("distribuidora = ? AND codigo_instalacao = ? AND id <> ?", distribuidora, codigo, u.ID).
+		First(&outra).Error
+	if err == nil {
+		http.Error(w, "Já existe uma unidade com esse código de instalação nessa distribuidora", http.StatusConflict)
+		return false
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Erro ao verificar unidade", http.StatusInternalServerError)
+		return false
+	}
+
+	u.NegociacaoID = req.NegociacaoID
+	u.CodigoInstalacao = codigo
+	u.Distribuidora = distribuidora
+	u.Grupo = grupo
+	u.Subgrupo = subgrupo
+	u.Modalidade = modalidade
+	u.Descricao = strings.TrimSpace(req.Descricao)
+	u.DemandaContratadaPonta = req.DemandaContratadaPonta
+	u.DemandaContratadaForaPonta = req.DemandaContratadaForaPonta
+	return true
+}
+
+/* ================== Histórico de consumo ================== */
+
+// Historico trata GET /unidades/{id}/consumos?meses=12
+func (h *Handler) Historico(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	meses, _ := strconv.Atoi(r.URL.Query().Get("meses"))
+	if meses <= 0 {
+		meses = mesesHistorico
+	}
+	meses = min(meses, 120)
+
+	hist, err := h.Repository.Historico(h.DB, u.ID, meses, time.Now())
+	if err != nil {
+		http.Error(w, "Erro ao carregar consumo", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"consumos": hist,
+		"resumo":   Resumir(hist),
+	})
+}
+
+// SalvarConsumos trata PUT /unidades/{id}/consumos
+// Body: [ { "referencia": "2024-05", "consumoPontaKWh": 0, "consumoForaPontaKWh": 0, "demandaKW": 0, "valor": 0 } ]
+// Meses já existentes são substituídos.
+func (h *Handler) SalvarConsumos(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	var req []consumoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "JSON inválido (esperado uma lista de meses)", http.StatusBadRequest)
+		return
+	}
+	if len(req) == 0 {
+		http.Error(w, "Nenhum mês informado", http.StatusBadRequest)
+		return
+	}
+
+	consumos := make([]ConsumoMensal, 0, len(req))
+	vistos := map[time.Time]bool{}
+	for i, c := range req {
+		ref, err := LerReferencia(c.Referencia)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("item %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		if vistos[ref] {
+			http.Error(w, fmt.Sprintf("item %d: mês repetido", i+1), http.StatusBadRequest)
+			return
+		}
+		vistos[ref] = true
+		m := ConsumoMensal{
+			UnidadeID:           u.ID,
+			Referencia:          ref,
+			ConsumoPontaKWh:     c.ConsumoPontaKWh,
+			ConsumoForaPontaKWh: c.ConsumoForaPontaKWh,
+			DemandaKW:           c.DemandaKW,
+			Valor:               c.Valor,
+			Origem:              OrigemManual,
+		}
+		if err := validarConsumo(m); err != nil {
+			http.Error(w, fmt.Sprintf("item %d: %v", i+1, err), http.StatusBadRequest)
+			return
+		}
+		consumos = append(consumos, m)
+	}
+
+	if err := h.Repository.SalvarConsumos(h.DB, consumos); err != nil {
+		http.Error(w, "Erro ao salvar consumo", http.StatusInternalServerError)
+		return
+	}
+	h.responderHistorico(w, u.ID)
+}
+
+// ImportarCSV trata POST /unidades/{id}/consumos/importar
+// Aceita multipart (campo "arquivo") ou o CSV direto no corpo (text/csv).
+// Se alguma linha for inválida nada é gravado e a resposta lista os erros.
+func (h *Handler) ImportarCSV(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+
+	var fonte io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		arq, _, err := r.FormFile("arquivo")
+		if err != nil {
+			http.Error(w, "Envie o CSV no campo 'arquivo'", http.StatusBadRequest)
+			return
+		}
+		defer arq.Close()
+		fonte = arq
+	}
+
+	consumos, erros, err := LerCSV(fonte, u.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(erros) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]any{"erros": erros})
+		return
+	}
+	if len(consumos) == 0 {
+		http.Error(w, "O CSV não tem nenhum mês", http.StatusBadRequest)
+		return
+	}
+	if err := h.Repository.SalvarConsumos(h.DB, consumos); err != nil {
+		http.Error(w, "Erro ao salvar consumo", http.StatusInternalServerError)
+		return
+	}
+	h.responderHistorico(w, u.ID)
+}
+
+// RemoverConsumo trata DELETE /unidades/{id}/consumos/{referencia} (AAAA-MM)
+func (h *Handler) RemoverConsumo(w http.ResponseWriter, r *http.Request) {
+	u, ok := h.carregarUnidade(w, r)
+	if !ok {
+		return
+	}
+	ref, err := LerReferencia(mux.Vars(r)["referencia"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n, err := h.Repository.RemoverConsumo(h.DB, u.ID, ref)
+	if err != nil {
+		http.Error(w, "Erro ao remover consumo", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		http.Error(w, "Mês não encontrado", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) responderHistorico(w http.ResponseWriter, unidadeID uint) {
+	hist, err := h.Repository.Historico(h.DB, unidadeID, mesesHistorico, time.Now())
+	if err != nil {
+		http.Error(w, "Erro ao carregar consumo", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"consumos": hist,
+		"resumo":   Resumir(hist),
+	})
+}
+
+// validarConsumo rejeita valores negativos e meses que ainda não terminaram.
+func validarConsumo(c ConsumoMensal) error {
+	if c.ConsumoPontaKWh < 0 || c.ConsumoForaPontaKWh < 0 || c.DemandaKW < 0 || c.Valor < 0 {
+		return errors.New("valores não podem ser negativos")
+	}
+	if c.Referencia.After(inicioDoMes(time.Now())) {
+		return errors.New("referência no futuro")
+	}
+	return nil
+}
+
+/* ================== Helpers ================== */
+
+// carregarCliente confere se o cliente está no escopo do usuário; em caso de erro já responde.
+func (h *Handler) carregarCliente(w http.ResponseWriter, r *http.Request, idParam string) (uint, bool) {
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, false
+	}
+	if _, err := h.Clientes.BuscarPorID(h.DB, uint(id)); err != nil {
+		http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+		return 0, false
+	}
+	escopo, err := negociacao.EscopoDaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return 0, false
+	}
+	visivel, err := h.Clientes.Visivel(h.DB, escopo, uint(id))
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return 0, false
+	}
+	if !visivel {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// carregarUnidade lê {id} e confere o acesso pelo cliente da unidade.
+func (h *Handler) carregarUnidade(w http.ResponseWriter, r *http.Request) (*UnidadeConsumidora, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+	u, err := h.Repository.BuscarPorID(h.DB, uint(id))
+	if err != nil {
+		http.Error(w, "Unidade não encontrada", http.StatusNotFound)
+		return nil, false
+	}
+	if _, ok := h.carregarCliente(w, r, strconv.Itoa(int(u.ClienteID))); !ok {
+		return nil, false
+	}
+	return u, true
+}
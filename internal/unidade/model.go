@@ -0,0 +1,109 @@
+// internal/unidade/model.go
+package unidade
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Grupos tarifários (ANEEL)
+const (
+	GrupoA = "A" // alta tensão: tarifa binômia (consumo + demanda)
+	GrupoB = "B" // baixa tensão: só consumo
+)
+
+// Modalidades tarifárias
+var modalidades = []string{"Azul", "Verde", "Convencional", "Branca"}
+
+// Origem do registro de consumo
+const (
+	OrigemManual = "manual"
+	OrigemCSV    = "csv"
+)
+
+// UnidadeConsumidora é uma instalação do cliente na distribuidora. Pode estar
+// ligada a uma negociação específica do cliente.
+type UnidadeConsumidora struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deletedAt,omitempty"`
+
+	ClienteID    uint  `gorm:"not null;index" json:"clienteId"`
+	NegociacaoID *uint `gorm:"index" json:"negociacaoId"`
+
+	CodigoInstalacao string `gorm:"size:50;not null;uniqueIndex:idx_uc_instalacao,where:deleted_at IS NULL" json:"codigoInstalacao"`
+	Distribuidora    string `gorm:"size:100;not null;uniqueIndex:idx_uc_instalacao,where:deleted_at IS NULL" json:"distribuidora"`
+	Grupo            string `gorm:"size:1;not null" json:"grupo"` // "A" | "B"
+	Subgrupo         string `gorm:"size:5" json:"subgrupo"`       // ex.: "A4", "A3a", "B3"
+	Modalidade       string `gorm:"size:20" json:"modalidade"`    // "Azul" | "Verde" | "Convencional" | "Branca"
+	Descricao        string `gorm:"size:255" json:"descricao"`    // apelido/endereço
+
+	// Demanda contratada (kW); na modalidade Verde só vale a fora ponta
+	DemandaContratadaPonta     float64 `gorm:"not null;default:0" json:"demandaContratadaPonta"`
+	DemandaContratadaForaPonta float64 `gorm:"not null;default:0" json:"demandaContratadaForaPonta"`
+
+	Consumos []ConsumoMensal `gorm:"foreignKey:UnidadeID;constraint:OnDelete:CASCADE" json:"consumos,omitempty"`
+	Resumo   *ResumoConsumo  `gorm:"-" json:"resumo,omitempty"`
+}
+
+func (UnidadeConsumidora) TableName() string { return "unidades_consumidoras" }
+
+// ConsumoMensal é uma fatura mensal da unidade (um registro por mês).
+type ConsumoMensal struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UnidadeID  uint      `gorm:"not null;uniqueIndex:idx_consumo_mes" json:"unidadeId"`
+	Referencia time.Time `gorm:"type:date;not null;uniqueIndex:idx_consumo_mes" json:"referencia"` // dia 1 do mês
+
+	ConsumoPontaKWh     float64 `gorm:"column:consumo_ponta_kwh;not null;default:0" json:"consumoPontaKWh"`
+	ConsumoForaPontaKWh float64 `gorm:"column:consumo_fora_ponta_kwh;not null;default:0" json:"consumoForaPontaKWh"`
+	DemandaKW           float64 `gorm:"not null;default:0" json:"demandaKW"` // demanda medida
+	Valor               float64 `gorm:"not null;default:0" json:"valor"`     // total da fatura (R$)
+
+	Origem    string    `gorm:"size:10;not null" json:"origem"` // "manual" | "csv"
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (ConsumoMensal) TableName() string { return "consumos_mensais" }
+
+// TotalKWh é o consumo do mês (ponta + fora ponta).
+func (c ConsumoMensal) TotalKWh() float64 {
+	return c.ConsumoPontaKWh + c.ConsumoForaPontaKWh
+}
+
+// ResumoConsumo consolida o histórico usado em estudos e estimativas.
+type ResumoConsumo struct {
+	Meses               int     `json:"meses"`
+	TotalKWh            float64 `json:"totalKWh"`
+	MediaMensalKWh      float64 `json:"mediaMensalKWh"`
+	MediaMensalMWh      float64 `json:"mediaMensalMWh"`
+	ConsumoPontaKWh     float64 `json:"consumoPontaKWh"`
+	ConsumoForaPontaKWh float64 `json:"consumoForaPontaKWh"`
+	DemandaMaximaKW     float64 `json:"demandaMaximaKW"`
+	ValorTotal          float64 `json:"valorTotal"`
+}
+
+// Resumir consolida os meses informados.
+func Resumir(consumos []ConsumoMensal) ResumoConsumo {
+	var r ResumoConsumo
+	for _, c := range consumos {
+		r.Meses++
+		r.ConsumoPontaKWh += c.ConsumoPontaKWh
+		r.ConsumoForaPontaKWh += c.ConsumoForaPontaKWh
+		r.ValorTotal += c.Valor
+		r.DemandaMaximaKW = max(r.DemandaMaximaKW, c.DemandaKW)
+	}
+	r.TotalKWh = r.ConsumoPontaKWh + r.ConsumoForaPontaKWh
+	if r.Meses > 0 {
+		r.MediaMensalKWh = r.TotalKWh / float64(r.Meses)
+		r.MediaMensalMWh = r.MediaMensalKWh / 1000
+	}
+	return r
+}
+
+// inicioDoMes normaliza a referência para o dia 1 do mês.
+func inicioDoMes(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
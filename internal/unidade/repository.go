@@ -0,0 +1,92 @@
+// internal/unidade/repository.go
+package unidade
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// mesesHistorico é a janela padrão do histórico de consumo.
+const mesesHistorico = 12
+
+// Repository define operações de persistência de unidades e consumos
+type Repository interface {
+	Salvar(db *gorm.DB, u *UnidadeConsumidora) error
+	BuscarPorID(db *gorm.DB, id uint) (*UnidadeConsumidora, error)
+	ListarPorCliente(db *gorm.DB, clienteID uint) ([]UnidadeConsumidora, error)
+	ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]UnidadeConsumidora, error)
+	Deletar(db *gorm.DB, u *UnidadeConsumidora) error
+
+	Historico(db *gorm.DB, unidadeID uint, meses int, ate time.Time) ([]ConsumoMensal, error)
+	SalvarConsumos(db *gorm.DB, consumos []ConsumoMensal) error
+	RemoverConsumo(db *gorm.DB, unidadeID uint, referencia time.Time) (int64, error)
+}
+
+type repositoryImpl struct{}
+
+// NewRepository cria instância de Repository
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) Salvar(db *gorm.DB, u *UnidadeConsumidora) error {
+	return db.Omit("Consumos").Save(u).Error
+}
+
+func (r *repositoryImpl) BuscarPorID(db *gorm.DB, id uint) (*UnidadeConsumidora, error) {
+	var u UnidadeConsumidora
+	if err := db.First(&u, id).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *repositoryImpl) ListarPorCliente(db *gorm.DB, clienteID uint) ([]UnidadeConsumidora, error) {
+	list := []UnidadeConsumidora{}
+	err := db.Where("cliente_id = ?", clienteID).Order("distribuidora ASC, codigo_instalacao ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) ListarPorNegociacao(db *gorm.DB, negociacaoID uint) ([]UnidadeConsumidora, error) {
+	list := []UnidadeConsumidora{}
+	err := db.Where("negociacao_id = ?", negociacaoID).Order("distribuidora ASC, codigo_instalacao ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repositoryImpl) Deletar(db *gorm.DB, u *UnidadeConsumidora) error {
+	return db.Delete(u).Error
+}
+
+// Historico devolve os registros dos `meses` meses de calendário que terminam
+// no mês de `ate` (inclusive), do mais antigo para o mais recente. Meses sem
+// fatura ficam de fora em vez de puxar registros mais antigos para completar
+// a janela.
+func (r *repositoryImpl) Historico(db *gorm.DB, unidadeID uint, meses int, ate time.Time) ([]ConsumoMensal, error) {
+	fim := time.Date(ate.Year(), ate.Month()+1, 1, 0, 0, 0, 0, ate.Location())
+	inicio := fim.AddDate(0, -meses, 0)
+	list := []ConsumoMensal{}
+	err := db.Where("unidade_id = ? AND referencia >= ? AND referencia < ?", unidadeID, inicio, fim).
+		Order("referencia ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// SalvarConsumos grava os meses, substituindo os que já existem.
+func (r *repositoryImpl) SalvarConsumos(db *gorm.DB, consumos []ConsumoMensal) error {
+	if len(consumos) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "unidade_id"}, {Name: "referencia"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"consumo_ponta_kwh", "consumo_fora_ponta_kwh", "demanda_kw", "valor", "origem", "updated_at",
+		}),
+	}).Create(&consumos).Error
+}
+
+func (r *repositoryImpl) RemoverConsumo(db *gorm.DB, unidadeID uint, referencia time.Time) (int64, error) {
+	res := db.Where("unidade_id = ? AND referencia = ?", unidadeID, referencia).Delete(&ConsumoMensal{})
+	return res.RowsAffected, res.Error
+}
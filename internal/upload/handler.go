@@ -0,0 +1,174 @@
+// internal/upload/handler.go
+package upload
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/KromaEnergia/api-consultor/internal/auth"
+	"github.com/KromaEnergia/api-consultor/internal/comercial"
+	"github.com/KromaEnergia/api-consultor/internal/consultor"
+	"github.com/KromaEnergia/api-consultor/internal/documento"
+	"github.com/KromaEnergia/api-consultor/internal/escopo"
+	"github.com/KromaEnergia/api-consultor/internal/models"
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// Handler expõe os endpoints de upload (multipart, campo "arquivo").
+type Handler struct {
+	DB      *gorm.DB
+	Storage storage.Storage
+}
+
+// NewHandler cria o handler de uploads
+func NewHandler(db *gorm.DB, st storage.Storage) *Handler {
+	return &Handler{DB: db, Storage: st}
+}
+
+// POST /consultores/{id}/foto
+func (h *Handler) FotoConsultor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	if !ehDono(r, auth.AtorConsultor, uint(id)) {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	var c consultor.Consultor
+	if err := h.DB.Select("id").First(&c, id).Error; err != nil {
+		http.Error(w, "consultor não encontrado", http.StatusNotFound)
+		return
+	}
+
+	res, ok := h.receber(w, r, fmt.Sprintf("consultores/%d/foto", id), RegrasImagem)
+	if !ok {
+		return
+	}
+	if err := h.DB.Model(&c).Updates(map[string]any{
+		"foto":       res.URL,
+		"foto_thumb": res.ThumbnailURL,
+	}).Error; err != nil {
+		http.Error(w, "erro ao atualizar foto", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// POST /comerciais/{id}/foto
+func (h *Handler) FotoComercial(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	if !ehDono(r, auth.AtorComercial, uint(id)) {
+		http.Error(w, "acesso negado", http.StatusForbidden)
+		return
+	}
+
+	var c comercial.Comercial
+	if err := h.DB.Select("id").First(&c, id).Error; err != nil {
+		http.Error(w, "comercial não encontrado", http.StatusNotFound)
+		return
+	}
+
+	res, ok := h.receber(w, r, fmt.Sprintf("comerciais/%d/foto", id), RegrasImagem)
+	if !ok {
+		return
+	}
+	if err := h.DB.Model(&c).Updates(map[string]any{
+		"foto":       res.URL,
+		"foto_thumb": res.ThumbnailURL,
+	}).Error; err != nil {
+		http.Error(w, "erro ao atualizar foto", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// POST /negociacoes/{id}/logo
+func (h *Handler) LogoNegociacao(w http.ResponseWriter, r *http.Request) {
+	h.documentoNegociacao(w, r, models.DocLogo, RegrasImagem)
+}
+
+// POST /negociacoes/{id}/documentos/{tipo}/arquivo
+func (h *Handler) DocumentoNegociacao(w http.ResponseWriter, r *http.Request) {
+	h.documentoNegociacao(w, r, mux.Vars(r)["tipo"], RegrasDocumento)
+}
+
+func (h *Handler) documentoNegociacao(w http.ResponseWriter, r *http.Request, tipo string, regras Regras) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var neg models.Negociacao
+	if err := h.DB.Select("id", "consultor_id").First(&neg, id).Error; err != nil {
+		http.Error(w, "Negociação não encontrada", http.StatusNotFound)
+		return
+	}
+	e, err := escopo.DaRequisicao(h.DB, r)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	pode, err := e.AlcancaNegociacao(h.DB, neg.ID)
+	if err != nil {
+		http.Error(w, "Erro ao verificar permissões", http.StatusInternalServerError)
+		return
+	}
+	if !pode {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return
+	}
+
+	res, ok := h.receber(w, r, fmt.Sprintf("negociacoes/%d/%s", id, tipo), regras)
+	if !ok {
+		return
+	}
+	doc, err := documento.Definir(h.DB, neg.ID, tipo, res.URL, res.ThumbnailURL, models.StatusEnviado, auth.AtorDaRequisicao(r))
+	if err != nil {
+		documento.ResponderErro(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"arquivo":   res,
+		"documento": doc,
+	})
+}
+
+// ehDono: admin, ou o próprio usuário do tipo da rota (um consultor com o
+// mesmo ID numérico não é o comercial, e vice-versa).
+func ehDono(r *http.Request, tipo string, id uint) bool {
+	ator := auth.AtorDaRequisicao(r)
+	return ator.Admin || (ator.Tipo == tipo && ator.ID == id)
+}
+
+// receber lê, valida e grava a imagem; em caso de erro já responde.
+func (h *Handler) receber(w http.ResponseWriter, r *http.Request, prefixo string, regras Regras) (*Resultado, bool) {
+	arq, err := LerArquivo(w, r, "arquivo", regras)
+	if err != nil {
+		ResponderErro(w, err)
+		return nil, false
+	}
+	res, err := Salvar(r.Context(), h.Storage, prefixo, arq, regras)
+	if err != nil {
+		ResponderErro(w, err)
+		return nil, false
+	}
+	return res, true
+}
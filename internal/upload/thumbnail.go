@@ -0,0 +1,93 @@
+// internal/upload/thumbnail.go
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// decoders registrados para image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// TamanhoThumbnail é o maior lado (px) da miniatura gerada.
+const TamanhoThumbnail = 256
+
+// MaxPixelsImagem limita largura x altura das imagens decodificadas: um
+// arquivo pequeno pode declarar dimensões que ocupariam gigabytes em memória.
+const MaxPixelsImagem = 40_000_000
+
+// GerarThumbnail reduz a imagem para caber em max x max (mantendo a
+// proporção) usando média por área, e devolve um JPEG. Imagens menores que
+// o limite são apenas reencodadas; acima de MaxPixelsImagem, devolve
+// ErrImagemGrande sem decodificar.
+func GerarThumbnail(dados []byte, max int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(dados))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixelsImagem {
+		return nil, ErrImagemGrande
+	}
+	src, _, err := image.Decode(bytes.NewReader(dados))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	nw, nh := w, h
+	if w > max || h > max {
+		if w >= h {
+			nw, nh = max, h*max/w
+		} else {
+			nw, nh = w*max/h, max
+		}
+	}
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0 := b.Min.Y + y*h/nh
+		y1 := b.Min.Y + (y+1)*h/nh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < nw; x++ {
+			x0 := b.Min.X + x*w/nw
+			x1 := b.Min.X + (x+1)*w/nw
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			// fundo branco para PNG/GIF com transparência (JPEG não tem alfa)
+			alpha := a / n
+			branco := uint64(0xffff) - alpha
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r/n + branco),
+				G: uint16(g/n + branco),
+				B: uint16(bl/n + branco),
+				A: 0xffff,
+			})
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,174 @@
+// internal/upload/upload.go
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/KromaEnergia/api-consultor/internal/storage"
+)
+
+var (
+	ErrArquivoAusente   = errors.New("arquivo ausente no formulário")
+	ErrArquivoGrande    = errors.New("arquivo excede o tamanho máximo")
+	ErrTipoNaoPermitido = errors.New("tipo de arquivo não permitido")
+	ErrImagemGrande     = errors.New("imagem excede as dimensões máximas")
+)
+
+// Regras define o que um endpoint de upload aceita.
+type Regras struct {
+	TiposPermitidos []string // MIME detectados pelo conteúdo, não pela extensão
+	MaxBytes        int64    // 0 = usa UPLOAD_MAX_BYTES (default 5 MiB)
+	GerarThumbnail  bool
+}
+
+// RegrasImagem cobre foto de perfil e logo.
+var RegrasImagem = Regras{
+	TiposPermitidos: []string{"image/jpeg", "image/png", "image/gif"},
+	GerarThumbnail:  true,
+}
+
+// RegrasDocumento cobre os documentos do checklist da negociação.
+var RegrasDocumento = Regras{
+	TiposPermitidos: []string{"application/pdf", "image/jpeg", "image/png"},
+	GerarThumbnail:  true, // só para imagens
+}
+
+// Arquivo é o conteúdo já validado de um upload.
+type Arquivo struct {
+	Nome        string
+	ContentType string
+	Dados       []byte
+	Hash        string // sha256 hex do conteúdo
+}
+
+// Resultado é o que devolvemos ao front-end depois de gravar.
+type Resultado struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl,omitempty"`
+	ContentType  string `json:"contentType"`
+	Tamanho      int64  `json:"tamanho"`
+}
+
+var extensoes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"application/pdf": ".pdf",
+}
+
+func maxBytesPadrao() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return 5 << 20
+}
+
+// LerArquivo lê o campo multipart `campo`, aplicando limite de tamanho e
+// checagem de MIME pelo conteúdo (http.DetectContentType).
+func LerArquivo(w http.ResponseWriter, r *http.Request, campo string, regras Regras) (*Arquivo, error) {
+	max := regras.MaxBytes
+	if max <= 0 {
+		max = maxBytesPadrao()
+	}
+	// folga para os cabeçalhos do multipart
+	r.Body = http.MaxBytesReader(w, r.Body, max+(1<<20))
+	if err := r.ParseMultipartForm(max); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return nil, ErrArquivoGrande
+		}
+		return nil, ErrArquivoAusente
+	}
+
+	f, hdr, err := r.FormFile(campo)
+	if err != nil {
+		return nil, ErrArquivoAusente
+	}
+	defer f.Close()
+
+	dados, err := io.ReadAll(io.LimitReader(f, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(dados)) > max {
+		return nil, ErrArquivoGrande
+	}
+	if len(dados) == 0 {
+		return nil, ErrArquivoAusente
+	}
+
+	ct := http.DetectContentType(dados)
+	if i := strings.Index(ct, ";"); i >= 0 {
+		ct = ct[:i]
+	}
+	permitido := false
+	for _, t := range regras.TiposPermitidos {
+		if t == ct {
+			permitido = true
+			break
+		}
+	}
+	if !permitido {
+		return nil, ErrTipoNaoPermitido
+	}
+
+	sum := sha256.Sum256(dados)
+	return &Arquivo{
+		Nome:        hdr.Filename,
+		ContentType: ct,
+		Dados:       dados,
+		Hash:        hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// Salvar grava o arquivo em `prefixo/<hash><ext>`. Como a chave vem do
+// conteúdo, reenviar o mesmo arquivo devolve a mesma URL.
+func Salvar(ctx context.Context, st storage.Storage, prefixo string, arq *Arquivo, regras Regras) (*Resultado, error) {
+	base := strings.TrimSuffix(prefixo, "/") + "/" + arq.Hash[:32]
+	// a miniatura sai antes, para uma imagem recusada não ficar no storage
+	var thumb []byte
+	if regras.GerarThumbnail && strings.HasPrefix(arq.ContentType, "image/") {
+		var err error
+		if thumb, err = GerarThumbnail(arq.Dados, TamanhoThumbnail); err != nil {
+			return nil, err
+		}
+	}
+	url, err := st.Put(ctx, base+extensoes[arq.ContentType], bytes.NewReader(arq.Dados), int64(len(arq.Dados)), arq.ContentType)
+	if err != nil {
+		return nil, err
+	}
+	res := &Resultado{URL: url, ContentType: arq.ContentType, Tamanho: int64(len(arq.Dados))}
+
+	if thumb != nil {
+		res.ThumbnailURL, err = st.Put(ctx, base+"_thumb.jpg", bytes.NewReader(thumb), int64(len(thumb)), "image/jpeg")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ResponderErro traduz os erros de upload para o status HTTP adequado.
+func ResponderErro(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrArquivoAusente):
+		http.Error(w, "Envie o arquivo no campo 'arquivo' (multipart/form-data)", http.StatusBadRequest)
+	case errors.Is(err, ErrArquivoGrande):
+		http.Error(w, "Arquivo excede o tamanho máximo permitido", http.StatusRequestEntityTooLarge)
+	case errors.Is(err, ErrTipoNaoPermitido):
+		http.Error(w, "Tipo de arquivo não permitido", http.StatusUnsupportedMediaType)
+	case errors.Is(err, ErrImagemGrande):
+		http.Error(w, "Imagem excede as dimensões máximas permitidas", http.StatusRequestEntityTooLarge)
+	default:
+		http.Error(w, "Erro ao processar arquivo", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// NormalizarCNPJ mantém só os dígitos ("12.345.678/0001-90" → "12345678000190").
+func NormalizarCNPJ(cnpj string) string {
+	var b strings.Builder
+	for _, r := range cnpj {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrIfMatchAusente  = errors.New("cabeçalho If-Match obrigatório; envie o ETag da última leitura")
+	ErrIfMatchInvalido = errors.New("cabeçalho If-Match inválido")
+	ErrVersaoConflito  = errors.New("o registro foi alterado por outra pessoa; recarregue e tente novamente")
+)
+
+// ETag formata a versão do registro como ETag forte ("3").
+func ETag(versao uint) string {
+	return `"` + strconv.FormatUint(uint64(versao), 10) + `"`
+}
+
+// DefinirETag escreve o cabeçalho ETag da resposta.
+func DefinirETag(w http.ResponseWriter, versao uint) {
+	w.Header().Set("ETag", ETag(versao))
+}
+
+// ConferirIfMatch exige o If-Match e confere se algum dos ETags informados
+// corresponde à versão atual. "*" aceita qualquer versão.
+func ConferirIfMatch(r *http.Request, atual uint) error {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" {
+		return ErrIfMatchAusente
+	}
+	if v == "*" {
+		return nil
+	}
+	for _, tag := range strings.Split(v, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		n, err := strconv.ParseUint(strings.Trim(tag, `"`), 10, 64)
+		if err != nil {
+			return ErrIfMatchInvalido
+		}
+		if uint(n) == atual {
+			return nil
+		}
+	}
+	return ErrVersaoConflito
+}
+
+// ResponderErroVersao traduz os erros de versão (428, 400, 412). Devolve false
+// se o erro não for de versão, para o chamador tratar.
+func ResponderErroVersao(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, ErrIfMatchAusente):
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
+	case errors.Is(err, ErrIfMatchInvalido):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrVersaoConflito):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	default:
+		return false
+	}
+	return true
+}